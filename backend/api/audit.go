@@ -0,0 +1,239 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"redis-analyzer-api/audit"
+	"redis-analyzer-api/parser"
+	"redis-analyzer-api/semantic"
+)
+
+// DefaultAuditLimit es el número de entradas que devuelve /audit si no se
+// indica limit
+const DefaultAuditLimit = 100
+
+// SetAuditLog activa el registro de auditoría de los comandos ejecutados
+// (execute, scripts, borrado de claves y flush). nil lo desactiva.
+func (s *Server) SetAuditLog(auditLog *audit.Log) {
+	s.auditLog = auditLog
+}
+
+// recordAudit completa la entrada con el usuario, la dirección remota y los
+// comandos normalizados y la añade al log de auditoría. script indica que
+// entry.Command es un script con comentarios y no un único comando. Las
+// contraseñas se enmascaran antes de escribir la entrada. Los fallos del
+// sink no interrumpen la petición.
+func (s *Server) recordAudit(c *gin.Context, entry audit.Entry, validations []semantic.ValidationResult, script bool) {
+	if s.auditLog == nil {
+		return
+	}
+
+	principal := principalFrom(c)
+	entry.Time = time.Now()
+	entry.User = principal.Subject
+	entry.Role = principal.Role.String()
+	entry.RemoteAddr = c.ClientIP()
+	entry.Endpoint = c.Request.Method + " " + c.FullPath()
+
+	// Con errores de parseo se enmascaran igualmente los argumentos de los
+	// comandos que se hayan podido leer
+	commands, diagnostics := parseAuditCommands(entry.Command, script)
+	secrets := []parser.Expression{}
+	for _, cmd := range commands {
+		secrets = append(secrets, secretArguments(cmd)...)
+	}
+	entry.Command = maskSecrets(entry.Command, secrets)
+	if len(diagnostics) == 0 {
+		lines := []string{}
+		for _, cmd := range commands {
+			lines = append(lines, normalizeCommand(cmd, secrets))
+			entry.Commands = appendUnique(entry.Commands, strings.ToUpper(cmd.Command.Value))
+			for _, key := range s.analyzer.Keys(cmd) {
+				entry.Keys = appendUnique(entry.Keys, key.Name)
+			}
+		}
+		entry.Normalized = strings.Join(lines, "\n")
+	}
+
+	// Sin resultados de validación el comando no llegó a analizarse
+	entry.Valid = len(validations) > 0
+	for _, validation := range validations {
+		if !validation.Valid {
+			entry.Valid = false
+		}
+		for _, err := range validation.Errors {
+			entry.ValidationErrors = append(entry.ValidationErrors, err.Message)
+		}
+	}
+
+	if err := s.auditLog.Record(entry); err != nil {
+		log.Printf("Error escribiendo el log de auditoría: %v", err)
+	}
+}
+
+// validationList convierte el resultado de validación de un comando, que es nil
+// si no se pudo parsear, en la lista que espera recordAudit
+func validationList(validation *semantic.ValidationResult) []semantic.ValidationResult {
+	if validation == nil {
+		return nil
+	}
+	return []semantic.ValidationResult{*validation}
+}
+
+// parseAuditCommands parsea el texto de una entrada como un script o como un
+// único comando, igual que lo hizo el endpoint que la registra
+func parseAuditCommands(text string, script bool) ([]*parser.RedisCommand, []parser.Diagnostic) {
+	if script {
+		program, diagnostics := parser.ParseCommandsWithDiagnostics(text)
+		return program.Commands(), diagnostics
+	}
+	cmd, diagnostics := parser.ParseCommandWithDiagnostics(text)
+	if cmd == nil {
+		return nil, diagnostics
+	}
+	return []*parser.RedisCommand{cmd}, diagnostics
+}
+
+// maskedSecret sustituye a las contraseñas en las entradas de auditoría
+const maskedSecret = "***"
+
+// secretArguments devuelve los argumentos del comando que son contraseñas:
+// AUTH [username] password, HELLO ... AUTH username password y MIGRATE ...
+// AUTH password o AUTH2 username password
+func secretArguments(cmd *parser.RedisCommand) []parser.Expression {
+	args := cmd.Arguments
+	word := func(i int) string {
+		if lit, ok := args[i].(*parser.StringLiteral); ok {
+			return strings.ToUpper(lit.Value)
+		}
+		return strings.ToUpper(args[i].String())
+	}
+	// after devuelve los argumentos entre i+1 e i+n que existan
+	after := func(i, n int) []parser.Expression {
+		return args[min(i+1, len(args)):min(i+1+n, len(args))]
+	}
+
+	switch strings.ToUpper(cmd.Command.Value) {
+	case "AUTH":
+		if len(args) > 0 {
+			return args[len(args)-1:]
+		}
+	case "HELLO":
+		for i := 1; i < len(args); i++ {
+			if word(i) == "AUTH" {
+				return after(i+1, 1)
+			}
+		}
+	case "MIGRATE":
+		// Tras KEYS solo hay claves, que pueden llamarse AUTH
+		for i := 5; i < len(args) && word(i) != "KEYS"; i++ {
+			switch word(i) {
+			case "AUTH":
+				return after(i, 1)
+			case "AUTH2":
+				return after(i+1, 1)
+			}
+		}
+	}
+	return nil
+}
+
+// maskSecrets sustituye en el texto original los argumentos secrets por
+// maskedSecret
+func maskSecrets(text string, secrets []parser.Expression) string {
+	spans := make([]parser.Span, 0, len(secrets))
+	for _, secret := range secrets {
+		spans = append(spans, secret.Span())
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Start.Offset > spans[j].Start.Offset })
+	for _, span := range spans {
+		start, end := span.Start.Offset, min(span.End.Offset, len(text))
+		if start < 0 || start >= end {
+			continue
+		}
+		text = text[:start] + maskedSecret + text[end:]
+	}
+	return text
+}
+
+// normalizeCommand devuelve el comando con el nombre en mayúsculas y los
+// argumentos separados por un espacio, con maskedSecret en lugar de los
+// argumentos secrets
+func normalizeCommand(cmd *parser.RedisCommand, secrets []parser.Expression) string {
+	parts := []string{strings.ToUpper(cmd.Command.Value)}
+	for _, arg := range cmd.Arguments {
+		if containsExpression(secrets, arg) {
+			parts = append(parts, maskedSecret)
+			continue
+		}
+		parts = append(parts, arg.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+func containsExpression(expressions []parser.Expression, expression parser.Expression) bool {
+	for _, e := range expressions {
+		if e == expression {
+			return true
+		}
+	}
+	return false
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+// queryAudit devuelve las entradas recientes del log de auditoría. Acepta
+// los filtros since y until (RFC 3339), user, command y limit. Solo consulta
+// las entradas que el log conserva en memoria (audit.DefaultCapacity); las
+// anteriores están únicamente en el sink, y truncated avisa de que la
+// respuesta podría estar incompleta por ello.
+func (s *Server) queryAudit(c *gin.Context) {
+	if s.auditLog == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "audit log is disabled"})
+		return
+	}
+
+	query := audit.Query{
+		User:    c.Query("user"),
+		Command: c.Query("command"),
+		Limit:   DefaultAuditLimit,
+	}
+	for name, target := range map[string]*time.Time{"since": &query.Since, "until": &query.Until} {
+		if value := c.Query(name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name + ": expected an RFC 3339 time"})
+				return
+			}
+			*target = t
+		}
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit: expected a positive integer"})
+			return
+		}
+		query.Limit = limit
+	}
+
+	entries := s.auditLog.Query(query)
+	c.JSON(http.StatusOK, gin.H{
+		"entries":   entries,
+		"count":     len(entries),
+		"truncated": s.auditLog.Truncated(query, len(entries)),
+	})
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"redis-analyzer-api/audit"
+	"redis-analyzer-api/redis"
+)
+
+func TestAuditLog(t *testing.T) {
+	// Redis no está disponible: los comandos válidos fallan al ejecutarse,
+	// pero igualmente se auditan
+	server := NewServer(redis.Config{Host: "127.0.0.1", Port: 1})
+	keys, _ := ParseAPIKeys("o:operator:olga,a:admin:ana")
+	server.SetAuthenticator(keys)
+	var buf bytes.Buffer
+	server.SetAuditLog(audit.New(audit.NewWriterSink(&buf), 0))
+
+	request := func(method, path, body, apiKey string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", apiKey)
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+		return w
+	}
+
+	request("POST", "/api/v1/execute", `{"command": "get user:1"}`, "o")
+	request("POST", "/api/v1/execute", `{"command": "SET a"}`, "a")
+	request("POST", "/api/v1/scripts/execute", `{"script": "MULTI\nSET a 1\nINCR b\nEXEC"}`, "a")
+	request("DELETE", "/api/v1/keys/session:9", "", "a")
+	request("DELETE", "/api/v1/database/flush", "", "a")
+
+	if lines := strings.Count(buf.String(), "\n"); lines != 5 {
+		t.Fatalf("Expected 5 audit lines, got %d: %s", lines, buf.String())
+	}
+
+	if w := request("GET", "/api/v1/audit", "", "o"); w.Code != http.StatusForbidden {
+		t.Errorf("Expected operators to be denied, got %d", w.Code)
+	}
+
+	query := func(params string) []audit.Entry {
+		w := request("GET", "/api/v1/audit"+params, "", "a")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var response struct {
+			Entries []audit.Entry `json:"entries"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response.Entries
+	}
+
+	entries := query("?user=olga")
+	if len(entries) != 1 {
+		t.Fatalf("Expected one entry for olga, got %+v", entries)
+	}
+	get := entries[0]
+	if get.Role != "operator" || get.Command != "get user:1" || get.Normalized != `GET user:1` ||
+		len(get.Keys) != 1 || get.Keys[0] != "user:1" || !get.Valid || get.Success || get.Endpoint != "POST /api/v1/execute" {
+		t.Errorf("Unexpected entry: %+v", get)
+	}
+
+	entries = query("?command=SET")
+	if len(entries) != 2 {
+		t.Fatalf("Expected two entries with SET, got %+v", entries)
+	}
+	if script := entries[0]; len(script.Commands) != 4 || strings.Join(script.Keys, ",") != "a,b" {
+		t.Errorf("Unexpected script entry: %+v", script)
+	}
+	if invalid := entries[1]; invalid.Valid || len(invalid.ValidationErrors) == 0 {
+		t.Errorf("Expected an invalid entry with validation errors, got %+v", invalid)
+	}
+
+	if entries := query("?limit=2"); len(entries) != 2 || entries[0].Command != "FLUSHDB" || entries[1].Keys[0] != "session:9" {
+		t.Errorf("Expected the two most recent entries, got %+v", entries)
+	}
+	if entries := query("?since=2999-01-01T00:00:00Z"); len(entries) != 0 {
+		t.Errorf("Expected no entries in the future, got %+v", entries)
+	}
+	if w := request("GET", "/api/v1/audit?since=yesterday", "", "a"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid time, got %d", w.Code)
+	}
+}
+
+func TestDeleteKeyQuoting(t *testing.T) {
+	server := NewServer(redis.Config{Host: "127.0.0.1", Port: 1})
+	var buf bytes.Buffer
+	server.SetAuditLog(audit.New(audit.NewWriterSink(&buf), 0))
+
+	// La clave del path llega como un único argumento de DEL
+	tests := []struct {
+		path    string
+		key     string
+		command string
+	}{
+		{"/api/v1/keys/session:9", "session:9", "DEL session:9"},
+		{"/api/v1/keys/%23foo", "#foo", `DEL "#foo"`},
+		{"/api/v1/keys/a%20b", "a b", `DEL "a b"`},
+		{"/api/v1/keys/it's", "it's", `DEL "it's"`},
+		{"/api/v1/keys/a%22b", `a"b`, `DEL "a\"b"`},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest("DELETE", tt.path, nil)
+		server.router.ServeHTTP(httptest.NewRecorder(), req)
+
+		entries := server.auditLog.Query(audit.Query{Limit: 1})
+		if len(entries) != 1 {
+			t.Fatalf("%s: expected an audit entry, got %+v", tt.path, entries)
+		}
+		entry := entries[0]
+		if entry.Command != tt.command || !entry.Valid || len(entry.Keys) != 1 || entry.Keys[0] != tt.key {
+			t.Errorf("%s: expected %s on key %q, got %+v", tt.path, tt.command, tt.key, entry)
+		}
+	}
+}
+
+func TestAuditMasksPasswords(t *testing.T) {
+	server := NewServer(redis.Config{Host: "127.0.0.1", Port: 1})
+	var buf bytes.Buffer
+	server.SetAuditLog(audit.New(audit.NewWriterSink(&buf), 0))
+
+	tests := []struct {
+		path       string
+		body       string
+		command    string
+		normalized string
+	}{
+		{"/api/v1/execute", `{"command": "AUTH secret"}`, "AUTH ***", "AUTH ***"},
+		{"/api/v1/execute", `{"command": "auth user secret"}`, "auth user ***", "AUTH user ***"},
+		{"/api/v1/execute", `{"command": "HELLO 3 AUTH user \"my secret\" SETNAME app"}`, "HELLO 3 AUTH user *** SETNAME app", "HELLO 3 AUTH user *** SETNAME app"},
+		// Tras KEYS, AUTH es el nombre de una clave
+		{"/api/v1/execute", `{"command": "MIGRATE h 6379 \"\" 0 5000 AUTH2 u secret KEYS AUTH k"}`,
+			`MIGRATE h 6379 "" 0 5000 AUTH2 u *** KEYS AUTH k`, `MIGRATE h 6379 "" 0 5000 AUTH2 u *** KEYS AUTH k`},
+		{"/api/v1/scripts/execute", `{"script": "# migrar\nMIGRATE h 6379 k 0 5000 AUTH secret\nGET k"}`,
+			"# migrar\nMIGRATE h 6379 k 0 5000 AUTH ***\nGET k", "MIGRATE h 6379 k 0 5000 AUTH ***\nGET k"},
+		{"/api/v1/execute", `{"command": "GET #secret"}`, "GET #secret", "GET #secret"},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest("POST", tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		server.router.ServeHTTP(httptest.NewRecorder(), req)
+
+		entries := server.auditLog.Query(audit.Query{Limit: 1})
+		if len(entries) != 1 {
+			t.Fatalf("%s: expected an audit entry, got %+v", tt.body, entries)
+		}
+		if entry := entries[0]; entry.Command != tt.command || entry.Normalized != tt.normalized {
+			t.Errorf("%s: expected %q (%q), got %q (%q)", tt.body, tt.command, tt.normalized, entry.Command, entry.Normalized)
+		}
+	}
+
+	// Solo llega al sink el argumento de GET, que no es una contraseña, en
+	// el comando, el normalizado y las claves
+	if count := strings.Count(buf.String(), "secret"); count != 3 {
+		t.Errorf("Expected only GET #secret in the sink, got:\n%s", buf.String())
+	}
+}
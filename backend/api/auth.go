@@ -0,0 +1,250 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"redis-analyzer-api/parser"
+	"redis-analyzer-api/semantic"
+)
+
+// Role es el nivel de acceso de un usuario de la API. Cada rol incluye los
+// permisos de los anteriores.
+type Role int
+
+const (
+	RoleViewer   Role = iota + 1 // analizar comandos sin ejecutarlos
+	RoleOperator                 // ejecutar comandos de lectura
+	RoleAdmin                    // ejecutar escrituras y administración, flush y borrado de claves
+)
+
+// principalKey es la clave del contexto de gin donde se guarda el Principal
+const principalKey = "principal"
+
+// ErrNoCredentials indica que la petición no trae credenciales del tipo que
+// espera un Authenticator
+var ErrNoCredentials = errors.New("no credentials provided")
+
+// ParseRole convierte el nombre de un rol (viewer, operator o admin)
+func ParseRole(name string) (Role, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "viewer":
+		return RoleViewer, nil
+	case "operator":
+		return RoleOperator, nil
+	case "admin":
+		return RoleAdmin, nil
+	}
+	return 0, fmt.Errorf("unknown role %q (expected viewer, operator or admin)", name)
+}
+
+func (r Role) String() string {
+	switch r {
+	case RoleViewer:
+		return "viewer"
+	case RoleOperator:
+		return "operator"
+	case RoleAdmin:
+		return "admin"
+	}
+	return "none"
+}
+
+// roleForCategory devuelve el rol mínimo para ejecutar comandos de la
+// categoría dada
+func roleForCategory(category semantic.Category) Role {
+	if category == semantic.CategoryRead {
+		return RoleOperator
+	}
+	return RoleAdmin
+}
+
+// Principal identifica al usuario autenticado
+type Principal struct {
+	Subject string
+	Role    Role
+}
+
+// Authenticator valida las credenciales de una petición
+type Authenticator interface {
+	// Authenticate devuelve ErrNoCredentials si la petición no trae
+	// credenciales que este autenticador sepa validar
+	Authenticate(r *http.Request) (Principal, error)
+}
+
+// Authenticators prueba varios autenticadores en orden y usa el primero
+// que encuentre credenciales
+type Authenticators []Authenticator
+
+func (a Authenticators) Authenticate(r *http.Request) (Principal, error) {
+	for _, auth := range a {
+		principal, err := auth.Authenticate(r)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return principal, err
+	}
+	return Principal{}, ErrNoCredentials
+}
+
+// APIKeys autentica con claves estáticas enviadas en la cabecera X-API-Key
+type APIKeys map[string]Principal
+
+// ParseAPIKeys lee una lista "clave:rol[:nombre],..." de claves estáticas
+func ParseAPIKeys(list string) (APIKeys, error) {
+	keys := APIKeys{}
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) < 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid API key entry %q (expected key:role[:name])", entry)
+		}
+		role, err := ParseRole(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		subject := "api-key-" + parts[1]
+		if len(parts) == 3 && parts[2] != "" {
+			subject = parts[2]
+		}
+		keys[parts[0]] = Principal{Subject: subject, Role: role}
+	}
+	return keys, nil
+}
+
+func (k APIKeys) Authenticate(r *http.Request) (Principal, error) {
+	key := r.Header.Get("X-API-Key")
+	if key == "" {
+		return Principal{}, ErrNoCredentials
+	}
+	// Comparar con todas las claves en tiempo constante
+	var found Principal
+	ok := false
+	for candidate, principal := range k {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(key)) == 1 {
+			found, ok = principal, true
+		}
+	}
+	if !ok {
+		return Principal{}, fmt.Errorf("invalid API key")
+	}
+	return found, nil
+}
+
+// SetAuthenticator activa la autenticación de la API. Sin autenticador
+// (por defecto) todas las peticiones tienen rol admin.
+func (s *Server) SetAuthenticator(auth Authenticator) {
+	s.authenticator = auth
+}
+
+// authenticate valida las credenciales de la petición y guarda el
+// Principal en el contexto de gin
+func (s *Server) authenticate(c *gin.Context) {
+	if s.authenticator == nil {
+		c.Set(principalKey, Principal{Subject: "anonymous", Role: RoleAdmin})
+		c.Next()
+		return
+	}
+
+	principal, err := s.authenticator.Authenticate(c.Request)
+	if err != nil {
+		c.Header("WWW-Authenticate", `Bearer realm="redis-analyzer"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required: " + err.Error()})
+		return
+	}
+	c.Set(principalKey, principal)
+	c.Next()
+}
+
+// principalFrom devuelve el usuario autenticado de la petición
+func principalFrom(c *gin.Context) Principal {
+	if value, ok := c.Get(principalKey); ok {
+		if principal, ok := value.(Principal); ok {
+			return principal
+		}
+	}
+	return Principal{}
+}
+
+// authorize comprueba que el usuario tenga al menos el rol indicado y, si
+// no, responde 403
+func (s *Server) authorize(c *gin.Context, role Role) bool {
+	principal := principalFrom(c)
+	if principal.Role >= role {
+		return true
+	}
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+		"error":         fmt.Sprintf("role %s is not allowed to perform this operation", principal.Role),
+		"required_role": role.String(),
+	})
+	return false
+}
+
+// requireRole es un middleware que exige un rol mínimo
+func (s *Server) requireRole(role Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.authorize(c, role) {
+			c.Next()
+		}
+	}
+}
+
+// requireCommands es un middleware para los endpoints que ejecutan comandos
+// fijos: exige el rol que corresponde a la categoría de esos comandos, que
+// se busca por nombre en la tabla del analizador. Un comando que no está en
+// la tabla es un error de programación y detiene el arranque.
+func (s *Server) requireCommands(names ...string) gin.HandlerFunc {
+	for _, name := range names {
+		if _, ok := s.analyzer.GetCommandSpecs()[name]; !ok {
+			panic(fmt.Sprintf("api: unknown route command %q", name))
+		}
+	}
+	s.routeCommands = append(s.routeCommands, names...)
+	
+	return func(c *gin.Context) {
+		role := RoleViewer
+		for _, name := range names {
+			// El rol se calcula en cada petición porque la tabla de comandos
+			// puede cargarse después de crear las rutas
+			category := semantic.CategoryAdmin
+			if spec, ok := s.analyzer.GetCommandSpecs()[name]; ok {
+				category = spec.Category()
+			}
+			role = maxRole(role, roleForCategory(category))
+		}
+		if s.authorize(c, role) {
+			c.Next()
+		}
+	}
+}
+
+// commandsRole devuelve el rol necesario para ejecutar todos los comandos
+// de un programa, incluidos los encolados en bloques MULTI/EXEC
+func (s *Server) commandsRole(program *parser.Program) Role {
+	role := RoleOperator
+	for _, stmt := range program.Statements {
+		switch st := stmt.(type) {
+		case *parser.RedisCommand:
+			role = maxRole(role, roleForCategory(s.analyzer.CommandCategory(st)))
+		case *parser.TransactionBlock:
+			for _, cmd := range st.AllCommands() {
+				role = maxRole(role, roleForCategory(s.analyzer.CommandCategory(cmd)))
+			}
+		}
+	}
+	return role
+}
+
+func maxRole(a, b Role) Role {
+	if a > b {
+		return a
+	}
+	return b
+}
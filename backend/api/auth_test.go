@@ -0,0 +1,279 @@
+package api
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"redis-analyzer-api/redis"
+)
+
+func TestParseAPIKeys(t *testing.T) {
+	keys, err := ParseAPIKeys("k1:viewer, k2:admin:deploy-bot,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys["k1"].Role != RoleViewer || keys["k2"].Role != RoleAdmin || keys["k2"].Subject != "deploy-bot" {
+		t.Errorf("Unexpected keys: %+v", keys)
+	}
+
+	for _, invalid := range []string{"k1", ":admin", "k1:root"} {
+		if _, err := ParseAPIKeys(invalid); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestTokenAuthenticator(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	secret := []byte("test-secret")
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// JWKS local con una clave RSA y otra EC
+	jwks := map[string]interface{}{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa-1", "n": segment(rsaKey.N.Bytes()), "e": segment(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": segment(ecKey.X.Bytes()), "y": segment(ecKey.Y.Bytes())},
+	}}
+	data, _ := json.Marshal(jwks)
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(jwksFile, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := LoadJWKS(jwksFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth := &TokenAuthenticator{Secret: secret, Keys: keys, Audience: "redis-analyzer", now: func() time.Time { return now }}
+	claims := func(extra map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{"sub": "alice", "role": "operator", "aud": "redis-analyzer", "exp": now.Add(time.Hour).Unix()}
+		for k, v := range extra {
+			c[k] = v
+		}
+		return c
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		role  Role
+	}{
+		{name: "HS256", token: signHMAC(t, "HS256", secret, claims(nil)), role: RoleOperator},
+		{name: "RS256", token: signRSA(t, "rsa-1", rsaKey, claims(map[string]interface{}{"role": "admin"})), role: RoleAdmin},
+		{name: "ES256", token: signEC(t, "ec-1", ecKey, claims(map[string]interface{}{"role": []string{"viewer", "operator"}})), role: RoleOperator},
+		{name: "Expired", token: signHMAC(t, "HS256", secret, claims(map[string]interface{}{"exp": now.Add(-time.Minute).Unix()}))},
+		{name: "Wrong secret", token: signHMAC(t, "HS256", []byte("other"), claims(nil))},
+		{name: "Wrong audience", token: signHMAC(t, "HS256", secret, claims(map[string]interface{}{"aud": "other"}))},
+		{name: "Unknown role", token: signHMAC(t, "HS256", secret, claims(map[string]interface{}{"role": "root"}))},
+		{name: "Unsigned", token: encodeToken(t, map[string]string{"alg": "none"}, claims(nil), nil)},
+		{name: "HMAC with public key", token: signHMAC(t, "HS256", rsaKey.N.Bytes(), claims(nil), "rsa-1")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			principal, err := auth.Authenticate(req)
+			if tt.role == 0 {
+				if err == nil {
+					t.Errorf("Expected the token to be rejected, got %+v", principal)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if principal.Role != tt.role || principal.Subject != "alice" {
+				t.Errorf("Expected alice with role %s, got %+v", tt.role, principal)
+			}
+		})
+	}
+}
+
+func TestRoleAuthorization(t *testing.T) {
+	// Redis no está disponible: las peticiones autorizadas fallan al
+	// ejecutar, pero nunca con 401 o 403
+	server := NewServer(redis.Config{Host: "127.0.0.1", Port: 1})
+	keys, _ := ParseAPIKeys("v:viewer,o:operator,a:admin")
+	server.SetAuthenticator(Authenticators{keys, &TokenAuthenticator{Secret: []byte("s")}})
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		apiKey   string
+		expected int // 0 = cualquier código salvo 401 y 403
+	}{
+		{name: "Health is public", method: "GET", path: "/api/v1/health", expected: http.StatusOK},
+		{name: "Missing credentials", method: "POST", path: "/api/v1/analyze", body: `{"command": "GET a"}`, expected: http.StatusUnauthorized},
+		{name: "Invalid key", method: "POST", path: "/api/v1/analyze", body: `{"command": "GET a"}`, apiKey: "x", expected: http.StatusUnauthorized},
+		{name: "Viewer analyzes", method: "POST", path: "/api/v1/analyze", body: `{"command": "FLUSHALL"}`, apiKey: "v", expected: http.StatusOK},
+		{name: "Viewer cannot read", method: "POST", path: "/api/v1/execute", body: `{"command": "GET a"}`, apiKey: "v", expected: http.StatusForbidden},
+		{name: "Operator reads", method: "POST", path: "/api/v1/execute", body: `{"command": "GET a"}`, apiKey: "o"},
+		{name: "Operator cannot write", method: "POST", path: "/api/v1/execute", body: `{"command": "SET a 1"}`, apiKey: "o", expected: http.StatusForbidden},
+		{name: "Operator cannot configure", method: "POST", path: "/api/v1/execute", body: `{"command": "CONFIG SET maxmemory 1mb"}`, apiKey: "o", expected: http.StatusForbidden},
+		{name: "Operator script with write", method: "POST", path: "/api/v1/scripts/execute", body: `{"script": "GET a\nMULTI\nSET a 1\nEXEC"}`, apiKey: "o", expected: http.StatusForbidden},
+		{name: "Operator read script", method: "POST", path: "/api/v1/scripts/execute", body: `{"script": "GET a\nMULTI\nGET b\nEXEC"}`, apiKey: "o"},
+		{name: "Operator lists keys", method: "GET", path: "/api/v1/keys", apiKey: "o"},
+		{name: "Viewer cannot read key info", method: "GET", path: "/api/v1/keys/a", apiKey: "v", expected: http.StatusForbidden},
+		{name: "Operator reads key info", method: "GET", path: "/api/v1/keys/a", apiKey: "o"},
+		{name: "Operator cannot delete", method: "DELETE", path: "/api/v1/keys/a", apiKey: "o", expected: http.StatusForbidden},
+		{name: "Operator cannot flush", method: "DELETE", path: "/api/v1/database/flush", apiKey: "o", expected: http.StatusForbidden},
+		{name: "Admin writes", method: "POST", path: "/api/v1/execute", body: `{"command": "SET a 1"}`, apiKey: "a"},
+		{name: "Admin flushes", method: "DELETE", path: "/api/v1/database/flush", apiKey: "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.apiKey != "" {
+				req.Header.Set("X-API-Key", tt.apiKey)
+			}
+			w := httptest.NewRecorder()
+
+			server.router.ServeHTTP(w, req)
+
+			switch {
+			case tt.expected != 0 && w.Code != tt.expected:
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			case tt.expected == 0 && (w.Code == http.StatusUnauthorized || w.Code == http.StatusForbidden):
+				t.Errorf("Expected the request to be authorized, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouteCommandRoles(t *testing.T) {
+	server := NewServer(redis.Config{Host: "127.0.0.1", Port: 1})
+	keys, _ := ParseAPIKeys("v:viewer")
+	server.SetAuthenticator(keys)
+
+	// TYPE y TTL son comandos de lectura: la ruta exige operator, no admin
+	req, _ := http.NewRequest("GET", "/api/v1/keys/a", nil)
+	req.Header.Set("X-API-Key", "v")
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusForbidden || response["required_role"] != "operator" {
+		t.Errorf("Expected 403 requiring operator, got %d: %s", w.Code, w.Body.String())
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Expected an unknown route command to panic")
+			}
+		}()
+		server.requireCommands("TYPE k")
+	}()
+
+	// Una tabla de comandos sin los comandos de las rutas no se acepta
+	path := filepath.Join(t.TempDir(), "commands.json")
+	if err := os.WriteFile(path, []byte(`{"PING": {"summary": "Ping", "group": "connection", "arity": -1}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.LoadCommandsFile(path); err == nil || !strings.Contains(err.Error(), "missing command") {
+		t.Errorf("Expected an error for the missing route commands, got %v", err)
+	}
+}
+
+func TestCORSAllowedOrigins(t *testing.T) {
+	server := NewServer(redis.Config{Host: "localhost", Port: 6379})
+	server.SetAllowedOrigins([]string{"https://app.example.com"})
+
+	for origin, expected := range map[string]string{
+		"https://app.example.com":  "https://app.example.com",
+		"https://evil.example.com": "",
+	} {
+		req, _ := http.NewRequest("OPTIONS", "/api/v1/health", nil)
+		req.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+
+		server.router.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != expected {
+			t.Errorf("Origin %s: expected %q, got %q", origin, expected, got)
+		}
+	}
+}
+
+// segment codifica bytes en base64url sin relleno
+func segment(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// encodeToken construye un JWT con la firma dada
+func encodeToken(t *testing.T, header map[string]string, claims map[string]interface{}, signature []byte) string {
+	t.Helper()
+	h, _ := json.Marshal(header)
+	c, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return segment(h) + "." + segment(c) + "." + segment(signature)
+}
+
+// signingInput devuelve la parte firmada de un token sin firma
+func signingInput(token string) string {
+	return token[:strings.LastIndex(token, ".")]
+}
+
+func signHMAC(t *testing.T, alg string, secret []byte, claims map[string]interface{}, kid ...string) string {
+	header := map[string]string{"alg": alg}
+	if len(kid) > 0 {
+		header["kid"] = kid[0]
+	}
+	unsigned := signingInput(encodeToken(t, header, claims, nil))
+	mac := hmac.New(crypto.SHA256.New, secret)
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + segment(mac.Sum(nil))
+}
+
+func signRSA(t *testing.T, kid string, key *rsa.PrivateKey, claims map[string]interface{}) string {
+	unsigned := signingInput(encodeToken(t, map[string]string{"alg": "RS256", "kid": kid}, claims, nil))
+	digest := crypto.SHA256.New()
+	digest.Write([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest.Sum(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return unsigned + "." + segment(signature)
+}
+
+func signEC(t *testing.T, kid string, key *ecdsa.PrivateKey, claims map[string]interface{}) string {
+	unsigned := signingInput(encodeToken(t, map[string]string{"alg": "ES256", "kid": kid}, claims, nil))
+	digest := crypto.SHA256.New()
+	digest.Write([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest.Sum(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return unsigned + "." + segment(signature)
+}
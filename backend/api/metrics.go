@@ -0,0 +1,151 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"redis-analyzer-api/metrics"
+	"redis-analyzer-api/redis"
+	"redis-analyzer-api/semantic"
+)
+
+// redisBuckets son los límites del histograma de latencia de Redis, más
+// finos que los de las peticiones HTTP
+var redisBuckets = []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1}
+
+// serverMetrics contiene las métricas que exporta /metrics
+type serverMetrics struct {
+	registry        *metrics.Registry
+	requests        *metrics.Counter   // method, route, status
+	requestDuration *metrics.Histogram // method, route
+	analyses        *metrics.Counter   // result
+	analyzeErrors   *metrics.Counter   // type
+	executions      *metrics.Counter   // command, result
+	redisDuration   *metrics.Histogram // command
+}
+
+// newServerMetrics registra las métricas del servidor y las del pool de
+// conexiones del cliente
+func newServerMetrics(client *redis.Client) *serverMetrics {
+	registry := metrics.NewRegistry()
+	m := &serverMetrics{
+		registry: registry,
+		requests: registry.NewCounter("redis_analyzer_http_requests_total",
+			"HTTP requests by method, route and status code.", "method", "route", "status"),
+		requestDuration: registry.NewHistogram("redis_analyzer_http_request_duration_seconds",
+			"HTTP request latency by method and route.", nil, "method", "route"),
+		analyses: registry.NewCounter("redis_analyzer_analyze_total",
+			"Commands analyzed by /analyze, by result (valid or invalid).", "result"),
+		analyzeErrors: registry.NewCounter("redis_analyzer_analyze_errors_total",
+			"Errors reported by /analyze, by error type.", "type"),
+		executions: registry.NewCounter("redis_analyzer_command_executions_total",
+			"Executed Redis commands by command name and result (success or failure).", "command", "result"),
+		redisDuration: registry.NewHistogram("redis_analyzer_redis_command_duration_seconds",
+			"Round-trip latency of commands sent to Redis, by command name.", redisBuckets, "command"),
+	}
+
+	pool := func(field func(redis.PoolStats) uint32) func() float64 {
+		return func() float64 { return float64(field(client.PoolStats())) }
+	}
+	registry.NewCounterFunc("redis_analyzer_redis_pool_hits_total",
+		"Times a free connection was found in the pool.", pool(func(s redis.PoolStats) uint32 { return s.Hits }))
+	registry.NewCounterFunc("redis_analyzer_redis_pool_misses_total",
+		"Times a new connection had to be opened.", pool(func(s redis.PoolStats) uint32 { return s.Misses }))
+	registry.NewCounterFunc("redis_analyzer_redis_pool_timeouts_total",
+		"Times waiting for a pool connection timed out.", pool(func(s redis.PoolStats) uint32 { return s.Timeouts }))
+	registry.NewCounterFunc("redis_analyzer_redis_pool_stale_connections_total",
+		"Stale connections removed from the pool.", pool(func(s redis.PoolStats) uint32 { return s.StaleConns }))
+	registry.NewGaugeFunc("redis_analyzer_redis_pool_connections",
+		"Open connections in the pool.", pool(func(s redis.PoolStats) uint32 { return s.TotalConns }))
+	registry.NewGaugeFunc("redis_analyzer_redis_pool_idle_connections",
+		"Idle connections in the pool.", pool(func(s redis.PoolStats) uint32 { return s.IdleConns }))
+
+	return m
+}
+
+// instrument mide el número y la latencia de las peticiones por ruta. Las
+// rutas se identifican por su patrón (/api/v1/keys/:key) para no crear una
+// serie por clave.
+func (s *Server) instrument(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	method := c.Request.Method
+	s.metrics.requests.Inc(method, route, strconv.Itoa(c.Writer.Status()))
+	s.metrics.requestDuration.Observe(time.Since(start).Seconds(), method, route)
+}
+
+// serveMetrics exporta las métricas en formato de texto de Prometheus
+func (s *Server) serveMetrics(c *gin.Context) {
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Status(http.StatusOK)
+	s.metrics.registry.Write(c.Writer)
+}
+
+// observeAnalysis registra el resultado de un análisis y sus errores por
+// tipo. Los errores de parseo se cuentan como PARSE_ERROR.
+func (s *Server) observeAnalysis(parseErrors []string, validation *semantic.ValidationResult) {
+	valid := len(parseErrors) == 0 && validation != nil && validation.Valid
+	if valid {
+		s.metrics.analyses.Inc("valid")
+	} else {
+		s.metrics.analyses.Inc("invalid")
+	}
+	if len(parseErrors) > 0 {
+		s.metrics.analyzeErrors.Add(float64(len(parseErrors)), "PARSE_ERROR")
+	}
+	if validation != nil {
+		for _, err := range validation.Errors {
+			s.metrics.analyzeErrors.Inc(err.Type)
+		}
+	}
+}
+
+// observeExecution registra la ejecución de un comando. La latencia solo se
+// mide para los comandos que llegaron a enviarse a Redis.
+func (s *Server) observeExecution(result redis.ExecutionResult) {
+	command := s.commandLabel(result.Command)
+	if result.Success {
+		s.metrics.executions.Inc(command, "success")
+	} else {
+		s.metrics.executions.Inc(command, "failure")
+	}
+	if result.Validation != nil && result.Validation.Valid {
+		s.metrics.redisDuration.Observe(result.ExecutionTime.Seconds(), command)
+	}
+}
+
+// observeProgram registra cada comando ejecutado de un programa
+func (s *Server) observeProgram(result redis.ProgramResult) {
+	for _, stmt := range result.Statements {
+		if stmt.Command != nil {
+			s.observeExecution(*stmt.Command)
+		}
+		if stmt.Transaction != nil {
+			for _, cmd := range stmt.Transaction.Results {
+				s.observeExecution(cmd)
+			}
+		}
+	}
+}
+
+// commandLabel devuelve el nombre del comando en mayúsculas, o UNKNOWN si el
+// analizador no lo conoce, para que un nombre arbitrario no cree una serie
+func (s *Server) commandLabel(input string) string {
+	fields := strings.Fields(input)
+	if len(fields) == 0 {
+		return "UNKNOWN"
+	}
+	name := strings.ToUpper(fields[0])
+	if _, known := s.analyzer.GetCommandSpecs()[name]; !known {
+		return "UNKNOWN"
+	}
+	return name
+}
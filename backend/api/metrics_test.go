@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"redis-analyzer-api/redis"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	// Redis no está disponible: las ejecuciones válidas fallan
+	server := NewServer(redis.Config{Host: "127.0.0.1", Port: 1})
+
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+		return w
+	}
+
+	request("POST", "/api/v1/analyze", `{"command": "GET user:1"}`)
+	request("POST", "/api/v1/analyze", `{"command": "FOO bar"}`)
+	request("POST", "/api/v1/analyze", `{"command": "SET a \"unterminated"}`)
+	request("POST", "/api/v1/execute", `{"command": "GET a"}`)
+	request("POST", "/api/v1/execute", `{"command": "NOSUCH a"}`)
+	request("GET", "/api/v1/keys/session:1", "")
+
+	w := request("GET", "/metrics", "")
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Fatalf("Unexpected response: %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	body := w.Body.String()
+
+	for _, line := range []string{
+		`redis_analyzer_http_requests_total{method="POST",route="/api/v1/analyze",status="200"} 3`,
+		`redis_analyzer_http_request_duration_seconds_count{method="GET",route="/api/v1/keys/:key"} 1`,
+		`redis_analyzer_analyze_total{result="valid"} 1`,
+		`redis_analyzer_analyze_total{result="invalid"} 2`,
+		`redis_analyzer_analyze_errors_total{type="UNKNOWN_COMMAND"} 1`,
+		`redis_analyzer_analyze_errors_total{type="PARSE_ERROR"} 1`,
+		`redis_analyzer_command_executions_total{command="GET",result="failure"} 1`,
+		`redis_analyzer_command_executions_total{command="UNKNOWN",result="failure"} 1`,
+		`redis_analyzer_redis_command_duration_seconds_count{command="GET"} 1`,
+		`# TYPE redis_analyzer_redis_pool_connections gauge`,
+		`# TYPE redis_analyzer_redis_pool_hits_total counter`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("Expected %q in the metrics output:\n%s", line, body)
+		}
+	}
+	if strings.Contains(body, `command="NOSUCH"`) {
+		t.Errorf("Unknown command names must not create series")
+	}
+}
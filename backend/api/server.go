@@ -1,35 +1,113 @@
 package api
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 	
 	"github.com/gin-gonic/gin"
+	"redis-analyzer-api/audit"
+	"redis-analyzer-api/lexer"
 	"redis-analyzer-api/redis"
 	"redis-analyzer-api/parser"
+	"redis-analyzer-api/policy"
 	"redis-analyzer-api/semantic"
 )
 
+// DefaultRequestTimeout es el plazo por defecto de las operaciones contra
+// Redis de cada petición
+const DefaultRequestTimeout = 30 * time.Second
+
+// Timeouts por defecto del servidor HTTP. WriteTimeout supera a
+// DefaultRequestTimeout para que una petición lenta pueda responder 504.
+const (
+	DefaultReadTimeout  = 15 * time.Second
+	DefaultWriteTimeout = 60 * time.Second
+	DefaultIdleTimeout  = 120 * time.Second
+)
+
+// HTTPConfig configura el servidor HTTP. Un timeout 0 no impone límite.
+type HTTPConfig struct {
+	ReadTimeout  time.Duration // lectura de la petición completa
+	WriteTimeout time.Duration // desde el fin de la lectura hasta escribir la respuesta
+	IdleTimeout  time.Duration // espera de la siguiente petición en conexiones keep-alive
+	TLSCertFile  string        // certificado PEM; activa HTTPS junto con TLSKeyFile
+	TLSKeyFile   string
+}
+
+// DefaultHTTPConfig devuelve la configuración con los timeouts por defecto y
+// sin TLS
+func DefaultHTTPConfig() HTTPConfig {
+	return HTTPConfig{
+		ReadTimeout:  DefaultReadTimeout,
+		WriteTimeout: DefaultWriteTimeout,
+		IdleTimeout:  DefaultIdleTimeout,
+	}
+}
+
+// Validate comprueba que el certificado y la clave TLS se indiquen juntos y
+// se puedan cargar
+func (c HTTPConfig) Validate() error {
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return fmt.Errorf("TLS requires both a certificate and a key file")
+	}
+	if c.TLSCertFile != "" {
+		if _, err := tls.LoadX509KeyPair(c.TLSCertFile, c.TLSKeyFile); err != nil {
+			return fmt.Errorf("invalid TLS certificate: %w", err)
+		}
+	}
+	return nil
+}
+
 // Server representa el servidor API
 type Server struct {
-	router      *gin.Engine
-	redisClient *redis.Client
-	analyzer    *semantic.Analyzer
+	router         *gin.Engine
+	redisClient    *redis.Client
+	analyzer       *semantic.Analyzer
+	requestTimeout time.Duration
+	authenticator  Authenticator // nil desactiva la autenticación
+	allowedOrigins []string      // orígenes CORS permitidos; vacío permite cualquiera
+	auditLog       *audit.Log    // nil desactiva la auditoría
+	metrics        *serverMetrics
+	httpConfig     HTTPConfig
+	routeCommands  []string      // comandos fijos de las rutas (requireCommands)
+	
+	mu         sync.Mutex
+	httpServer *http.Server // creado por Serve
+	closed     bool         // Shutdown ya se llamó
 }
 
 // AnalyzeRequest representa una solicitud de análisis
 type AnalyzeRequest struct {
 	Command string `json:"command" binding:"required"`
+	Format  string `json:"format"`
 }
 
 // AnalyzeResponse representa la respuesta del análisis
 type AnalyzeResponse struct {
 	Valid        bool                           `json:"valid"`
 	ParsedAST    string                        `json:"parsed_ast"`
+	AST          *parser.RedisCommand          `json:"ast,omitempty"`
 	Validation   *semantic.ValidationResult    `json:"validation"`
 	CommandInfo  map[string]interface{}        `json:"command_info"`
 	ParseErrors  []string                      `json:"parse_errors,omitempty"`
+	Diagnostics  []parser.Diagnostic           `json:"diagnostics"`
+	Keys         []KeySlotInfo                 `json:"keys"`
+}
+
+// KeySlotInfo representa una clave del comando analizado y su slot en
+// Redis Cluster
+type KeySlotInfo struct {
+	Key     string      `json:"key"`
+	HashTag string      `json:"hash_tag"`
+	Slot    int         `json:"slot"`
+	Span    parser.Span `json:"span"`
 }
 
 // ExecuteRequest representa una solicitud de ejecución
@@ -41,11 +119,43 @@ type ExecuteRequest struct {
 type ExecuteResponse struct {
 	Success       bool                        `json:"success"`
 	Result        interface{}                 `json:"result,omitempty"`
+	Reply         *redis.Reply                `json:"reply,omitempty"`
 	Error         string                      `json:"error,omitempty"`
 	ExecutionTime string                      `json:"execution_time"`
 	Validation    *semantic.ValidationResult `json:"validation"`
 }
 
+// ScriptExecuteRequest representa una solicitud de ejecución de un programa
+type ScriptExecuteRequest struct {
+	Script        string `json:"script" binding:"required"`
+	StopOnError   bool   `json:"stop_on_error"`
+	CheckKeyTypes bool   `json:"check_key_types"`
+}
+
+// ScriptExecuteResponse representa la respuesta de ejecución de un programa
+type ScriptExecuteResponse struct {
+	Success       bool                         `json:"success"`
+	Statements    []StatementResponse          `json:"statements"`
+	Error         string                       `json:"error,omitempty"`
+	ExecutionTime string                       `json:"execution_time"`
+	Validation    []semantic.ValidationResult `json:"validation"`
+}
+
+// StatementResponse representa el resultado de una sentencia del programa.
+// Los bloques MULTI/EXEC devuelven un resultado por comando encolado.
+type StatementResponse struct {
+	Statement     string            `json:"statement"`
+	Line          int               `json:"line"`
+	Success       bool              `json:"success"`
+	Result        interface{}       `json:"result,omitempty"`
+	Reply         *redis.Reply      `json:"reply,omitempty"`
+	Error         string            `json:"error,omitempty"`
+	ExecutionTime string            `json:"execution_time"`
+	Results       []ExecuteResponse `json:"results,omitempty"`
+	Discarded     bool              `json:"discarded,omitempty"`
+	Aborted       bool              `json:"aborted,omitempty"`
+}
+
 // DatabaseInfoResponse representa información de la base de datos
 type DatabaseInfoResponse struct {
 	Version      string            `json:"version"`
@@ -82,6 +192,11 @@ type CommandSpecInfo struct {
 	MaxArgs     int                           `json:"max_args"`
 	Description string                        `json:"description"`
 	Options     map[string]OptionSpecInfo     `json:"options,omitempty"`
+	Group       string                        `json:"group,omitempty"`
+	Since       string                        `json:"since,omitempty"`
+	Complexity  string                        `json:"complexity,omitempty"`
+	Flags       []string                      `json:"flags,omitempty"`
+	Subcommands []string                      `json:"subcommands,omitempty"`
 }
 
 // OptionSpecInfo representa información de especificación de opción
@@ -99,64 +214,172 @@ func NewServer(redisConfig redis.Config) *Server {
 	
 	router := gin.Default()
 	
-	// Configurar CORS
-	router.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
-		
-		c.Next()
-	})
-	
-	// Crear cliente Redis
+	// Crear cliente Redis; el analizador se comparte para que la tabla de
+	// comandos cargada sea la misma en análisis y ejecución
 	redisClient := redis.NewClient(redisConfig)
-	analyzer := semantic.New()
+	analyzer := redisClient.Analyzer()
 	
 	server := &Server{
-		router:      router,
-		redisClient: redisClient,
-		analyzer:    analyzer,
+		router:         router,
+		redisClient:    redisClient,
+		analyzer:       analyzer,
+		requestTimeout: DefaultRequestTimeout,
+		metrics:        newServerMetrics(redisClient),
+		httpConfig:     DefaultHTTPConfig(),
 	}
 	
-	// Configurar rutas
+	// Configurar métricas, CORS y rutas
+	router.Use(server.instrument, server.cors)
 	server.setupRoutes()
 	
 	return server
 }
 
-// setupRoutes configura las rutas de la API
+// LoadCommandsFile carga la tabla de comandos desde un archivo commands.json.
+// La tabla debe incluir los comandos que ejecutan las rutas fijas; si falta
+// alguno, el rol de esas rutas dependería de un comando desconocido.
+func (s *Server) LoadCommandsFile(path string) error {
+	if err := s.analyzer.LoadCommandsFile(path); err != nil {
+		return err
+	}
+	specs := s.analyzer.GetCommandSpecs()
+	for _, name := range s.routeCommands {
+		if _, ok := specs[name]; !ok {
+			return fmt.Errorf("%s: missing command %s used by the API routes", path, name)
+		}
+	}
+	return nil
+}
+
+// LoadPolicyFile carga la política de comandos desde un archivo JSON o YAML.
+// Los comandos denegados se reportan como errores semánticos POLICY tanto al
+// analizarlos como al ejecutarlos.
+func (s *Server) LoadPolicyFile(path string) error {
+	p, err := policy.LoadFile(path)
+	if err != nil {
+		return err
+	}
+	s.redisClient.SetPolicy(p)
+	return nil
+}
+
+// LoadCommandDocs carga la tabla de comandos del servidor Redis conectado.
+// Como LoadCommandsFile, exige los comandos que ejecutan las rutas fijas; si
+// falta alguno no se carga la tabla.
+func (s *Server) LoadCommandDocs() error {
+	ctx, cancel := s.timeoutContext(context.Background())
+	defer cancel()
+	return s.redisClient.LoadCommandDocs(ctx, s.routeCommands...)
+}
+
+// SetRequestTimeout fija el plazo de las operaciones contra Redis de cada
+// petición. Un valor <= 0 no impone plazo: la operación solo se cancela si
+// el cliente HTTP cierra la conexión.
+func (s *Server) SetRequestTimeout(timeout time.Duration) {
+	s.requestTimeout = timeout
+}
+
+// timeoutContext deriva de parent un contexto con el plazo configurado
+func (s *Server) timeoutContext(parent context.Context) (context.Context, context.CancelFunc) {
+	if s.requestTimeout <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeout(parent, s.requestTimeout)
+}
+
+// requestContext devuelve el contexto de la petición con el plazo configurado
+func (s *Server) requestContext(c *gin.Context) (context.Context, context.CancelFunc) {
+	return s.timeoutContext(c.Request.Context())
+}
+
+// errorStatus devuelve 504 si el error es un timeout de Redis y fallback en
+// otro caso
+func errorStatus(err error, fallback int) int {
+	if redis.IsTimeout(err) {
+		return http.StatusGatewayTimeout
+	}
+	return fallback
+}
+
+// SetAllowedOrigins limita los orígenes que pueden llamar a la API desde el
+// navegador. Sin orígenes (por defecto) se permite cualquiera.
+func (s *Server) SetAllowedOrigins(origins []string) {
+	s.allowedOrigins = origins
+}
+
+// cors añade las cabeceras CORS y responde a las peticiones preflight
+func (s *Server) cors(c *gin.Context) {
+	if len(s.allowedOrigins) == 0 {
+		c.Header("Access-Control-Allow-Origin", "*")
+	} else {
+		c.Header("Vary", "Origin")
+		origin := c.GetHeader("Origin")
+		for _, allowed := range s.allowedOrigins {
+			if origin == allowed {
+				c.Header("Access-Control-Allow-Origin", origin)
+				break
+			}
+		}
+	}
+	c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key")
+	
+	if c.Request.Method == "OPTIONS" {
+		c.AbortWithStatus(204)
+		return
+	}
+	
+	c.Next()
+}
+
+// setupRoutes configura las rutas de la API. Salvo /health y /metrics,
+// todas exigen autenticación; el rol necesario para ejecutar comandos
+// depende de su categoría (lectura, escritura o administración). En modo
+// solo lectura las rutas que modifican datos responden 403.
 func (s *Server) setupRoutes() {
-	api := s.router.Group("/api/v1")
+	// Rutas de salud y métricas
+	s.router.GET("/api/v1/health", s.healthCheck)
+	s.router.GET("/metrics", s.serveMetrics)
+	
+	api := s.router.Group("/api/v1", s.authenticate)
 	
 	// Rutas de análisis
-	api.POST("/analyze", s.analyzeCommand)
-	api.GET("/commands", s.getCommandSpecs)
+	api.POST("/analyze", s.requireRole(RoleViewer), s.analyzeCommand)
+	api.GET("/commands", s.requireRole(RoleViewer), s.getCommandSpecs)
 	
-	// Rutas de ejecución
+	// Rutas de ejecución; el rol se comprueba con los comandos recibidos
 	api.POST("/execute", s.executeCommand)
+	api.POST("/scripts/execute", s.executeScript)
 	
 	// Rutas de base de datos
-	api.GET("/database/info", s.getDatabaseInfo)
-	api.DELETE("/database/flush", s.flushDatabase)
+	api.GET("/database/info", s.requireCommands("INFO", "DBSIZE"), s.getDatabaseInfo)
+	api.DELETE("/database/flush", s.writable, s.requireCommands("FLUSHDB"), s.flushDatabase)
 	
 	// Rutas de claves
-	api.GET("/keys", s.listKeys)
-	api.GET("/keys/:key", s.getKeyInfo)
-	api.DELETE("/keys/:key", s.deleteKey)
+	api.GET("/keys", s.requireCommands("SCAN"), s.listKeys)
+	api.GET("/keys/:key", s.requireCommands("TYPE", "TTL"), s.getKeyInfo)
+	api.DELETE("/keys/:key", s.writable, s.requireCommands("DEL"), s.deleteKey)
 	
-	// Ruta de salud
-	api.GET("/health", s.healthCheck)
+	// Auditoría
+	api.GET("/audit", s.requireRole(RoleAdmin), s.queryAudit)
 	
 	// Servir archivos estáticos (para el frontend)
 	s.router.Static("/static", "./web/static")
 	s.router.StaticFile("/", "./web/index.html")
 }
 
+// writable rechaza la petición si el servidor está en modo solo lectura
+func (s *Server) writable(c *gin.Context) {
+	if s.redisClient.ReadOnly() {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error":     "server is in read-only mode",
+			"read_only": true,
+		})
+		return
+	}
+	c.Next()
+}
+
 // analyzeCommand analiza un comando Redis sin ejecutarlo
 func (s *Server) analyzeCommand(c *gin.Context) {
 	var req AnalyzeRequest
@@ -166,14 +389,19 @@ func (s *Server) analyzeCommand(c *gin.Context) {
 	}
 	
 	// Parsear el comando
-	cmd, parseErrors := parser.ParseCommand(req.Command)
+	p := parser.New(lexer.NewCommand(req.Command))
+	cmd := p.ParseCommand()
+	parseErrors := p.Errors()
 	
 	response := AnalyzeResponse{
 		ParseErrors: parseErrors,
+		Diagnostics: append([]parser.Diagnostic{}, p.Diagnostics()...),
+		Keys:        []KeySlotInfo{},
 	}
 	
 	if len(parseErrors) > 0 {
 		response.Valid = false
+		s.observeAnalysis(parseErrors, nil)
 		c.JSON(http.StatusOK, response)
 		return
 	}
@@ -181,10 +409,30 @@ func (s *Server) analyzeCommand(c *gin.Context) {
 	// Obtener AST como string
 	response.ParsedAST = cmd.String()
 	
-	// Validar semánticamente
+	// Con format=json se incluye el árbol completo codificado como JSON
+	format := req.Format
+	if format == "" {
+		format = c.Query("format")
+	}
+	if format == "json" {
+		response.AST = cmd
+	}
+	
+	// Validar semánticamente y aplicar la política de comandos
 	validation := s.analyzer.ValidateCommand(cmd)
+	s.redisClient.CheckPolicy(cmd, &validation)
+	s.observeAnalysis(nil, &validation)
 	response.Validation = &validation
 	response.Valid = validation.Valid
+	response.Diagnostics = append(response.Diagnostics, validation.Diagnostics...)
+	for _, key := range validation.Keys {
+		response.Keys = append(response.Keys, KeySlotInfo{
+			Key:     key.Name,
+			HashTag: key.HashTag,
+			Slot:    key.Slot,
+			Span:    key.Span,
+		})
+	}
 	
 	// Obtener información del comando
 	response.CommandInfo = parser.GetCommandInfo(cmd)
@@ -200,25 +448,117 @@ func (s *Server) executeCommand(c *gin.Context) {
 		return
 	}
 	
+	// El rol necesario depende de la categoría del comando; si no se puede
+	// parsear no llegará a ejecutarse
+	role := RoleOperator
+	if cmd, errs := parser.ParseCommand(req.Command); len(errs) == 0 {
+		role = roleForCategory(s.analyzer.CommandCategory(cmd))
+	}
+	if !s.authorize(c, role) {
+		return
+	}
+	
 	// Ejecutar comando
-	result := s.redisClient.ExecuteCommand(req.Command)
+	ctx, cancel := s.requestContext(c)
+	defer cancel()
+	result := s.redisClient.ExecuteCommand(ctx, req.Command)
+	s.observeExecution(result)
+	s.recordAudit(c, audit.Entry{
+		Command:  req.Command,
+		Success:  result.Success,
+		Error:    result.Error,
+		Duration: result.ExecutionTime,
+	}, validationList(result.Validation), false)
 	
 	response := ExecuteResponse{
 		Success:       result.Success,
 		Result:        result.Result,
+		Reply:         result.Reply,
 		Error:         result.Error,
 		ExecutionTime: result.ExecutionTime.String(),
 		Validation:    result.Validation,
 	}
 	
-	c.JSON(http.StatusOK, response)
+	c.JSON(errorStatus(result.Err, http.StatusOK), response)
+}
+
+// executeScript valida y ejecuta un programa de varios comandos
+func (s *Server) executeScript(c *gin.Context) {
+	var req ScriptExecuteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	
+	if program, errs := parser.ParseCommands(req.Script); len(errs) == 0 {
+		if !s.authorize(c, s.commandsRole(program)) {
+			return
+		}
+	} else if !s.authorize(c, RoleOperator) {
+		return
+	}
+	
+	ctx, cancel := s.requestContext(c)
+	defer cancel()
+	result := s.redisClient.ExecuteProgram(ctx, req.Script, redis.ProgramOptions{
+		StopOnError:   req.StopOnError,
+		CheckKeyTypes: req.CheckKeyTypes,
+	})
+	s.observeProgram(result)
+	s.recordAudit(c, audit.Entry{
+		Command:  req.Script,
+		Success:  result.Success,
+		Error:    result.Error,
+		Duration: result.ExecutionTime,
+	}, result.Validation, true)
+	
+	response := ScriptExecuteResponse{
+		Success:       result.Success,
+		Statements:    []StatementResponse{},
+		Error:         result.Error,
+		ExecutionTime: result.ExecutionTime.String(),
+		Validation:    result.Validation,
+	}
+	
+	for _, stmt := range result.Statements {
+		stmtResponse := StatementResponse{
+			Statement:     stmt.Statement,
+			Line:          stmt.Line,
+			Success:       stmt.Success,
+			Error:         stmt.Error,
+			ExecutionTime: stmt.ExecutionTime.String(),
+		}
+		if stmt.Command != nil {
+			stmtResponse.Result = stmt.Command.Result
+			stmtResponse.Reply = stmt.Command.Reply
+		}
+		if stmt.Transaction != nil {
+			stmtResponse.Discarded = stmt.Transaction.Discarded
+			stmtResponse.Aborted = stmt.Transaction.Aborted
+			for _, cmd := range stmt.Transaction.Results {
+				stmtResponse.Results = append(stmtResponse.Results, ExecuteResponse{
+					Success:       cmd.Success,
+					Result:        cmd.Result,
+					Reply:         cmd.Reply,
+					Error:         cmd.Error,
+					ExecutionTime: cmd.ExecutionTime.String(),
+					Validation:    cmd.Validation,
+				})
+			}
+		}
+		response.Statements = append(response.Statements, stmtResponse)
+	}
+	
+	c.JSON(errorStatus(result.Err, http.StatusOK), response)
 }
 
 // getDatabaseInfo obtiene información de la base de datos
 func (s *Server) getDatabaseInfo(c *gin.Context) {
-	info, err := s.redisClient.GetDatabaseInfo()
+	ctx, cancel := s.requestContext(c)
+	defer cancel()
+	info, err := s.redisClient.GetDatabaseInfo(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 	
@@ -243,9 +583,11 @@ func (s *Server) listKeys(c *gin.Context) {
 		limit = 100
 	}
 	
-	keys, err := s.redisClient.ListKeys(pattern, limit)
+	ctx, cancel := s.requestContext(c)
+	defer cancel()
+	keys, err := s.redisClient.ListKeys(ctx, pattern, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 	
@@ -262,7 +604,9 @@ func (s *Server) listKeys(c *gin.Context) {
 func (s *Server) getKeyInfo(c *gin.Context) {
 	key := c.Param("key")
 	
-	info, err := s.redisClient.GetKeyInfo(key)
+	ctx, cancel := s.requestContext(c)
+	defer cancel()
+	info, err := s.redisClient.GetKeyInfo(ctx, key)
 	
 	response := KeyInfoResponse{
 		Key:    key,
@@ -275,14 +619,26 @@ func (s *Server) getKeyInfo(c *gin.Context) {
 		response.Info = info
 	}
 	
-	c.JSON(http.StatusOK, response)
+	c.JSON(errorStatus(err, http.StatusOK), response)
 }
 
 // deleteKey elimina una clave
 func (s *Server) deleteKey(c *gin.Context) {
 	key := c.Param("key")
-	
-	result := s.redisClient.ExecuteCommand("DEL " + key)
+	// La clave se entrecomilla para que llegue como un único argumento
+	// aunque contenga espacios, comillas o #
+	command := "DEL " + parser.FormatValue(key)
+	
+	ctx, cancel := s.requestContext(c)
+	defer cancel()
+	result := s.redisClient.ExecuteCommand(ctx, command)
+	s.observeExecution(result)
+	s.recordAudit(c, audit.Entry{
+		Command:  command,
+		Success:  result.Success,
+		Error:    result.Error,
+		Duration: result.ExecutionTime,
+	}, validationList(result.Validation), false)
 	
 	if result.Success {
 		c.JSON(http.StatusOK, gin.H{
@@ -290,7 +646,7 @@ func (s *Server) deleteKey(c *gin.Context) {
 			"message": "Key deleted successfully",
 		})
 	} else {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(errorStatus(result.Err, http.StatusInternalServerError), gin.H{
 			"success": false,
 			"error":   result.Error,
 		})
@@ -299,9 +655,34 @@ func (s *Server) deleteKey(c *gin.Context) {
 
 // flushDatabase limpia la base de datos
 func (s *Server) flushDatabase(c *gin.Context) {
-	err := s.redisClient.FlushDatabase()
+	ctx, cancel := s.requestContext(c)
+	defer cancel()
+	start := time.Now()
+	err := s.redisClient.FlushDatabase(ctx)
+	
+	// FlushDatabase aplica las mismas comprobaciones que un FLUSHDB ejecutado
+	cmd, _ := parser.ParseCommand("FLUSHDB")
+	validation := s.analyzer.ValidateCommand(cmd)
+	s.redisClient.CheckPolicy(cmd, &validation)
+	elapsed := time.Since(start)
+	s.observeExecution(redis.ExecutionResult{Command: "FLUSHDB", Success: err == nil, ExecutionTime: elapsed, Validation: &validation})
+	entry := audit.Entry{Command: "FLUSHDB", Success: err == nil, Duration: elapsed}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		entry.Error = err.Error()
+	}
+	s.recordAudit(c, entry, validationList(&validation), false)
+	
+	if errors.Is(err, redis.ErrReadOnly) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "read_only": true})
+		return
+	}
+	var denial *policy.Denial
+	if errors.As(err, &denial) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "rule": denial.Rule})
+		return
+	}
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 	
@@ -333,6 +714,11 @@ func (s *Server) getCommandSpecs(c *gin.Context) {
 			MaxArgs:     spec.MaxArgs,
 			Description: spec.Description,
 			Options:     options,
+			Group:       spec.Group,
+			Since:       spec.Since,
+			Complexity:  spec.Complexity,
+			Flags:       spec.Flags,
+			Subcommands: spec.Subcommands,
 		}
 	}
 	
@@ -346,7 +732,9 @@ func (s *Server) getCommandSpecs(c *gin.Context) {
 // healthCheck verifica el estado del servidor
 func (s *Server) healthCheck(c *gin.Context) {
 	// Verificar conexión a Redis
-	err := s.redisClient.Connect()
+	ctx, cancel := s.requestContext(c)
+	defer cancel()
+	err := s.redisClient.Connect(ctx)
 	redisStatus := "ok"
 	if err != nil {
 		redisStatus = "error: " + err.Error()
@@ -356,23 +744,89 @@ func (s *Server) healthCheck(c *gin.Context) {
 		"status":    "ok",
 		"timestamp": time.Now().Unix(),
 		"redis":     redisStatus,
+		"read_only": s.redisClient.ReadOnly(),
 		"version":   "1.0.0",
 	})
 }
 
-// Start inicia el servidor
+// SetHTTPConfig fija los timeouts y el TLS del servidor HTTP. Debe llamarse
+// antes de Start.
+func (s *Server) SetHTTPConfig(config HTTPConfig) {
+	s.httpConfig = config
+}
+
+// Start conecta con Redis y atiende peticiones en el puerto indicado hasta
+// que se llama a Shutdown, en cuyo caso devuelve nil
 func (s *Server) Start(port string) error {
 	// Conectar a Redis
-	if err := s.redisClient.Connect(); err != nil {
+	ctx, cancel := s.timeoutContext(context.Background())
+	defer cancel()
+	if err := s.redisClient.Connect(ctx); err != nil {
 		return err
 	}
 	
-	// Iniciar servidor
-	return s.router.Run("0.0.0.0:" + port)
+	listener, err := net.Listen("tcp", "0.0.0.0:"+port)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve atiende peticiones en listener, con TLS si está configurado, hasta
+// que se llama a Shutdown. No conecta con Redis.
+func (s *Server) Serve(listener net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		listener.Close()
+		return nil
+	}
+	s.httpServer = &http.Server{
+		Handler:      s.router,
+		ReadTimeout:  s.httpConfig.ReadTimeout,
+		WriteTimeout: s.httpConfig.WriteTimeout,
+		IdleTimeout:  s.httpConfig.IdleTimeout,
+	}
+	httpServer := s.httpServer
+	s.mu.Unlock()
+	
+	var err error
+	if s.httpConfig.TLSCertFile != "" {
+		err = httpServer.ServeTLS(listener, s.httpConfig.TLSCertFile, s.httpConfig.TLSKeyFile)
+	} else {
+		err = httpServer.Serve(listener)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown deja de aceptar conexiones, espera a que terminen las peticiones
+// en curso (o a que venza ctx) y después cierra el log de auditoría y el
+// pool de Redis
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closed = true
+	httpServer := s.httpServer
+	s.mu.Unlock()
+	
+	var err error
+	if httpServer != nil {
+		err = httpServer.Shutdown(ctx)
+	}
+	if stopErr := s.Stop(); err == nil {
+		err = stopErr
+	}
+	return err
 }
 
-// Stop detiene el servidor
+// Stop cierra el log de auditoría y la conexión con Redis sin esperar a las
+// peticiones en curso
 func (s *Server) Stop() error {
+	if s.auditLog != nil {
+		s.auditLog.Close()
+	}
 	return s.redisClient.Close()
 }
 
@@ -2,11 +2,19 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 	
+	"redis-analyzer-api/parser"
 	"redis-analyzer-api/redis"
 )
 
@@ -100,6 +108,152 @@ func TestAnalyzeEndpoint(t *testing.T) {
 	}
 }
 
+func TestAnalyzeDiagnostics(t *testing.T) {
+	server := NewServer(redis.Config{Host: "localhost", Port: 6379, DB: 1})
+	
+	tests := []struct {
+		name        string
+		command     string
+		code        string
+		startColumn int
+		endColumn   int
+	}{
+		{
+			name:        "Semantic error anchored to the argument",
+			command:     "ZADD myset NX abc member",
+			code:        "TYPE_MISMATCH",
+			startColumn: 15,
+			endColumn:   18,
+		},
+		{
+			name:        "Unknown command anchored to the command name",
+			command:     "FOO key",
+			code:        "UNKNOWN_COMMAND",
+			startColumn: 1,
+			endColumn:   4,
+		},
+		{
+			name:        "Parse error anchored to the token",
+			command:     "GET key \"abc",
+			code:        "UNTERMINATED_STRING",
+			startColumn: 9,
+			endColumn:   13,
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jsonData, _ := json.Marshal(AnalyzeRequest{Command: tt.command})
+			req, _ := http.NewRequest("POST", "/api/v1/analyze", bytes.NewBuffer(jsonData))
+			req.Header.Set("Content-Type", "application/json")
+			
+			w := httptest.NewRecorder()
+			server.router.ServeHTTP(w, req)
+			
+			var response AnalyzeResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Error parsing response: %v", err)
+			}
+			
+			if len(response.Diagnostics) == 0 {
+				t.Fatalf("Expected diagnostics, got none")
+			}
+			
+			diag := response.Diagnostics[0]
+			if diag.Code != tt.code || diag.Severity != "error" {
+				t.Errorf("Expected error %s, got %s %s", tt.code, diag.Severity, diag.Code)
+			}
+			if diag.Span.Start.Column != tt.startColumn || diag.Span.End.Column != tt.endColumn {
+				t.Errorf("Expected span columns %d-%d, got %s", tt.startColumn, tt.endColumn, diag.Span)
+			}
+		})
+	}
+}
+
+func TestAnalyzeJSONFormat(t *testing.T) {
+	server := NewServer(redis.Config{Host: "localhost", Port: 6379, DB: 1})
+	
+	tests := []struct {
+		name      string
+		url       string
+		request   AnalyzeRequest
+		expectAST bool
+	}{
+		{name: "Default format", url: "/api/v1/analyze", request: AnalyzeRequest{Command: "INCRBYFLOAT counter 1.5"}, expectAST: false},
+		{name: "Format in body", url: "/api/v1/analyze", request: AnalyzeRequest{Command: "INCRBYFLOAT counter 1.5", Format: "json"}, expectAST: true},
+		{name: "Format in query", url: "/api/v1/analyze?format=json", request: AnalyzeRequest{Command: "INCRBYFLOAT counter 1.5"}, expectAST: true},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jsonData, _ := json.Marshal(tt.request)
+			req, _ := http.NewRequest("POST", tt.url, bytes.NewBuffer(jsonData))
+			req.Header.Set("Content-Type", "application/json")
+			
+			w := httptest.NewRecorder()
+			server.router.ServeHTTP(w, req)
+			
+			var raw map[string]json.RawMessage
+			if err := json.Unmarshal(w.Body.Bytes(), &raw); err != nil {
+				t.Fatalf("Error parsing response: %v", err)
+			}
+			
+			astData, hasAST := raw["ast"]
+			if hasAST != tt.expectAST {
+				t.Fatalf("Expected ast present=%v, got %v", tt.expectAST, hasAST)
+			}
+			if !tt.expectAST {
+				return
+			}
+			
+			cmd, err := parser.DecodeCommand(astData)
+			if err != nil {
+				t.Fatalf("Error decoding AST: %v", err)
+			}
+			if cmd.String() != "INCRBYFLOAT counter 1.5" {
+				t.Errorf("Unexpected decoded command: %s", cmd.String())
+			}
+			if _, ok := cmd.Arguments[1].(*parser.FloatLiteral); !ok {
+				t.Errorf("Expected FloatLiteral argument, got %s", cmd.Arguments[1].Type())
+			}
+		})
+	}
+}
+
+func TestAnalyzeKeySlots(t *testing.T) {
+	server := NewServer(redis.Config{Host: "localhost", Port: 6379, DB: 1})
+	
+	jsonData, _ := json.Marshal(AnalyzeRequest{Command: "DEL {user:1}:a foo"})
+	req, _ := http.NewRequest("POST", "/api/v1/analyze", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	
+	var response AnalyzeResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error parsing response: %v", err)
+	}
+	
+	if len(response.Keys) != 2 {
+		t.Fatalf("Expected 2 keys, got %v", response.Keys)
+	}
+	if response.Keys[0].Key != "{user:1}:a" || response.Keys[0].HashTag != "user:1" {
+		t.Errorf("Unexpected first key: %+v", response.Keys[0])
+	}
+	if response.Keys[1].Key != "foo" || response.Keys[1].Slot != 12182 {
+		t.Errorf("Unexpected second key: %+v", response.Keys[1])
+	}
+	
+	crossSlot := false
+	for _, diag := range response.Diagnostics {
+		crossSlot = crossSlot || diag.Code == "CROSSSLOT"
+	}
+	if !crossSlot {
+		t.Errorf("Expected a CROSSSLOT diagnostic, got %v", response.Diagnostics)
+	}
+}
+
 func TestExecuteEndpoint(t *testing.T) {
 	// Crear servidor de prueba
 	config := redis.Config{
@@ -111,7 +265,7 @@ func TestExecuteEndpoint(t *testing.T) {
 	server := NewServer(config)
 	
 	// Verificar que Redis esté disponible
-	if err := server.redisClient.Connect(); err != nil {
+	if err := server.redisClient.Connect(context.Background()); err != nil {
 		t.Skipf("Redis not available, skipping integration tests: %v", err)
 		return
 	}
@@ -186,7 +340,95 @@ func TestExecuteEndpoint(t *testing.T) {
 	}
 	
 	// Limpiar datos de prueba
-	server.redisClient.ExecuteCommand("DEL testkey")
+	server.redisClient.ExecuteCommand(context.Background(), "DEL testkey")
+}
+
+func TestScriptExecuteEndpoint(t *testing.T) {
+	server := NewServer(redis.Config{Host: "localhost", Port: 6379, DB: 1})
+	
+	post := func(body interface{}) (*httptest.ResponseRecorder, ScriptExecuteResponse) {
+		jsonData, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", "/api/v1/scripts/execute", bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+		
+		var response ScriptExecuteResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+	
+	// Una solicitud sin script es inválida
+	if w, _ := post(map[string]string{}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for missing script, got %d", http.StatusBadRequest, w.Code)
+	}
+	
+	// El programa se valida completo antes de ejecutar nada
+	_, response := post(ScriptExecuteRequest{Script: "SET script:a 1\nGET\nSET script:b 2"})
+	if response.Success || !strings.Contains(response.Error, "Semantic errors") {
+		t.Errorf("Expected semantic error, got success=%v error=%q", response.Success, response.Error)
+	}
+	if len(response.Statements) != 0 {
+		t.Errorf("Expected no statements executed, got %d", len(response.Statements))
+	}
+	if len(response.Validation) != 3 || response.Validation[1].Valid {
+		t.Errorf("Expected validation for every statement, got %+v", response.Validation)
+	}
+	
+	// Los tipos de las claves se siguen a lo largo del programa
+	_, response = post(ScriptExecuteRequest{Script: "HSET script:h f v\nGET script:h"})
+	if response.Success || len(response.Validation) != 2 || response.Validation[1].Errors[0].Type != "WRONGTYPE" {
+		t.Errorf("Expected a WRONGTYPE error, got %+v", response.Validation)
+	}
+	
+	if err := server.redisClient.Connect(context.Background()); err != nil {
+		t.Skipf("Redis not available, skipping integration tests: %v", err)
+		return
+	}
+	defer server.redisClient.Close()
+	
+	// Con check_key_types el tipo de partida se consulta en el servidor
+	server.redisClient.ExecuteCommand(context.Background(), "HSET script:h f v")
+	_, response = post(ScriptExecuteRequest{Script: "GET script:h", CheckKeyTypes: true})
+	if response.Success || len(response.Validation) != 1 || response.Validation[0].Valid {
+		t.Errorf("Expected a WRONGTYPE error from the server type, got %+v", response.Validation)
+	}
+	server.redisClient.ExecuteCommand(context.Background(), "DEL script:h")
+	
+	script := `DEL script:counter script:list
+SET script:counter 10
+MULTI
+INCR script:counter
+LPUSH script:list a
+EXEC
+GET script:counter`
+	
+	for _, stopOnError := range []bool{false, true} {
+		_, response := post(ScriptExecuteRequest{Script: script, StopOnError: stopOnError})
+		if !response.Success {
+			t.Fatalf("Expected success (stop_on_error=%v), got error: %s", stopOnError, response.Error)
+		}
+		if len(response.Statements) != 4 {
+			t.Fatalf("Expected 4 statements, got %d", len(response.Statements))
+		}
+		
+		tx := response.Statements[2]
+		if tx.Line != 3 || len(tx.Results) != 2 {
+			t.Errorf("Expected transaction at line 3 with 2 results, got line %d with %d results", tx.Line, len(tx.Results))
+		}
+		if get := response.Statements[3]; get.Result != "11" || get.ExecutionTime == "" {
+			t.Errorf("Expected GET to return 11, got %v", get.Result)
+		}
+	}
+	
+	// Con stop_on_error la ejecución se detiene en el primer fallo
+	_, response = post(ScriptExecuteRequest{Script: "SET script:counter x\nINCR script:counter\nDEL script:counter", StopOnError: true})
+	if response.Success || len(response.Statements) != 2 || !strings.Contains(response.Error, "1 statement(s) not executed") {
+		t.Errorf("Expected execution to stop at line 2, got %d statements and error %q", len(response.Statements), response.Error)
+	}
+	
+	server.redisClient.ExecuteCommand(context.Background(), "DEL script:counter script:list")
 }
 
 func TestDatabaseInfoEndpoint(t *testing.T) {
@@ -198,7 +440,7 @@ func TestDatabaseInfoEndpoint(t *testing.T) {
 	
 	server := NewServer(config)
 	
-	if err := server.redisClient.Connect(); err != nil {
+	if err := server.redisClient.Connect(context.Background()); err != nil {
 		t.Skipf("Redis not available, skipping test: %v", err)
 		return
 	}
@@ -233,15 +475,15 @@ func TestKeysEndpoint(t *testing.T) {
 	
 	server := NewServer(config)
 	
-	if err := server.redisClient.Connect(); err != nil {
+	if err := server.redisClient.Connect(context.Background()); err != nil {
 		t.Skipf("Redis not available, skipping test: %v", err)
 		return
 	}
 	defer server.redisClient.Close()
 	
 	// Insertar algunas claves de prueba
-	server.redisClient.ExecuteCommand(`SET testkey1 "value1"`)
-	server.redisClient.ExecuteCommand(`SET testkey2 "value2"`)
+	server.redisClient.ExecuteCommand(context.Background(), `SET testkey1 "value1"`)
+	server.redisClient.ExecuteCommand(context.Background(), `SET testkey2 "value2"`)
 	
 	// Test listar claves
 	req, _ := http.NewRequest("GET", "/api/v1/keys?pattern=testkey*", nil)
@@ -284,7 +526,217 @@ func TestKeysEndpoint(t *testing.T) {
 	}
 	
 	// Limpiar
-	server.redisClient.ExecuteCommand("DEL testkey1 testkey2")
+	server.redisClient.ExecuteCommand(context.Background(), "DEL testkey1 testkey2")
+}
+
+// silentRedis arranca un servidor que acepta conexiones pero nunca responde
+// y devuelve la configuración para conectarse a él
+func silentRedis(t *testing.T) redis.Config {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go io.Copy(io.Discard, conn)
+		}
+	}()
+	
+	addr := listener.Addr().(*net.TCPAddr)
+	return redis.Config{Host: addr.IP.String(), Port: addr.Port}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	server := NewServer(silentRedis(t))
+	server.SetRequestTimeout(100 * time.Millisecond)
+	defer server.Stop()
+	
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{method: "POST", path: "/api/v1/execute", body: `{"command": "GET foo"}`},
+		{method: "POST", path: "/api/v1/scripts/execute", body: `{"script": "SET a 1\nGET a"}`},
+		{method: "GET", path: "/api/v1/database/info"},
+		{method: "GET", path: "/api/v1/keys/foo"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			
+			server.router.ServeHTTP(w, req)
+			
+			if w.Code != http.StatusGatewayTimeout {
+				t.Errorf("Expected status 504, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestGracefulShutdown(t *testing.T) {
+	// Las peticiones a Redis quedan en curso hasta que vence su plazo
+	server := NewServer(silentRedis(t))
+	server.SetRequestTimeout(300 * time.Millisecond)
+	
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := "http://" + listener.Addr().String()
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+	
+	responses := make(chan int, 1)
+	go func() {
+		resp, err := http.Post(url+"/api/v1/execute", "application/json", strings.NewReader(`{"command": "GET a"}`))
+		if err != nil {
+			responses <- 0
+			return
+		}
+		resp.Body.Close()
+		responses <- resp.StatusCode
+	}()
+	time.Sleep(100 * time.Millisecond)
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	if err := server.Shutdown(ctx); err != nil {
+		t.Fatalf("Unexpected shutdown error: %v", err)
+	}
+	
+	// Shutdown espera a la petición en curso, que termina con su timeout
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("Shutdown returned after %s, before the in-flight request finished", elapsed)
+	}
+	select {
+	case code := <-responses:
+		if code != http.StatusGatewayTimeout {
+			t.Errorf("Expected the in-flight request to finish with 504, got %d", code)
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("The in-flight request did not finish")
+	}
+	if err := <-served; err != nil {
+		t.Errorf("Expected Serve to return nil after Shutdown, got %v", err)
+	}
+	if _, err := http.Get(url + "/api/v1/health"); err == nil {
+		t.Errorf("Expected new connections to be refused")
+	}
+}
+
+func TestHTTPConfigValidate(t *testing.T) {
+	if err := DefaultHTTPConfig().Validate(); err != nil {
+		t.Errorf("Expected the default configuration to be valid, got %v", err)
+	}
+	
+	dir := t.TempDir()
+	for _, config := range []HTTPConfig{
+		{TLSCertFile: filepath.Join(dir, "cert.pem")},
+		{TLSCertFile: filepath.Join(dir, "cert.pem"), TLSKeyFile: filepath.Join(dir, "key.pem")},
+	} {
+		if err := config.Validate(); err == nil {
+			t.Errorf("Expected an error for %+v", config)
+		}
+	}
+}
+
+func TestPolicyEnforcement(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	rules := `{"rules": [
+		{"name": "no-flush", "effect": "deny", "commands": ["FLUSHDB", "FLUSHALL"]},
+		{"name": "sandbox-only", "effect": "deny", "categories": ["write"], "keys": ["prod:*"]}
+	]}`
+	if err := os.WriteFile(path, []byte(rules), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	
+	// Las denegaciones se deciden antes de contactar con Redis
+	server := NewServer(redis.Config{Host: "127.0.0.1", Port: 1})
+	if err := server.LoadPolicyFile(path); err != nil {
+		t.Fatal(err)
+	}
+	
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+		return w
+	}
+	
+	// El análisis muestra la denegación como error semántico
+	var analysis AnalyzeResponse
+	json.Unmarshal(post("/api/v1/analyze", `{"command": "FLUSHALL"}`).Body.Bytes(), &analysis)
+	if analysis.Valid || len(analysis.Validation.Errors) != 1 || analysis.Validation.Errors[0].Rule != "no-flush" {
+		t.Errorf("Expected a no-flush policy error, got %+v", analysis.Validation)
+	}
+	
+	var execution ExecuteResponse
+	json.Unmarshal(post("/api/v1/execute", `{"command": "SET prod:a 1"}`).Body.Bytes(), &execution)
+	if execution.Success || execution.Validation == nil || execution.Validation.Errors[0].Type != "POLICY" {
+		t.Errorf("Expected a POLICY error, got %+v", execution)
+	}
+	
+	var script ScriptExecuteResponse
+	json.Unmarshal(post("/api/v1/scripts/execute", `{"script": "MULTI\nSET prod:a 1\nEXEC"}`).Body.Bytes(), &script)
+	if script.Success || len(script.Statements) != 0 || script.Validation[1].Errors[0].Rule != "sandbox-only" {
+		t.Errorf("Expected the script to be rejected by sandbox-only, got %+v", script)
+	}
+	
+	req, _ := http.NewRequest("DELETE", "/api/v1/database/flush", nil)
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for flush, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestReadOnlyMode(t *testing.T) {
+	server := NewServer(redis.Config{Host: "127.0.0.1", Port: 1, ReadOnly: true})
+	
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+		return w
+	}
+	
+	var health map[string]interface{}
+	json.Unmarshal(request("GET", "/api/v1/health", "").Body.Bytes(), &health)
+	if health["read_only"] != true {
+		t.Errorf("Expected health to report read_only, got %v", health)
+	}
+	
+	var execution ExecuteResponse
+	json.Unmarshal(request("POST", "/api/v1/execute", `{"command": "SET a 1"}`).Body.Bytes(), &execution)
+	if execution.Success || execution.Validation == nil || execution.Validation.Errors[0].Type != "READ_ONLY" {
+		t.Errorf("Expected a READ_ONLY error, got %+v", execution)
+	}
+	
+	var script ScriptExecuteResponse
+	json.Unmarshal(request("POST", "/api/v1/scripts/execute", `{"script": "GET a\nCONFIG SET maxmemory 1mb"}`).Body.Bytes(), &script)
+	if script.Success || len(script.Statements) != 0 || script.Validation[1].Errors[0].Type != "READ_ONLY" {
+		t.Errorf("Expected the script to be rejected, got %+v", script)
+	}
+	
+	for _, path := range []string{"/api/v1/database/flush", "/api/v1/keys/a"} {
+		if w := request("DELETE", path, ""); w.Code != http.StatusForbidden {
+			t.Errorf("%s: expected status 403, got %d: %s", path, w.Code, w.Body.String())
+		}
+	}
 }
 
 func TestHealthEndpoint(t *testing.T) {
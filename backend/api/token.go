@@ -0,0 +1,315 @@
+package api
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// TokenAuthenticator valida bearer tokens JWT firmados con un secreto HMAC
+// (HS256/384/512) o con las claves públicas de un JWKS local (RS256/384/512
+// y ES256/384/512). El rol se lee del claim RoleClaim.
+type TokenAuthenticator struct {
+	Secret    []byte                 // secreto HMAC compartido
+	Keys      map[string]interface{} // claves del JWKS por kid: []byte, *rsa.PublicKey o *ecdsa.PublicKey
+	Issuer    string                 // iss esperado (vacío no lo comprueba)
+	Audience  string                 // aud esperado (vacío no lo comprueba)
+	RoleClaim string                 // claim con el rol (por defecto "role")
+
+	now func() time.Time
+}
+
+// signingHashes contiene los algoritmos de firma admitidos y su hash. "none"
+// no está incluido, por lo que los tokens sin firmar se rechazan.
+var signingHashes = map[string]crypto.Hash{
+	"HS256": crypto.SHA256, "HS384": crypto.SHA384, "HS512": crypto.SHA512,
+	"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+	"ES256": crypto.SHA256, "ES384": crypto.SHA384, "ES512": crypto.SHA512,
+}
+
+// jwtHeader es la cabecera de un JWT
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// jwk es una clave de un JSON Web Key Set
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// LoadJWKS lee un archivo JSON Web Key Set con claves RSA, EC u oct
+func LoadJWKS(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS file %s: %w", path, err)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, k := range set.Keys {
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q in JWKS: %w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS file %s has no keys", path)
+	}
+	return keys, nil
+}
+
+// publicKey convierte la JWK en la clave que usa crypto
+func (k jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "oct":
+		return decodeSegment(k.K)
+	case "RSA":
+		n, err := decodeSegment(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeSegment(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeSegment(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeSegment(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func (t *TokenAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	authorization := r.Header.Get("Authorization")
+	if len(authorization) < 7 || !strings.EqualFold(authorization[:7], "Bearer ") {
+		return Principal{}, ErrNoCredentials
+	}
+
+	claims, err := t.verify(strings.TrimSpace(authorization[7:]))
+	if err != nil {
+		return Principal{}, fmt.Errorf("invalid bearer token: %w", err)
+	}
+
+	roleClaim := t.RoleClaim
+	if roleClaim == "" {
+		roleClaim = "role"
+	}
+	role := claimRole(claims[roleClaim])
+	if role == 0 {
+		return Principal{}, fmt.Errorf("invalid bearer token: no valid %q claim", roleClaim)
+	}
+
+	subject, _ := claims["sub"].(string)
+	return Principal{Subject: subject, Role: role}, nil
+}
+
+// verify comprueba la firma y los claims registrados del token y devuelve
+// sus claims
+func (t *TokenAuthenticator) verify(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token")
+	}
+
+	var header jwtHeader
+	if err := decodeJSONSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed header: %w", err)
+	}
+	signature, err := decodeSegment(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed signature: %w", err)
+	}
+	if err := t.verifySignature(header, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]interface{}
+	if err := decodeJSONSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed claims: %w", err)
+	}
+	if err := t.validateClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// verifySignature comprueba la firma con la clave que corresponde al kid y
+// al algoritmo del token. El tipo de clave debe coincidir con el algoritmo
+// para que un token HS256 no pueda firmarse con una clave pública.
+func (t *TokenAuthenticator) verifySignature(header jwtHeader, signed string, signature []byte) error {
+	hash, supported := signingHashes[header.Alg]
+	if !supported {
+		return fmt.Errorf("unsupported algorithm %q", header.Alg)
+	}
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	key, ok := t.Keys[header.Kid]
+	if !ok && header.Kid == "" && len(t.Keys) == 1 {
+		// Un JWKS con una sola clave no necesita kid
+		for _, only := range t.Keys {
+			key, ok = only, true
+		}
+	}
+	if !ok && strings.HasPrefix(header.Alg, "HS") && t.Secret != nil {
+		key, ok = t.Secret, true
+	}
+	if !ok {
+		return fmt.Errorf("unknown signing key %q", header.Kid)
+	}
+
+	switch header.Alg[:2] {
+	case "HS":
+		secret, isSecret := key.([]byte)
+		if !isSecret {
+			return fmt.Errorf("key %q cannot verify %s", header.Kid, header.Alg)
+		}
+		mac := hmac.New(hash.New, secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return fmt.Errorf("signature mismatch")
+		}
+	case "RS":
+		pub, isRSA := key.(*rsa.PublicKey)
+		if !isRSA {
+			return fmt.Errorf("key %q cannot verify %s", header.Kid, header.Alg)
+		}
+		if err := rsa.VerifyPKCS1v15(pub, hash, digest, signature); err != nil {
+			return fmt.Errorf("signature mismatch")
+		}
+	case "ES":
+		pub, isEC := key.(*ecdsa.PublicKey)
+		if !isEC {
+			return fmt.Errorf("key %q cannot verify %s", header.Kid, header.Alg)
+		}
+		// La firma es r || s, cada uno del tamaño de la curva
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return fmt.Errorf("signature mismatch")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return fmt.Errorf("signature mismatch")
+		}
+	}
+	return nil
+}
+
+// validateClaims comprueba exp, nbf, iss y aud
+func (t *TokenAuthenticator) validateClaims(claims map[string]interface{}) error {
+	now := time.Now()
+	if t.now != nil {
+		now = t.now()
+	}
+
+	if exp, ok := claims["exp"].(float64); ok && now.Unix() >= int64(exp) {
+		return fmt.Errorf("token expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Unix() < int64(nbf) {
+		return fmt.Errorf("token not valid yet")
+	}
+	if t.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != t.Issuer {
+			return fmt.Errorf("unexpected issuer %q", iss)
+		}
+	}
+	if t.Audience != "" && !claimContains(claims["aud"], t.Audience) {
+		return fmt.Errorf("token not issued for audience %q", t.Audience)
+	}
+	return nil
+}
+
+// claimRole devuelve el rol de un claim que puede ser un nombre o una lista
+// de nombres (se usa el rol más alto); 0 si no contiene ningún rol válido
+func claimRole(value interface{}) Role {
+	var best Role
+	switch v := value.(type) {
+	case string:
+		best, _ = ParseRole(v)
+	case []interface{}:
+		for _, item := range v {
+			if name, ok := item.(string); ok {
+				if role, err := ParseRole(name); err == nil {
+					best = maxRole(best, role)
+				}
+			}
+		}
+	}
+	return best
+}
+
+// claimContains indica si un claim de tipo string o lista contiene value
+func claimContains(claim interface{}, value string) bool {
+	switch v := claim.(type) {
+	case string:
+		return v == value
+	case []interface{}:
+		for _, item := range v {
+			if item == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// decodeSegment decodifica un segmento base64url sin relleno
+func decodeSegment(segment string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+}
+
+// decodeJSONSegment decodifica un segmento base64url que contiene JSON
+func decodeJSONSegment(segment string, v interface{}) error {
+	data, err := decodeSegment(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,142 @@
+package audit
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCapacity es el número de entradas recientes que se conservan en
+// memoria para las consultas
+const DefaultCapacity = 1000
+
+// Entry registra un comando (o programa) ejecutado a través de la API
+type Entry struct {
+	Time             time.Time     `json:"time"` // momento en que terminó la ejecución
+	User             string        `json:"user"`
+	Role             string        `json:"role,omitempty"`
+	RemoteAddr       string        `json:"remote_addr"`
+	Endpoint         string        `json:"endpoint"`
+	Command          string        `json:"command"`              // texto recibido
+	Normalized       string        `json:"normalized,omitempty"` // comandos con el nombre en mayúsculas, uno por línea
+	Commands         []string      `json:"commands,omitempty"`   // nombres de los comandos, sin repetir
+	Keys             []string      `json:"keys,omitempty"`       // claves que tocan los comandos, sin repetir
+	Valid            bool          `json:"valid"`
+	ValidationErrors []string      `json:"validation_errors,omitempty"`
+	Success          bool          `json:"success"`
+	Error            string        `json:"error,omitempty"`
+	Duration         time.Duration `json:"duration_ns"`
+}
+
+// Query filtra las entradas recientes. Los campos vacíos no filtran.
+type Query struct {
+	Since   time.Time
+	Until   time.Time
+	User    string
+	Command string // nombre de comando, sin distinguir mayúsculas
+	Limit   int    // máximo de entradas; <= 0 las devuelve todas
+}
+
+// matches indica si la entrada cumple los filtros de la consulta
+func (q Query) matches(entry Entry) bool {
+	if !q.Since.IsZero() && entry.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && entry.Time.After(q.Until) {
+		return false
+	}
+	if q.User != "" && entry.User != q.User {
+		return false
+	}
+	if q.Command != "" {
+		for _, name := range entry.Commands {
+			if strings.EqualFold(name, q.Command) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Log escribe las entradas de auditoría en un Sink y conserva las más
+// recientes en memoria para poder consultarlas. Es seguro para uso
+// concurrente.
+type Log struct {
+	mu      sync.Mutex
+	sink    Sink
+	entries []Entry // buffer circular
+	next    int     // posición de la siguiente entrada
+	full    bool
+	dropped time.Time // hora de la entrada más reciente que ya no está en memoria
+}
+
+// New crea un log de auditoría que escribe en sink y conserva en memoria las
+// últimas capacity entradas (DefaultCapacity si capacity <= 0)
+func New(sink Sink, capacity int) *Log {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	return &Log{sink: sink, entries: make([]Entry, capacity)}
+}
+
+// Record añade una entrada al log. La entrada se conserva en memoria aunque
+// falle la escritura en el sink, cuyo error se devuelve.
+func (l *Log) Record(entry Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.full {
+		l.dropped = l.entries[l.next].Time
+	}
+	l.entries[l.next] = entry
+	l.next = (l.next + 1) % len(l.entries)
+	if l.next == 0 {
+		l.full = true
+	}
+	return l.sink.Write(entry)
+}
+
+// Query devuelve las entradas en memoria que cumplen la consulta, de la más
+// reciente a la más antigua
+func (l *Log) Query(q Query) []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	count := l.next
+	if l.full {
+		count = len(l.entries)
+	}
+	result := []Entry{}
+	for i := 1; i <= count; i++ {
+		entry := l.entries[(l.next-i+len(l.entries))%len(l.entries)]
+		if !q.matches(entry) {
+			continue
+		}
+		result = append(result, entry)
+		if q.Limit > 0 && len(result) == q.Limit {
+			break
+		}
+	}
+	return result
+}
+
+// Truncated indica si la consulta podría haber devuelto entradas que ya no
+// están en memoria: el log ha descartado entradas posteriores a q.Since y
+// la consulta no ha llegado a su límite
+func (l *Log) Truncated(q Query, returned int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.dropped.IsZero() || (q.Limit > 0 && returned >= q.Limit) {
+		return false
+	}
+	return q.Since.IsZero() || !l.dropped.Before(q.Since)
+}
+
+// Close cierra el sink
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.sink.Close()
+}
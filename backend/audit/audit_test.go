@@ -0,0 +1,106 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQuery(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(NewWriterSink(&buf), 3)
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	entries := []Entry{
+		{Time: base, User: "alice", Commands: []string{"GET"}},
+		{Time: base.Add(time.Minute), User: "bob", Commands: []string{"SET"}},
+		{Time: base.Add(2 * time.Minute), User: "alice", Commands: []string{"MULTI", "SET", "EXEC"}},
+		{Time: base.Add(3 * time.Minute), User: "alice", Commands: []string{"DEL"}},
+	}
+	for _, entry := range entries {
+		if err := log.Record(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Todas las entradas se escriben en el sink, una por línea
+	if lines := strings.Count(buf.String(), "\n"); lines != 4 {
+		t.Errorf("Expected 4 JSON lines, got %d", lines)
+	}
+
+	tests := []struct {
+		name      string
+		query     Query
+		expected  []string // comandos de las entradas devueltas, en orden
+		truncated bool     // la primera entrada ya no está en memoria
+	}{
+		{name: "Most recent first, capacity 3", query: Query{}, expected: []string{"DEL", "MULTI", "SET"}, truncated: true},
+		{name: "By user", query: Query{User: "alice"}, expected: []string{"DEL", "MULTI"}, truncated: true},
+		{name: "By command", query: Query{Command: "set"}, expected: []string{"MULTI", "SET"}, truncated: true},
+		{name: "By time range", query: Query{Since: base.Add(time.Minute), Until: base.Add(2 * time.Minute)}, expected: []string{"MULTI", "SET"}},
+		{name: "Limit", query: Query{Limit: 1}, expected: []string{"DEL"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			entries := log.Query(tt.query)
+			for _, entry := range entries {
+				got = append(got, entry.Commands[0])
+			}
+			if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+			if truncated := log.Truncated(tt.query, len(entries)); truncated != tt.truncated {
+				t.Errorf("Expected truncated %v, got %v", tt.truncated, truncated)
+			}
+		})
+	}
+}
+
+func TestFileRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := OpenFile(path, 200, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := New(sink, 0)
+
+	for i := 0; i < 10; i++ {
+		if err := log.Record(Entry{User: "alice", Command: "GET a"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := log.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("Expected %s to exist: %v", name, err)
+		}
+		if info.Size() > 200 {
+			t.Errorf("%s exceeds the maximum size: %d bytes", name, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("Expected only 2 backups to be kept")
+	}
+
+	// Cada línea es una entrada JSON completa
+	file, _ := os.Open(path)
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.User != "alice" {
+			t.Errorf("Invalid audit line %q: %v", scanner.Text(), err)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Sink recibe las entradas de auditoría. Log serializa las llamadas, por lo
+// que las implementaciones no necesitan sincronización propia.
+type Sink interface {
+	Write(entry Entry) error
+	Close() error
+}
+
+// WriterSink escribe cada entrada como una línea JSON en un io.Writer, p.ej.
+// os.Stdout. Close no cierra el writer.
+type WriterSink struct {
+	w io.Writer
+}
+
+// NewWriterSink crea un sink de líneas JSON sobre w
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+func (s *WriterSink) Write(entry Entry) error {
+	line, err := encode(entry)
+	if err != nil {
+		return err
+	}
+	_, err = s.w.Write(line)
+	return err
+}
+
+func (s *WriterSink) Close() error {
+	return nil
+}
+
+// FileSink añade cada entrada como una línea JSON al final de un archivo.
+// Si se indica un tamaño máximo el archivo rota al superarlo: path pasa a
+// path.1, path.1 a path.2... y se conserva el número de copias indicado.
+type FileSink struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// OpenFile abre (o crea) el archivo de auditoría en modo append. maxSize <= 0
+// desactiva la rotación; al rotar se conserva al menos una copia.
+func OpenFile(path string, maxSize int64, maxBackups int) (*FileSink, error) {
+	if maxBackups < 1 {
+		maxBackups = 1
+	}
+	s := &FileSink{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// open abre el archivo y lee su tamaño actual
+func (s *FileSink) open() error {
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
+
+func (s *FileSink) Write(entry Entry) error {
+	line, err := encode(entry)
+	if err != nil {
+		return err
+	}
+
+	if s.maxSize > 0 && s.size > 0 && s.size+int64(len(line)) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	return err
+}
+
+// rotate desplaza las copias anteriores, eliminando la más antigua, y abre
+// un archivo nuevo. Si no se puede renombrar el archivo se sigue escribiendo
+// en él y se devuelve el error.
+func (s *FileSink) rotate() error {
+	s.file.Close()
+
+	os.Remove(fmt.Sprintf("%s.%d", s.path, s.maxBackups))
+	for i := s.maxBackups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", s.path, i), fmt.Sprintf("%s.%d", s.path, i+1))
+	}
+	rotateErr := os.Rename(s.path, s.path+".1")
+
+	if err := s.open(); err != nil {
+		return err
+	}
+	if rotateErr != nil {
+		return fmt.Errorf("failed to rotate audit log: %w", rotateErr)
+	}
+	return nil
+}
+
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
+
+// encode serializa la entrada como una línea JSON
+func encode(entry Entry) ([]byte, error) {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
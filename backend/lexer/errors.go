@@ -0,0 +1,60 @@
+package lexer
+
+import "fmt"
+
+// Error representa un error léxico con su posición en el input
+type Error struct {
+	Code     string // motivo del error, p.ej. UNTERMINATED_STRING
+	Message  string
+	Rune     rune // carácter que causa el error (0 si no aplica)
+	Position int // desplazamiento en bytes del inicio del error
+	Line     int
+	Column   int
+	
+	// Final exclusivo del fragmento que causa el error
+	End       int
+	EndLine   int
+	EndColumn int
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("Lexer error at line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// Errors devuelve los errores encontrados hasta el momento
+func (l *Lexer) Errors() []Error {
+	return l.errors
+}
+
+// addError registra un error que empieza en la posición indicada y termina
+// en el carácter actual
+func (l *Lexer) addError(code, message string, r rune, position, line, column int) {
+	l.errors = append(l.errors, Error{
+		Code:      code,
+		Message:   message,
+		Rune:      r,
+		Position:  position,
+		Line:      line,
+		Column:    column,
+		End:       l.position,
+		EndLine:   l.line,
+		EndColumn: l.column,
+	})
+}
+
+// addRunError registra un error sobre los bytes [offset, offset+size) del
+// fragmento que empieza en el carácter actual. Los fragmentos no contienen
+// saltos de línea, por lo que el error está en la línea actual.
+func (l *Lexer) addRunError(code, message string, r rune, offset, size int) {
+	l.errors = append(l.errors, Error{
+		Code:      code,
+		Message:   message,
+		Rune:      r,
+		Position:  l.position + offset,
+		Line:      l.line,
+		Column:    l.column + offset,
+		End:       l.position + offset + size,
+		EndLine:   l.line,
+		EndColumn: l.column + offset + size,
+	})
+}
@@ -1,7 +1,11 @@
 package lexer
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // Lexer representa el analizador léxico
@@ -12,10 +16,21 @@ type Lexer struct {
 	ch           byte // carácter actual bajo examinación
 	line         int  // línea actual
 	column       int  // columna actual
+	errors       []Error
+	runEnd       int  // final del fragmento que se está dividiendo en tokens
+	comments     bool // # abre comentarios (scripts)
 }
 
-// New crea un nuevo lexer
+// New crea un nuevo lexer para un script, donde # abre comentarios
 func New(input string) *Lexer {
+	l := NewCommand(input)
+	l.comments = true
+	return l
+}
+
+// NewCommand crea un lexer para un único comando, como los que se escriben
+// en redis-cli: sin comentarios, así que # es un carácter más (GET #)
+func NewCommand(input string) *Lexer {
 	l := &Lexer{
 		input:  input,
 		line:   1,
@@ -25,8 +40,14 @@ func New(input string) *Lexer {
 	return l
 }
 
-// readChar lee el siguiente carácter y avanza la posición en el input
+// readChar lee el siguiente carácter y avanza la posición en el input.
+// line y column describen siempre la posición del carácter actual.
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line++
+		l.column = 0
+	}
+	
 	if l.readPosition >= len(l.input) {
 		l.ch = 0 // ASCII NUL representa "EOF"
 	} else {
@@ -34,13 +55,7 @@ func (l *Lexer) readChar() {
 	}
 	l.position = l.readPosition
 	l.readPosition++
-	
-	if l.ch == '\n' {
-		l.line++
-		l.column = 0
-	} else {
-		l.column++
-	}
+	l.column++
 }
 
 // peekChar devuelve el siguiente carácter sin avanzar la posición
@@ -53,11 +68,45 @@ func (l *Lexer) peekChar() byte {
 
 // NextToken escanea el input y devuelve el siguiente token
 func (l *Lexer) NextToken() Token {
-	var tok Token
-	
 	// Saltar espacios en blanco (excepto cuando son significativos)
 	l.skipWhitespace()
 	
+	position, line, column := l.position, l.line, l.column
+	tok := l.scanToken()
+	
+	// Registrar el inicio y el final (exclusivo) del token en el input
+	tok.Position, tok.Line, tok.Column = position, line, column
+	tok.End, tok.EndLine, tok.EndColumn = l.position, l.line, l.column
+	tok.Raw = l.input[position:min(l.position, len(l.input))]
+	return tok
+}
+
+// scanToken lee el token que empieza en el carácter actual
+func (l *Lexer) scanToken() Token {
+	var tok Token
+	
+	if l.atEOF() {
+		tok.Type = EOF
+		return tok
+	}
+	
+	// En un script, un # al principio de una palabra abre un comentario
+	// hasta el final de la línea; dentro de una palabra (a#b) es un carácter
+	// más
+	if l.comments && l.ch == '#' && l.position >= l.runEnd && l.atWordStart() {
+		return l.readComment()
+	}
+	
+	// Los fragmentos sin comillas que no encajan en la gramática de patrones
+	// y rangos se leen completos como un único argumento, como en redis-cli
+	if l.position >= l.runEnd && l.ch != '"' && l.ch != '\'' && l.ch != '\n' {
+		run := l.peekRun()
+		if !isStructuredRun(run) {
+			return l.readBareArgument(run)
+		}
+		l.runEnd = l.position + len(run)
+	}
+	
 	switch l.ch {
 	case '*':
 		tok = l.newToken(ASTERISK, l.ch)
@@ -67,6 +116,10 @@ func (l *Lexer) NextToken() Token {
 		tok = l.newToken(BRACKET_L, l.ch)
 	case ']':
 		tok = l.newToken(BRACKET_R, l.ch)
+	case '{':
+		tok = l.newToken(BRACE_L, l.ch)
+	case '}':
+		tok = l.newToken(BRACE_R, l.ch)
 	case '(':
 		tok = l.newToken(PAREN_L, l.ch)
 	case ')':
@@ -88,43 +141,33 @@ func (l *Lexer) NextToken() Token {
 			if strings.Contains(tok.Literal, ".") {
 				tok.Type = FLOAT
 			}
-			tok.Position = l.position - len(tok.Literal)
-			tok.Line = l.line
-			tok.Column = l.column - len(tok.Literal)
 			return tok
 		}
 		tok = l.newToken(MINUS, l.ch)
-	case '"':
+	case '"', '\'':
+		position, line, column := l.position, l.line, l.column
+		terminated := false
 		tok.Type = STRING
-		startPos := l.position
-		tok.Literal = l.readString()
-		tok.Position = startPos
-		tok.Line = l.line
-		tok.Column = l.column - len(tok.Literal) - 2
-		return tok
-	case '\'':
-		tok.Type = STRING
-		startPos := l.position
-		tok.Literal = l.readSingleQuoteString()
-		tok.Position = startPos
-		tok.Line = l.line
-		tok.Column = l.column - len(tok.Literal) - 2
+		if l.ch == '"' {
+			tok.Literal, terminated = l.readString()
+		} else {
+			tok.Literal, terminated = l.readSingleQuoteString()
+		}
+		if !terminated {
+			l.addError("UNTERMINATED_STRING", "unterminated string literal", 0, position, line, column)
+		} else if !l.atEOF() && !isArgumentSeparator(l.ch) {
+			// redis-cli exige un espacio después de la comilla de cierre
+			r, size := utf8.DecodeRuneInString(l.input[l.position:])
+			l.addRunError("QUOTE_NOT_FOLLOWED_BY_SPACE",
+				fmt.Sprintf("closing quote must be followed by a space, got %q", r), r, 0, size)
+		}
 		return tok
 	case '\n':
 		tok = l.newToken(NEWLINE, l.ch)
-	case 0:
-		tok.Literal = ""
-		tok.Type = EOF
-		tok.Position = l.position
-		tok.Line = l.line
-		tok.Column = l.column
 	default:
 		if isLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = LookupIdent(strings.ToUpper(tok.Literal))
-			tok.Position = l.position - len(tok.Literal)
-			tok.Line = l.line
-			tok.Column = l.column - len(tok.Literal)
 			return tok
 		} else if isDigit(l.ch) {
 			tok.Type = INT
@@ -133,9 +176,6 @@ func (l *Lexer) NextToken() Token {
 			if strings.Contains(tok.Literal, ".") {
 				tok.Type = FLOAT
 			}
-			tok.Position = l.position - len(tok.Literal)
-			tok.Line = l.line
-			tok.Column = l.column - len(tok.Literal)
 			return tok
 		} else {
 			tok = l.newToken(ILLEGAL, l.ch)
@@ -146,6 +186,134 @@ func (l *Lexer) NextToken() Token {
 	return tok
 }
 
+// atWordStart indica si el carácter actual empieza una palabra
+func (l *Lexer) atWordStart() bool {
+	return l.position == 0 || isArgumentSeparator(l.input[l.position-1])
+}
+
+// readComment lee un comentario hasta el final de la línea, sin incluir el
+// salto de línea. Literal es el texto que sigue al #.
+func (l *Lexer) readComment() Token {
+	start := l.position
+	for !l.atEOF() && l.ch != '\n' {
+		l.readChar()
+	}
+	return Token{Type: COMMENT, Literal: strings.TrimRight(l.input[start+1:l.position], "\r")}
+}
+
+// peekRun devuelve el fragmento sin espacios que empieza en el carácter
+// actual, sin avanzar la posición
+func (l *Lexer) peekRun() string {
+	end := l.position
+	for end < len(l.input) && !isArgumentSeparator(l.input[end]) {
+		end++
+	}
+	return l.input[l.position:end]
+}
+
+// readBareArgument lee un fragmento completo como un argumento sin comillas.
+// Los caracteres de control y los bytes que no son UTF-8 válido se
+// registran como errores, pero el fragmento se devuelve igualmente.
+func (l *Lexer) readBareArgument(run string) Token {
+	for offset := 0; offset < len(run); {
+		r, size := utf8.DecodeRuneInString(run[offset:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			l.addRunError("INVALID_UTF8", fmt.Sprintf("invalid UTF-8 byte 0x%02x", run[offset]), r, offset, size)
+		case unicode.IsControl(r):
+			l.addRunError("ILLEGAL_CHARACTER", fmt.Sprintf("illegal character %U", r), r, offset, size)
+		}
+		offset += size
+	}
+	
+	for i := 0; i < len(run); i++ {
+		l.readChar()
+	}
+	return Token{Type: IDENT, Literal: run}
+}
+
+// isStructuredRun indica si un fragmento sin comillas se puede dividir en
+// los tokens que entiende el parser: un símbolo suelto, un número, un rango
+// [a,b] o identificadores y números unidos por ':', '*', '?', '{' y '}'
+func isStructuredRun(run string) bool {
+	if len(run) == 1 && strings.ContainsRune("*?[](){},:|+-", rune(run[0])) {
+		return true
+	}
+	if isNumber(run) {
+		return true
+	}
+	if strings.HasPrefix(run, "[") && strings.HasSuffix(run, "]") {
+		bounds := strings.Split(run[1:len(run)-1], ",")
+		return len(bounds) == 2 && isWord(bounds[0]) && isWord(bounds[1])
+	}
+	
+	for _, segment := range strings.FieldsFunc(run, isPatternSymbol) {
+		if !isWord(segment) {
+			return false
+		}
+	}
+	return true
+}
+
+// isPatternSymbol verifica si el carácter une las partes de un patrón o
+// de una clave con hash tag (ej: {user:1}:profile)
+func isPatternSymbol(r rune) bool {
+	return r == ':' || r == '*' || r == '?' || r == '{' || r == '}'
+}
+
+// isWord verifica si s es un identificador o un número completo
+func isWord(s string) bool {
+	return isIdentifier(s) || isNumber(s)
+}
+
+// isIdentifier verifica si s se lee entero como un identificador
+func isIdentifier(s string) bool {
+	if s == "" || !isLetter(s[0]) {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if !isLetter(s[i]) && !isDigit(s[i]) && s[i] != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// isNumber verifica si s se lee entero como un entero o un flotante en base
+// 10 dentro de rango (int64 o float64 finito). El resto de secuencias de
+// dígitos (99999999999999999999) son argumentos sin comillas.
+func isNumber(s string) bool {
+	integer, fraction, hasFraction := strings.Cut(strings.TrimPrefix(s, "-"), ".")
+	if !isDigits(integer) || (hasFraction && !isDigits(fraction)) {
+		return false
+	}
+	var err error
+	if hasFraction {
+		_, err = strconv.ParseFloat(s, 64)
+	} else {
+		_, err = strconv.ParseInt(s, 10, 64)
+	}
+	return err == nil
+}
+
+// isDigits verifica si s es una secuencia no vacía de dígitos
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isArgumentSeparator verifica si el carácter separa argumentos
+func isArgumentSeparator(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\r' || ch == '\n'
+}
+
 // newToken crea un nuevo token con el tipo y carácter dados
 func (l *Lexer) newToken(tokenType TokenType, ch byte) Token {
 	return Token{
@@ -191,61 +359,120 @@ func (l *Lexer) readNumber() string {
 	return l.input[position:l.position]
 }
 
-// readString lee una cadena entre comillas dobles
-func (l *Lexer) readString() string {
-	position := l.position + 1 // saltar la comilla inicial
+// readString lee una cadena entre comillas dobles y decodifica sus
+// secuencias de escape como redis-cli: \n \r \t \b \a, \xHH y \<c>
+// para cualquier otro carácter. Devuelve false si la cadena no se cierra.
+func (l *Lexer) readString() (string, bool) {
+	var value strings.Builder
 	for {
 		l.readChar()
-		if l.ch == '"' || l.ch == 0 {
-			break
+		if l.atEOF() {
+			return value.String(), false
 		}
-		// Manejar escape sequences
-		if l.ch == '\\' {
-			l.readChar() // saltar el carácter de escape
-			if l.ch != 0 {
-				l.readChar() // saltar el carácter escapado
+		if l.ch == '"' {
+			l.readChar() // avanzar más allá de la comilla de cierre
+			return value.String(), true
+		}
+		if l.ch == '\\' && l.readPosition < len(l.input) {
+			if l.peekChar() == 'x' && isHexDigit(l.peekCharAt(2)) && isHexDigit(l.peekCharAt(3)) {
+				l.readChar() // saltar la 'x'
+				l.readChar()
+				high := hexValue(l.ch)
+				l.readChar()
+				value.WriteByte(high<<4 | hexValue(l.ch))
+				continue
 			}
+			l.readChar()
+			value.WriteByte(unescape(l.ch))
+			continue
 		}
+		value.WriteByte(l.ch)
 	}
-	result := l.input[position:l.position]
-	// Avanzar más allá de la comilla de cierre
-	if l.ch == '"' {
-		l.readChar()
-	}
-	return result
 }
 
-// readSingleQuoteString lee una cadena entre comillas simples
-func (l *Lexer) readSingleQuoteString() string {
-	position := l.position + 1 // saltar la comilla inicial
+// readSingleQuoteString lee una cadena entre comillas simples. Como en
+// redis-cli, la única secuencia de escape es \'
+func (l *Lexer) readSingleQuoteString() (string, bool) {
+	var value strings.Builder
 	for {
 		l.readChar()
-		if l.ch == '\'' || l.ch == 0 {
-			break
+		if l.atEOF() {
+			return value.String(), false
 		}
-		// Manejar escape sequences
-		if l.ch == '\\' {
-			l.readChar() // saltar el carácter de escape
-			if l.ch != 0 {
-				l.readChar() // saltar el carácter escapado
-			}
+		if l.ch == '\'' {
+			l.readChar() // avanzar más allá de la comilla de cierre
+			return value.String(), true
 		}
+		if l.ch == '\\' && l.peekChar() == '\'' {
+			l.readChar()
+		}
+		value.WriteByte(l.ch)
 	}
-	result := l.input[position:l.position]
-	// Avanzar más allá de la comilla de cierre
-	if l.ch == '\'' {
-		l.readChar()
+}
+
+// atEOF indica si el lexer llegó al final del input
+func (l *Lexer) atEOF() bool {
+	return l.position >= len(l.input)
+}
+
+// peekCharAt devuelve el carácter situado n posiciones después del actual
+func (l *Lexer) peekCharAt(n int) byte {
+	if l.position+n >= len(l.input) {
+		return 0
 	}
-	return result
+	return l.input[l.position+n]
 }
 
-// skipWhitespace salta espacios en blanco excepto nuevas líneas
+// unescape devuelve el byte representado por la secuencia \<ch>
+func unescape(ch byte) byte {
+	switch ch {
+	case 'n':
+		return '\n'
+	case 'r':
+		return '\r'
+	case 't':
+		return '\t'
+	case 'b':
+		return '\b'
+	case 'a':
+		return '\a'
+	default:
+		return ch
+	}
+}
+
+// skipWhitespace salta espacios en blanco excepto nuevas líneas. Una barra
+// invertida de continuación se salta junto con su salto de línea, de modo
+// que el comando sigue en la línea siguiente.
 func (l *Lexer) skipWhitespace() {
-	for l.ch == ' ' || l.ch == '\t' || l.ch == '\r' {
-		l.readChar()
+	for {
+		switch {
+		case l.ch == ' ' || l.ch == '\t' || l.ch == '\r':
+			l.readChar()
+		case l.isContinuation():
+			for l.ch != '\n' {
+				l.readChar()
+			}
+			l.readChar()
+		default:
+			return
+		}
 	}
 }
 
+// isContinuation indica si el carácter actual es una barra invertida de
+// continuación: precedida de un espacio y seguida del final de la línea
+func (l *Lexer) isContinuation() bool {
+	if l.ch != '\\' || l.position == 0 {
+		return false
+	}
+	if prev := l.input[l.position-1]; prev != ' ' && prev != '\t' {
+		return false
+	}
+	next := l.peekChar()
+	return next == '\n' || (next == '\r' && l.peekCharAt(2) == '\n')
+}
+
 // isLetter verifica si el carácter es una letra
 func isLetter(ch byte) bool {
 	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
@@ -256,6 +483,23 @@ func isDigit(ch byte) bool {
 	return '0' <= ch && ch <= '9'
 }
 
+// isHexDigit verifica si el carácter es un dígito hexadecimal
+func isHexDigit(ch byte) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
+}
+
+// hexValue devuelve el valor de un dígito hexadecimal
+func hexValue(ch byte) byte {
+	switch {
+	case isDigit(ch):
+		return ch - '0'
+	case 'a' <= ch && ch <= 'f':
+		return ch - 'a' + 10
+	default:
+		return ch - 'A' + 10
+	}
+}
+
 // TokenizeCommand tokeniza un comando Redis completo
 func (l *Lexer) TokenizeCommand() []Token {
 	var tokens []Token
@@ -281,3 +525,41 @@ func GetAllTokens(input string) []Token {
 	return lexer.TokenizeCommand()
 }
 
+
+// Quote devuelve s entre comillas dobles con las secuencias de escape que
+// entiende redis-cli, de forma que el lexer lo decodifique al mismo valor
+func Quote(s string) string {
+	var quoted strings.Builder
+	quoted.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; ch {
+		case '\\', '"':
+			quoted.WriteByte('\\')
+			quoted.WriteByte(ch)
+		case '\n':
+			quoted.WriteString(`\n`)
+		case '\r':
+			quoted.WriteString(`\r`)
+		case '\t':
+			quoted.WriteString(`\t`)
+		case '\b':
+			quoted.WriteString(`\b`)
+		case '\a':
+			quoted.WriteString(`\a`)
+		default:
+			if ch < 0x20 || ch == 0x7f {
+				fmt.Fprintf(&quoted, `\x%02x`, ch)
+			} else if ch < utf8.RuneSelf {
+				quoted.WriteByte(ch)
+			} else if r, size := utf8.DecodeRuneInString(s[i:]); r == utf8.RuneError && size == 1 {
+				// Los bytes que no son UTF-8 válido se escriben en hexadecimal
+				fmt.Fprintf(&quoted, `\x%02x`, ch)
+			} else {
+				quoted.WriteString(s[i : i+size])
+				i += size - 1
+			}
+		}
+	}
+	quoted.WriteByte('"')
+	return quoted.String()
+}
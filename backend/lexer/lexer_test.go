@@ -177,3 +177,281 @@ func TestNumbers(t *testing.T) {
 	}
 }
 
+
+func TestTokenPositionMultiline(t *testing.T) {
+	input := "GET key\nSET \"a b\" 10"
+	lexer := New(input)
+	
+	expected := []struct {
+		literal string
+		start   int
+		line    int
+		column  int
+		end     int
+		endCol  int
+	}{
+		{"GET", 0, 1, 1, 3, 4},
+		{"key", 4, 1, 5, 7, 8},
+		{"\n", 7, 1, 8, 8, 1},
+		{"SET", 8, 2, 1, 11, 4},
+		{"a b", 12, 2, 5, 17, 10},
+		{"10", 18, 2, 11, 20, 13},
+	}
+	
+	for i, exp := range expected {
+		tok := lexer.NextToken()
+		if tok.Literal != exp.literal || tok.Position != exp.start || tok.Line != exp.line ||
+			tok.Column != exp.column || tok.End != exp.end || tok.EndColumn != exp.endCol {
+			t.Errorf("token[%d] wrong position. expected=%+v, got=%s (end %d, col %d)",
+				i, exp, tok, tok.End, tok.EndColumn)
+		}
+	}
+}
+
+func TestLineContinuation(t *testing.T) {
+	tests := []struct {
+		input    string
+		literals []string
+	}{
+		{"HSET h \\\n  a 1 \\\r\n  b 2\nGET h", []string{"HSET", "h", "a", "1", "b", "2", "\n", "GET", "h", ""}},
+		{"SET k a\\\nGET k", []string{"SET", "k", "a\\", "\n", "GET", "k", ""}},
+		{"SET k \"a \\\nb\"", []string{"SET", "k", "a \nb", ""}},
+	}
+	
+	for _, tt := range tests {
+		l := New(tt.input)
+		for i, literal := range tt.literals {
+			if tok := l.NextToken(); tok.Literal != literal {
+				t.Errorf("%q token[%d]: expected %q, got %q", tt.input, i, literal, tok.Literal)
+			}
+		}
+	}
+	
+	// Las posiciones siguen contando las líneas de continuación
+	l := New("HSET h \\\n  a 1")
+	for i := 0; i < 2; i++ {
+		l.NextToken()
+	}
+	if tok := l.NextToken(); tok.Line != 2 || tok.Column != 3 {
+		t.Errorf("Expected a at 2:3, got %d:%d", tok.Line, tok.Column)
+	}
+}
+
+func TestStringEscapes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"a\"b"`, `a"b`},
+		{`"\x00\n"`, "\x00\n"},
+		{`"tab\there"`, "tab\there"},
+		{`"\r\b\a"`, "\r\b\a"},
+		{`"back\\slash"`, `back\slash`},
+		{`"\xZZ"`, "xZZ"},
+		{`"\x4a\x4B"`, "JK"},
+		{`"\q"`, "q"},
+		{`'it\'s'`, "it's"},
+		{`'a\nb'`, `a\nb`},
+		{`'"quoted"'`, `"quoted"`},
+		{`"caf\xc3\xa9"`, "café"},
+	}
+	
+	for _, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+		
+		if tok.Type != STRING {
+			t.Fatalf("%s - expected STRING, got %s", tt.input, tok.Type)
+		}
+		if tok.Literal != tt.expected {
+			t.Errorf("%s - wrong value. expected=%q, got=%q", tt.input, tt.expected, tok.Literal)
+		}
+		if tok.Raw != tt.input {
+			t.Errorf("%s - wrong raw text. got=%q", tt.input, tok.Raw)
+		}
+		if len(l.Errors()) != 0 {
+			t.Errorf("%s - unexpected errors: %v", tt.input, l.Errors())
+		}
+		if next := l.NextToken(); next.Type != EOF {
+			t.Errorf("%s - expected EOF after string, got %s", tt.input, next)
+		}
+	}
+}
+
+func TestUnterminatedString(t *testing.T) {
+	tests := []string{`SET k "abc`, `SET k 'abc`, `SET k "abc\"`, `SET k "`}
+	
+	for _, input := range tests {
+		l := New(input)
+		tokens := l.TokenizeCommand()
+		
+		if tokens[len(tokens)-1].Type != EOF || tokens[len(tokens)-2].Type != STRING {
+			t.Errorf("%s - expected STRING then EOF, got %v", input, tokens)
+		}
+		
+		errors := l.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("%s - expected 1 error, got %v", input, errors)
+		}
+		if errors[0].Code != "UNTERMINATED_STRING" || errors[0].Position != 6 || errors[0].End != len(input) {
+			t.Errorf("%s - wrong error: %+v", input, errors[0])
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := []string{"plain", `a"b`, `back\slash`, "line\nbreak\r\t", "\x00\x7f\a\b", "café", "\xff\xc3", ""}
+	
+	for _, value := range tests {
+		quoted := Quote(value)
+		tok := New(quoted).NextToken()
+		if tok.Type != STRING || tok.Literal != value {
+			t.Errorf("Quote(%q) = %s decodes to %q", value, quoted, tok.Literal)
+		}
+	}
+	
+	if got := Quote(`a"b`); got != `"a\"b"` {
+		t.Errorf("unexpected quoting: %s", got)
+	}
+	if got := Quote("é\xff"); got != `"é\xff"` {
+		t.Errorf("expected invalid UTF-8 to be escaped, got %s", got)
+	}
+}
+
+func TestBareArguments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []TokenType
+		literals []string
+	}{
+		{"GET user.profile", []TokenType{IDENT, IDENT, EOF}, []string{"GET", "user.profile", ""}},
+		{"GET cache@v2", []TokenType{IDENT, IDENT, EOF}, []string{"GET", "cache@v2", ""}},
+		{"GET {tag}:x", []TokenType{IDENT, BRACE_L, IDENT, BRACE_R, COLON, IDENT, EOF}, []string{"GET", "{", "tag", "}", ":", "x", ""}},
+		{"GET {a b", []TokenType{IDENT, BRACE_L, IDENT, IDENT, EOF}, []string{"GET", "{", "a", "b", ""}},
+		{"GET {x.y}z", []TokenType{IDENT, IDENT, EOF}, []string{"GET", "{x.y}z", ""}},
+		{"GET café", []TokenType{IDENT, IDENT, EOF}, []string{"GET", "café", ""}},
+		{"CONFIG SET maxmemory 100mb", []TokenType{IDENT, IDENT, IDENT, IDENT, EOF}, []string{"CONFIG", "SET", "maxmemory", "100mb", ""}},
+		{"ZRANGEBYSCORE z -inf (5", []TokenType{IDENT, IDENT, IDENT, IDENT, EOF}, []string{"ZRANGEBYSCORE", "z", "-inf", "(5", ""}},
+		{"ACL SETUSER u >pass ~* +@all", []TokenType{IDENT, IDENT, IDENT, IDENT, IDENT, IDENT, EOF}, []string{"ACL", "SETUSER", "u", ">pass", "~*", "+@all", ""}},
+		{"SET a#b", []TokenType{IDENT, IDENT, EOF}, []string{"SET", "a#b", ""}},
+		{"ZRANGE z [1,5]", []TokenType{IDENT, IDENT, BRACKET_L, INT, COMMA, INT, BRACKET_R, EOF}, []string{"ZRANGE", "z", "[", "1", ",", "5", "]", ""}},
+		{"KEYS user:*", []TokenType{IDENT, IDENT, COLON, ASTERISK, EOF}, []string{"KEYS", "user", ":", "*", ""}},
+	}
+	
+	for _, tt := range tests {
+		l := New(tt.input)
+		for i, expectedType := range tt.expected {
+			tok := l.NextToken()
+			if tok.Type != expectedType || tok.Literal != tt.literals[i] {
+				t.Errorf("%q token[%d]: expected %s %q, got %s %q", tt.input, i, expectedType, tt.literals[i], tok.Type, tok.Literal)
+			}
+		}
+		if len(l.Errors()) != 0 {
+			t.Errorf("%q: unexpected errors %v", tt.input, l.Errors())
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := "# setup\nSET k \"a # b\" #ttl\r\nGET a#b '#' #\n"
+	
+	tests := []struct {
+		expectedType    TokenType
+		expectedLiteral string
+		expectedRaw     string
+	}{
+		{COMMENT, " setup", "# setup"},
+		{NEWLINE, "\n", "\n"},
+		{IDENT, "SET", "SET"},
+		{IDENT, "k", "k"},
+		{STRING, "a # b", `"a # b"`},
+		{COMMENT, "ttl", "#ttl\r"},
+		{NEWLINE, "\n", "\n"},
+		{IDENT, "GET", "GET"},
+		{IDENT, "a#b", "a#b"},
+		{STRING, "#", "'#'"},
+		{COMMENT, "", "#"},
+		{NEWLINE, "\n", "\n"},
+		{EOF, "", ""},
+	}
+	
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral || tok.Raw != tt.expectedRaw {
+			t.Fatalf("tests[%d] - expected %s %q (%q), got %s %q (%q)",
+				i, tt.expectedType, tt.expectedLiteral, tt.expectedRaw, tok.Type, tok.Literal, tok.Raw)
+		}
+	}
+	if len(l.Errors()) != 0 {
+		t.Errorf("unexpected errors %v", l.Errors())
+	}
+}
+
+func TestCommandWithoutComments(t *testing.T) {
+	// Un comando suelto no tiene comentarios: # es un argumento más
+	l := NewCommand("SORT l GET # #x")
+	expected := []string{"SORT", "l", "GET", "#", "#x", ""}
+	for i, literal := range expected {
+		tok := l.NextToken()
+		if tok.Type == COMMENT || tok.Literal != literal {
+			t.Fatalf("tests[%d] - expected %q, got %s %q", i, literal, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestLexerErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		code     string
+		r        rune
+		position int
+		end      int
+	}{
+		{"GET a\x01b", "ILLEGAL_CHARACTER", '\x01', 5, 6},
+		{"GET k\xff", "INVALID_UTF8", '�', 5, 6},
+		{"SET k \"v\"x", "QUOTE_NOT_FOLLOWED_BY_SPACE", 'x', 9, 10},
+		{"GET a\x00b", "ILLEGAL_CHARACTER", '\x00', 5, 6},
+	}
+	
+	for _, tt := range tests {
+		l := New(tt.input)
+		l.TokenizeCommand()
+		
+		errors := l.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("%q: expected 1 error, got %v", tt.input, errors)
+		}
+		err := errors[0]
+		if err.Code != tt.code || err.Rune != tt.r || err.Position != tt.position || err.End != tt.end {
+			t.Errorf("%q: expected %s %q at %d-%d, got %+v", tt.input, tt.code, tt.r, tt.position, tt.end, err)
+		}
+		if err.Line != 1 || err.Column != tt.position+1 {
+			t.Errorf("%q: wrong line/column %d:%d", tt.input, err.Line, err.Column)
+		}
+	}
+}
+
+func TestNumberTokens(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected TokenType
+	}{
+		{"09", INT},
+		{"0012", INT},
+		{"-5", INT},
+		{"9223372036854775807", INT},
+		{"9223372036854775808", IDENT},
+		{"99999999999999999999", IDENT},
+		{"-99999999999999999999", IDENT},
+		{"1.50", FLOAT},
+		{"0x10", IDENT},
+	}
+	
+	for _, tt := range tests {
+		tok := New(tt.input).NextToken()
+		if tok.Type != tt.expected || tok.Literal != tt.input {
+			t.Errorf("%q: expected %s with the same literal, got %s", tt.input, tt.expected, tok)
+		}
+	}
+}
@@ -19,12 +19,15 @@ const (
 	// Delimitadores
 	SPACE     // espacios
 	NEWLINE   // nueva línea
+	COMMENT   // comentario # hasta el final de la línea
 	
 	// Símbolos especiales de Redis
 	ASTERISK  // * (usado en patrones)
 	QUESTION  // ? (usado en patrones)
 	BRACKET_L // [ (usado en rangos)
 	BRACKET_R // ] (usado en rangos)
+	BRACE_L   // { (usado en hash tags de Redis Cluster)
+	BRACE_R   // } (usado en hash tags de Redis Cluster)
 	PAREN_L   // ( (usado en algunos comandos)
 	PAREN_R   // ) (usado en algunos comandos)
 	COMMA     // , (separador en algunos comandos)
@@ -49,9 +52,15 @@ const (
 type Token struct {
 	Type     TokenType
 	Literal  string
-	Position int
+	Position int // desplazamiento en bytes del inicio del token
 	Line     int
 	Column   int
+	Raw      string // texto original del token en el input (con comillas)
+	
+	// Final exclusivo del token (posición del carácter siguiente)
+	End       int
+	EndLine   int
+	EndColumn int
 }
 
 // String devuelve una representación en string del token
@@ -79,6 +88,8 @@ func (tt TokenType) String() string {
 		return "SPACE"
 	case NEWLINE:
 		return "NEWLINE"
+	case COMMENT:
+		return "COMMENT"
 	case ASTERISK:
 		return "ASTERISK"
 	case QUESTION:
@@ -87,6 +98,10 @@ func (tt TokenType) String() string {
 		return "BRACKET_L"
 	case BRACKET_R:
 		return "BRACKET_R"
+	case BRACE_L:
+		return "BRACE_L"
+	case BRACE_R:
+		return "BRACE_R"
 	case PAREN_L:
 		return "PAREN_L"
 	case PAREN_R:
@@ -137,6 +152,11 @@ var keywords = map[string]TokenType{
 	"TYPE":       TYPE,
 }
 
+// IsKeyword indica si el tipo de token es una palabra clave de Redis
+func (tt TokenType) IsKeyword() bool {
+	return tt >= EX && tt <= TYPE
+}
+
 // LookupIdent verifica si un identificador es una palabra clave
 func LookupIdent(ident string) TokenType {
 	if tok, ok := keywords[ident]; ok {
@@ -0,0 +1,261 @@
+package lint
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"redis-analyzer-api/parser"
+)
+
+// Formatos de salida admitidos por Write
+const (
+	FormatText  = "text"
+	FormatJSON  = "json"
+	FormatSARIF = "sarif"
+	FormatJUnit = "junit"
+)
+
+// Formats son los formatos de salida admitidos, en el orden de la ayuda
+var Formats = []string{FormatText, FormatJSON, FormatSARIF, FormatJUnit}
+
+// ToolName es el nombre de la herramienta en los informes SARIF y JUnit
+const ToolName = "redis-analyzer"
+
+// Write escribe los diagnósticos de los archivos en el formato indicado
+func Write(w io.Writer, format string, files []File) error {
+	switch format {
+	case FormatText:
+		return writeText(w, files)
+	case FormatJSON:
+		return writeJSON(w, files)
+	case FormatSARIF:
+		return writeSARIF(w, files)
+	case FormatJUnit:
+		return writeJUnit(w, files)
+	}
+	return CheckFormat(format)
+}
+
+// CheckFormat devuelve un error si el formato no es uno de Formats
+func CheckFormat(format string) error {
+	for _, f := range Formats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown format %q (expected one of: %s)", format, strings.Join(Formats, ", "))
+}
+
+// writeText escribe una línea "archivo:línea:columna: severidad código
+// mensaje" por diagnóstico, el formato que entienden los editores
+func writeText(w io.Writer, files []File) error {
+	for _, file := range files {
+		for _, d := range file.Diagnostics {
+			if _, err := fmt.Fprintf(w, "%s:%s\n", file.Name, d); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// jsonFile es un archivo en la salida JSON
+type jsonFile struct {
+	File        string              `json:"file"`
+	Diagnostics []parser.Diagnostic `json:"diagnostics"`
+	Suppressed  int                 `json:"suppressed"`
+}
+
+func writeJSON(w io.Writer, files []File) error {
+	report := make([]jsonFile, 0, len(files))
+	for _, file := range files {
+		report = append(report, jsonFile{File: file.Name, Diagnostics: file.Diagnostics, Suppressed: file.Suppressed})
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// Tipos del subconjunto de SARIF 2.1.0 que se genera
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
+
+// sarifLevels traduce las severidades a los niveles de SARIF
+var sarifLevels = map[parser.Severity]string{
+	parser.SeverityError:   "error",
+	parser.SeverityWarning: "warning",
+	parser.SeverityInfo:    "note",
+}
+
+// writeSARIF escribe un informe SARIF 2.1.0, el formato que importan GitHub
+// code scanning y otros sistemas de análisis estático
+func writeSARIF(w io.Writer, files []File) error {
+	rules := map[string]bool{}
+	results := []sarifResult{}
+	for _, file := range files {
+		for _, d := range file.Diagnostics {
+			rules[d.Code] = true
+			results = append(results, sarifResult{
+				RuleID:  d.Code,
+				Level:   sarifLevels[d.Severity],
+				Message: sarifMessage{Text: d.Message},
+				Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(file.Name)},
+					Region: sarifRegion{
+						StartLine:   d.Span.Start.Line,
+						StartColumn: d.Span.Start.Column,
+						EndLine:     d.Span.End.Line,
+						EndColumn:   d.Span.End.Column,
+					},
+				}}},
+			})
+		}
+	}
+
+	ids := make([]string, 0, len(rules))
+	for id := range rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	driver := sarifDriver{Name: ToolName, Rules: []sarifRule{}}
+	for _, id := range ids {
+		driver.Rules = append(driver.Rules, sarifRule{ID: id})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	})
+}
+
+// Tipos del formato JUnit XML
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failures  []junitResult `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitResult struct {
+	Type    string `xml:"type,attr"`
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnit escribe un informe JUnit XML con un caso por archivo: los
+// errores son fallos y los avisos van en system-out, de modo que los
+// sistemas de CI muestran qué scripts no pasan el lint
+func writeJUnit(w io.Writer, files []File) error {
+	report := junitTestSuites{Name: ToolName, Suites: []junitTestSuite{}}
+	for _, file := range files {
+		testCase := junitTestCase{Name: file.Name, ClassName: ToolName}
+		var notes []string
+		for _, d := range file.Diagnostics {
+			location := fmt.Sprintf("%s:%d:%d", file.Name, d.Span.Start.Line, d.Span.Start.Column)
+			if d.Severity == parser.SeverityError {
+				testCase.Failures = append(testCase.Failures, junitResult{
+					Type:    d.Code,
+					Message: d.Message,
+					Text:    location,
+				})
+			} else {
+				notes = append(notes, file.Name+":"+d.String())
+			}
+		}
+		testCase.SystemOut = strings.Join(notes, "\n")
+
+		suite := junitTestSuite{Name: file.Name, Tests: 1, TestCases: []junitTestCase{testCase}}
+		if len(testCase.Failures) > 0 {
+			suite.Failures = 1
+		}
+		report.Tests++
+		report.Failures += suite.Failures
+		report.Suites = append(report.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
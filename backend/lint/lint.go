@@ -0,0 +1,221 @@
+package lint
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"redis-analyzer-api/parser"
+	"redis-analyzer-api/policy"
+	"redis-analyzer-api/semantic"
+)
+
+// Extension es la extensión de los scripts que se buscan en los directorios
+const Extension = ".redis"
+
+// Stdin es el nombre con el que se reporta la entrada estándar
+const Stdin = "<stdin>"
+
+// File contiene los diagnósticos de un archivo, ordenados por posición
+type File struct {
+	Name        string
+	Diagnostics []parser.Diagnostic
+	Suppressed  int // diagnósticos ocultados con lint:disable
+}
+
+// Linter analiza scripts de comandos Redis sin conectarse a ningún servidor
+type Linter struct {
+	analyzer *semantic.Analyzer
+	policy   *policy.Policy // nil no aplica ninguna política
+}
+
+// New crea un linter que valida con el analizador dado
+func New(analyzer *semantic.Analyzer) *Linter {
+	return &Linter{analyzer: analyzer}
+}
+
+// SetPolicy hace que los comandos denegados por la política se reporten
+// como errores POLICY
+func (l *Linter) SetPolicy(p *policy.Policy) {
+	l.policy = p
+}
+
+// Lint analiza el contenido de un script: lo parsea como programa, lo valida
+// con ValidateProgram y descarta los diagnósticos suprimidos con comentarios
+// lint:disable
+func (l *Linter) Lint(name, source string) File {
+	program, diagnostics := parser.ParseCommandsWithDiagnostics(source)
+
+	results := l.analyzer.ValidateProgram(program)
+	i := 0
+	for _, cmd := range program.Commands() {
+		if i >= len(results) {
+			break
+		}
+		if l.policy != nil {
+			l.policy.Check(l.analyzer, cmd, &results[i])
+		}
+		for _, d := range results[i].Diagnostics {
+			// Los diagnósticos sin posición se anclan al comando
+			if d.Span.IsZero() {
+				d.Span = cmd.Span()
+			}
+			diagnostics = append(diagnostics, d)
+		}
+		i++
+	}
+
+	sort.SliceStable(diagnostics, func(a, b int) bool {
+		return diagnostics[a].Span.Start.Offset < diagnostics[b].Span.Start.Offset
+	})
+
+	file := File{Name: name, Diagnostics: []parser.Diagnostic{}}
+	suppressions := parseSuppressions(program)
+	for _, d := range diagnostics {
+		if suppressions.suppresses(d) {
+			file.Suppressed++
+			continue
+		}
+		file.Diagnostics = append(file.Diagnostics, d)
+	}
+	return file
+}
+
+// LintReader analiza el script que se lee de r
+func (l *Linter) LintReader(name string, r io.Reader) (File, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return File{Name: name}, err
+	}
+	return l.Lint(name, string(data)), nil
+}
+
+// LintFile analiza un archivo; "-" es la entrada estándar
+func (l *Linter) LintFile(path string) (File, error) {
+	if path == "-" {
+		return l.LintReader(Stdin, os.Stdin)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return File{Name: path}, err
+	}
+	return l.Lint(path, string(data)), nil
+}
+
+// ExpandPaths sustituye los directorios por los archivos .redis que
+// contienen, recursivamente y en orden alfabético
+func ExpandPaths(paths []string) ([]string, error) {
+	files := []string{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if path == "-" || (err == nil && !info.IsDir()) {
+			files = append(files, path)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		err = filepath.WalkDir(path, func(p string, entry fs.DirEntry, err error) error {
+			if err == nil && !entry.IsDir() && filepath.Ext(p) == Extension {
+				files = append(files, p)
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// HasErrors indica si algún archivo tiene diagnósticos de error o, con
+// strict, de cualquier gravedad
+func HasErrors(files []File, strict bool) bool {
+	for _, file := range files {
+		for _, d := range file.Diagnostics {
+			if strict || d.Severity == parser.SeverityError {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// disablePattern reconoce "lint:disable" y "lint:disable=CODE1,CODE2"
+var disablePattern = regexp.MustCompile(`lint:disable(?:=([A-Za-z0-9_,\- ]+))?`)
+
+// suppressions son los códigos desactivados en todo el archivo y por línea.
+// Un conjunto vacío desactiva todos los códigos.
+type suppressions struct {
+	file  map[string]bool
+	lines map[int]map[string]bool
+}
+
+// parseSuppressions interpreta los comentarios lint:disable: en una línea
+// propia afectan a todo el archivo y tras un comando solo a las líneas de
+// ese comando
+func parseSuppressions(program *parser.Program) suppressions {
+	// Primera línea del comando al que sigue cada comentario final; un
+	// comando partido en líneas de continuación ocupa varias
+	firstLine := map[*parser.Comment]int{}
+	for _, cmd := range program.Commands() {
+		if cmd.Trailing != nil {
+			firstLine[cmd.Trailing] = cmd.Span().Start.Line
+		}
+	}
+
+	s := suppressions{lines: map[int]map[string]bool{}}
+	for _, c := range program.Comments {
+		match := disablePattern.FindStringSubmatch(c.Text)
+		if match == nil {
+			continue
+		}
+		codes := map[string]bool{}
+		for _, code := range strings.Split(match[1], ",") {
+			if code = strings.ToUpper(strings.TrimSpace(code)); code != "" {
+				codes[code] = true
+			}
+		}
+
+		if c.Trailing {
+			first, ok := firstLine[c]
+			if !ok {
+				first = c.Loc.Start.Line
+			}
+			for line := first; line <= c.Loc.Start.Line; line++ {
+				s.lines[line] = mergeCodes(s.lines[line], codes)
+			}
+		} else {
+			s.file = mergeCodes(s.file, codes)
+		}
+	}
+	return s
+}
+
+// mergeCodes une dos conjuntos de códigos; nil es "ninguno" y un conjunto
+// vacío es "todos"
+func mergeCodes(current, codes map[string]bool) map[string]bool {
+	if current == nil {
+		return codes
+	}
+	if len(current) == 0 || len(codes) == 0 {
+		return map[string]bool{}
+	}
+	for code := range codes {
+		current[code] = true
+	}
+	return current
+}
+
+// suppresses indica si un diagnóstico está desactivado
+func (s suppressions) suppresses(d parser.Diagnostic) bool {
+	return matchesCodes(s.file, d.Code) || matchesCodes(s.lines[d.Span.Start.Line], d.Code)
+}
+
+func matchesCodes(codes map[string]bool, code string) bool {
+	return codes != nil && (len(codes) == 0 || codes[code])
+}
@@ -0,0 +1,174 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"redis-analyzer-api/policy"
+	"redis-analyzer-api/semantic"
+)
+
+const testScript = `# Datos de prueba
+SET user:1 alice EX
+GET user:1 extra # lint:disable=EXCESSIVE_ARGS
+SET "a # b" 1
+FOO
+`
+
+func TestLint(t *testing.T) {
+	file := New(semantic.New()).Lint("test.redis", testScript)
+
+	expected := []string{
+		"2:20: error MISSING_OPTION_VALUE",
+		"5:1: error UNKNOWN_COMMAND",
+	}
+	if len(file.Diagnostics) != len(expected) {
+		t.Fatalf("Expected %d diagnostics, got %v", len(expected), file.Diagnostics)
+	}
+	for i, d := range file.Diagnostics {
+		if !strings.HasPrefix(d.String(), expected[i]) {
+			t.Errorf("Expected %q, got %q", expected[i], d.String())
+		}
+	}
+	if file.Suppressed != 1 {
+		t.Errorf("Expected 1 suppressed diagnostic, got %d", file.Suppressed)
+	}
+}
+
+func TestSuppressions(t *testing.T) {
+	linter := New(semantic.New())
+
+	tests := []struct {
+		name       string
+		source     string
+		remaining  int
+		suppressed int
+	}{
+		{name: "file-wide", source: "# lint:disable=UNKNOWN_COMMAND\nFOO\nBAR\nGET k x\n", remaining: 1, suppressed: 2},
+		{name: "several codes", source: "# lint:disable=unknown_command, EXCESSIVE_ARGS\nFOO\nGET k x\n", remaining: 0, suppressed: 2},
+		{name: "all codes", source: "FOO # lint:disable\nBAR\n", remaining: 1, suppressed: 1},
+		{name: "continuation", source: "FOO a \\\n  b # lint:disable\nBAR\n", remaining: 1, suppressed: 1},
+		{name: "other line", source: "FOO\nGET k # lint:disable=UNKNOWN_COMMAND\n", remaining: 1, suppressed: 0},
+		{name: "quoted", source: "SET k \"# lint:disable\" x\n", remaining: 1, suppressed: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := linter.Lint("test.redis", tt.source)
+			if len(file.Diagnostics) != tt.remaining || file.Suppressed != tt.suppressed {
+				t.Errorf("Expected %d diagnostics and %d suppressed, got %v and %d",
+					tt.remaining, tt.suppressed, file.Diagnostics, file.Suppressed)
+			}
+		})
+	}
+}
+
+func TestLintPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	os.WriteFile(path, []byte("default: allow\nrules:\n  - name: no-flush\n    effect: deny\n    commands: [FLUSHALL]\n"), 0644)
+	p, err := policy.LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	linter := New(semantic.New())
+	linter.SetPolicy(p)
+	file := linter.Lint("test.redis", "GET k\nFLUSHALL\n")
+	if len(file.Diagnostics) != 1 || file.Diagnostics[0].Code != "POLICY" || file.Diagnostics[0].Span.Start.Line != 2 {
+		t.Errorf("Expected a POLICY error on line 2, got %v", file.Diagnostics)
+	}
+}
+
+func TestExpandPaths(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "sub"), 0755)
+	for _, name := range []string{"b.redis", "a.txt", "sub/c.redis"} {
+		os.WriteFile(filepath.Join(dir, name), []byte("GET k\n"), 0644)
+	}
+	explicit := filepath.Join(dir, "a.txt")
+
+	files, err := ExpandPaths([]string{dir, explicit, "-"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{filepath.Join(dir, "b.redis"), filepath.Join(dir, "sub", "c.redis"), explicit, "-"}
+	if strings.Join(files, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, files)
+	}
+
+	if _, err := ExpandPaths([]string{filepath.Join(dir, "missing")}); err == nil {
+		t.Errorf("Expected an error for a missing path")
+	}
+}
+
+func TestHasErrors(t *testing.T) {
+	files := []File{New(semantic.New()).Lint("a.redis", "MGET a b\n")}
+	if len(files[0].Diagnostics) == 0 {
+		t.Fatal("Expected a CROSSSLOT warning")
+	}
+	if HasErrors(files, false) {
+		t.Errorf("Expected warnings not to fail without strict")
+	}
+	if !HasErrors(files, true) {
+		t.Errorf("Expected warnings to fail with strict")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	files := []File{
+		New(semantic.New()).Lint("bad.redis", "GET k x\n"),
+		New(semantic.New()).Lint("good.redis", "GET k\n"),
+	}
+
+	var text bytes.Buffer
+	if err := Write(&text, FormatText, files); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(text.String(), "bad.redis:1:7: error EXCESSIVE_ARGS ") || strings.Count(text.String(), "\n") != 1 {
+		t.Errorf("Unexpected text output: %q", text.String())
+	}
+
+	var out bytes.Buffer
+	if err := Write(&out, FormatJSON, files); err != nil {
+		t.Fatal(err)
+	}
+	var report []jsonFile
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil || len(report) != 2 || len(report[0].Diagnostics) != 1 {
+		t.Errorf("Unexpected JSON output (%v): %s", err, out.String())
+	}
+
+	out.Reset()
+	if err := Write(&out, FormatSARIF, files); err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(out.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	results := log.Runs[0].Results
+	if log.Version != "2.1.0" || len(results) != 1 || results[0].RuleID != "EXCESSIVE_ARGS" ||
+		results[0].Level != "error" || results[0].Locations[0].PhysicalLocation.Region.StartColumn != 7 {
+		t.Errorf("Unexpected SARIF output: %s", out.String())
+	}
+
+	out.Reset()
+	if err := Write(&out, FormatJUnit, files); err != nil {
+		t.Fatal(err)
+	}
+	var suites junitTestSuites
+	if err := xml.Unmarshal(out.Bytes(), &suites); err != nil {
+		t.Fatal(err)
+	}
+	if suites.Tests != 2 || suites.Failures != 1 || suites.Suites[0].TestCases[0].Failures[0].Type != "EXCESSIVE_ARGS" {
+		t.Errorf("Unexpected JUnit output: %s", out.String())
+	}
+
+	if err := Write(&out, "yaml", files); err == nil {
+		t.Errorf("Expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,255 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets son los límites por defecto de los histogramas, en segundos
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Registry agrupa métricas y las escribe en el formato de texto de
+// Prometheus. Las métricas se escriben en el orden en que se registraron.
+type Registry struct {
+	mu       sync.Mutex
+	families []family
+}
+
+// family es una métrica con todas sus series
+type family interface {
+	write(w *bufio.Writer)
+}
+
+// NewRegistry crea un registro vacío
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.families = append(r.families, f)
+}
+
+// Write escribe todas las métricas en formato de texto de Prometheus
+// (text/plain; version=0.0.4)
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	families := append([]family{}, r.families...)
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		f.write(bw)
+	}
+	return bw.Flush()
+}
+
+// Counter es un contador con etiquetas que solo puede crecer
+type Counter struct {
+	name, help string
+	labels     []string
+	mu         sync.Mutex
+	values     map[string]*counterSeries
+}
+
+type counterSeries struct {
+	labels []string
+	value  float64
+}
+
+// NewCounter registra un contador con las etiquetas dadas
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{name: name, help: help, labels: labels, values: map[string]*counterSeries{}}
+	r.register(c)
+	return c
+}
+
+// Inc incrementa en uno la serie con los valores de etiqueta dados, en el
+// orden en que se declararon las etiquetas
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add suma v a la serie con los valores de etiqueta dados
+func (c *Counter) Add(v float64, labelValues ...string) {
+	checkLabels(c.name, c.labels, labelValues)
+	key := strings.Join(labelValues, "\xff")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	series, ok := c.values[key]
+	if !ok {
+		series = &counterSeries{labels: append([]string{}, labelValues...)}
+		c.values[key] = series
+	}
+	series.value += v
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	writeHeader(w, c.name, c.help, "counter")
+	for _, key := range sortedKeys(c.values) {
+		series := c.values[key]
+		writeSample(w, c.name, c.labels, series.labels, "", "", series.value)
+	}
+}
+
+// Histogram cuenta observaciones en buckets acumulativos, con etiquetas
+type Histogram struct {
+	name, help string
+	labels     []string
+	buckets    []float64
+	mu         sync.Mutex
+	values     map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labels []string
+	counts []uint64 // una cuenta por bucket, sin acumular
+	count  uint64
+	sum    float64
+}
+
+// NewHistogram registra un histograma con los límites dados (DefaultBuckets
+// si buckets es nil)
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64{}, buckets...)
+	sort.Float64s(buckets)
+	h := &Histogram{name: name, help: help, labels: labels, buckets: buckets, values: map[string]*histogramSeries{}}
+	r.register(h)
+	return h
+}
+
+// Observe añade una observación a la serie con los valores de etiqueta dados
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	checkLabels(h.name, h.labels, labelValues)
+	key := strings.Join(labelValues, "\xff")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	series, ok := h.values[key]
+	if !ok {
+		series = &histogramSeries{labels: append([]string{}, labelValues...), counts: make([]uint64, len(h.buckets))}
+		h.values[key] = series
+	}
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		series.counts[i]++
+	}
+	series.count++
+	series.sum += v
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	writeHeader(w, h.name, h.help, "histogram")
+	for _, key := range sortedKeys(h.values) {
+		series := h.values[key]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += series.counts[i]
+			writeSample(w, h.name+"_bucket", h.labels, series.labels, "le", formatFloat(bound), float64(cumulative))
+		}
+		writeSample(w, h.name+"_bucket", h.labels, series.labels, "le", "+Inf", float64(series.count))
+		writeSample(w, h.name+"_sum", h.labels, series.labels, "", "", series.sum)
+		writeSample(w, h.name+"_count", h.labels, series.labels, "", "", float64(series.count))
+	}
+}
+
+// funcMetric es una métrica sin etiquetas cuyo valor se lee al exportarla
+type funcMetric struct {
+	name, help, kind string
+	fn               func() float64
+}
+
+// NewGaugeFunc registra un gauge cuyo valor devuelve fn en cada lectura
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{name: name, help: help, kind: "gauge", fn: fn})
+}
+
+// NewCounterFunc registra un contador mantenido fuera del registro, p.ej.
+// por una librería; fn devuelve su valor actual
+func (r *Registry) NewCounterFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{name: name, help: help, kind: "counter", fn: fn})
+}
+
+func (m *funcMetric) write(w *bufio.Writer) {
+	writeHeader(w, m.name, m.help, m.kind)
+	writeSample(w, m.name, nil, nil, "", "", m.fn())
+}
+
+// checkLabels comprueba que se indique un valor por etiqueta; un número
+// distinto es un error de programación
+func checkLabels(name string, labels, values []string) {
+	if len(labels) != len(values) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", name, len(labels), len(values)))
+	}
+}
+
+func writeHeader(w *bufio.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+}
+
+// writeSample escribe una línea "nombre{etiquetas} valor". extraName y
+// extraValue añaden una etiqueta más, como le en los buckets.
+func writeSample(w *bufio.Writer, name string, labels, values []string, extraName, extraValue string, value float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extraName != "" {
+		w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, `%s="%s"`, label, escapeLabel(values[i]))
+		}
+		if extraName != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, `%s="%s"`, extraName, extraValue)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	registry := NewRegistry()
+	requests := registry.NewCounter("requests_total", "Total requests.", "route", "status")
+	latency := registry.NewHistogram("latency_seconds", "Latency.", []float64{0.1, 1}, "route")
+	registry.NewGaugeFunc("connections", "Open connections.", func() float64 { return 3 })
+
+	requests.Inc("/a", "200")
+	requests.Inc("/a", "200")
+	requests.Add(5, `/b"c`, "500")
+	latency.Observe(0.05, "/a")
+	latency.Observe(0.1, "/a")
+	latency.Observe(2, "/a")
+
+	var buf bytes.Buffer
+	if err := registry.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `# HELP requests_total Total requests.
+# TYPE requests_total counter
+requests_total{route="/a",status="200"} 2
+requests_total{route="/b\"c",status="500"} 5
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/a",le="0.1"} 2
+latency_seconds_bucket{route="/a",le="1"} 2
+latency_seconds_bucket{route="/a",le="+Inf"} 3
+latency_seconds_sum{route="/a"} 2.15
+latency_seconds_count{route="/a"} 3
+# HELP connections Open connections.
+# TYPE connections gauge
+connections 3
+`
+	if got := buf.String(); got != expected {
+		t.Errorf("Unexpected output:\n%s\nExpected:\n%s", got, expected)
+	}
+}
+
+func TestLabelMismatch(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(r.(string), "expects 2 label values") {
+			t.Errorf("Expected a panic for missing label values, got %v", r)
+		}
+	}()
+	NewRegistry().NewCounter("c", "", "a", "b").Inc("x")
+}
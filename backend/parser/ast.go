@@ -2,6 +2,8 @@ package parser
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"redis-analyzer-api/lexer"
 )
 
@@ -9,6 +11,7 @@ import (
 type Node interface {
 	String() string
 	Type() string
+	Span() Span
 }
 
 // Statement representa una declaración en Redis
@@ -23,89 +26,107 @@ type Expression interface {
 	expressionNode()
 }
 
-// RedisCommand representa un comando Redis completo
+// RedisCommand representa un comando Redis completo. Leading son los
+// comentarios en líneas propias justo antes del comando y Trailing el
+// comentario que lo sigue en su última línea.
 type RedisCommand struct {
 	Command   *Identifier
 	Arguments []Expression
+	Loc       Span
+	Leading   []*Comment
+	Trailing  *Comment
 }
 
 func (rc *RedisCommand) statementNode() {}
 func (rc *RedisCommand) String() string {
-	args := ""
-	for i, arg := range rc.Arguments {
-		if i > 0 {
-			args += " "
-		}
-		args += arg.String()
+	words := []string{rc.Command.String()}
+	for _, arg := range rc.Arguments {
+		words = append(words, arg.String())
 	}
-	return fmt.Sprintf("%s %s", rc.Command.String(), args)
+	return strings.Join(words, " ")
 }
 func (rc *RedisCommand) Type() string { return "RedisCommand" }
+func (rc *RedisCommand) Span() Span   { return rc.Loc }
 
 // Identifier representa un identificador (comando o clave)
 type Identifier struct {
 	Token lexer.Token
 	Value string
+	Loc   Span
 }
 
 func (i *Identifier) expressionNode() {}
 func (i *Identifier) String() string  { return i.Value }
 func (i *Identifier) Type() string    { return "Identifier" }
+func (i *Identifier) Span() Span      { return i.Loc }
 
-// StringLiteral representa una cadena de texto
+// StringLiteral representa una cadena de texto. Value contiene el valor
+// con las secuencias de escape decodificadas y Raw el texto original.
 type StringLiteral struct {
 	Token lexer.Token
 	Value string
+	Raw   string
+	Loc   Span
 }
 
 func (sl *StringLiteral) expressionNode() {}
-func (sl *StringLiteral) String() string  { return fmt.Sprintf("\"%s\"", sl.Value) }
+func (sl *StringLiteral) String() string  { return lexer.Quote(sl.Value) }
 func (sl *StringLiteral) Type() string    { return "StringLiteral" }
+func (sl *StringLiteral) Span() Span      { return sl.Loc }
 
 // IntegerLiteral representa un número entero
 type IntegerLiteral struct {
 	Token lexer.Token
 	Value int64
+	Loc   Span
 }
 
 func (il *IntegerLiteral) expressionNode() {}
 func (il *IntegerLiteral) String() string  { return fmt.Sprintf("%d", il.Value) }
 func (il *IntegerLiteral) Type() string    { return "IntegerLiteral" }
+func (il *IntegerLiteral) Span() Span      { return il.Loc }
 
 // FloatLiteral representa un número flotante
 type FloatLiteral struct {
 	Token lexer.Token
 	Value float64
+	Loc   Span
 }
 
 func (fl *FloatLiteral) expressionNode() {}
-func (fl *FloatLiteral) String() string  { return fmt.Sprintf("%f", fl.Value) }
+func (fl *FloatLiteral) String() string  { return strconv.FormatFloat(fl.Value, 'f', -1, 64) }
 func (fl *FloatLiteral) Type() string    { return "FloatLiteral" }
+func (fl *FloatLiteral) Span() Span      { return fl.Loc }
 
 // KeywordExpression representa palabras clave de Redis como EX, PX, NX, etc.
 type KeywordExpression struct {
 	Token lexer.Token
 	Value string
+	Loc   Span
 }
 
 func (ke *KeywordExpression) expressionNode() {}
 func (ke *KeywordExpression) String() string  { return ke.Value }
 func (ke *KeywordExpression) Type() string    { return "KeywordExpression" }
+func (ke *KeywordExpression) Span() Span      { return ke.Loc }
 
 // PatternExpression representa patrones con wildcards
 type PatternExpression struct {
 	Token lexer.Token
 	Value string
+	Loc   Span
 }
 
 func (pe *PatternExpression) expressionNode() {}
 func (pe *PatternExpression) String() string  { return pe.Value }
 func (pe *PatternExpression) Type() string    { return "PatternExpression" }
+func (pe *PatternExpression) Span() Span      { return pe.Loc }
 
 // RangeExpression representa rangos como [0, -1]
 type RangeExpression struct {
 	Start Expression
 	End   Expression
+	Loc   Span
 }
 
 func (re *RangeExpression) expressionNode() {}
@@ -113,11 +134,13 @@ func (re *RangeExpression) String() string {
 	return fmt.Sprintf("[%s, %s]", re.Start.String(), re.End.String())
 }
 func (re *RangeExpression) Type() string { return "RangeExpression" }
+func (re *RangeExpression) Span() Span   { return re.Loc }
 
 // OptionExpression representa opciones de comandos con sus valores
 type OptionExpression struct {
 	Option Expression
 	Value  Expression // puede ser nil si la opción no tiene valor
+	Loc    Span
 }
 
 func (oe *OptionExpression) expressionNode() {}
@@ -128,10 +151,48 @@ func (oe *OptionExpression) String() string {
 	return oe.Option.String()
 }
 func (oe *OptionExpression) Type() string { return "OptionExpression" }
+func (oe *OptionExpression) Span() Span   { return oe.Loc }
+
+// TransactionBlock agrupa los comandos encolados entre MULTI y EXEC/DISCARD.
+// End es nil si el bloque no se cerró antes del final del programa.
+type TransactionBlock struct {
+	Multi    *RedisCommand
+	Commands []*RedisCommand
+	End      *RedisCommand
+	Loc      Span
+}
+
+func (tb *TransactionBlock) statementNode() {}
+func (tb *TransactionBlock) String() string {
+	lines := make([]string, 0, len(tb.Commands)+2)
+	for _, cmd := range tb.AllCommands() {
+		lines = append(lines, cmd.String())
+	}
+	return strings.Join(lines, "\n")
+}
+func (tb *TransactionBlock) Type() string { return "TransactionBlock" }
+func (tb *TransactionBlock) Span() Span   { return tb.Loc }
+
+// AllCommands devuelve los comandos del bloque en orden, incluyendo MULTI y
+// el comando de cierre si existe
+func (tb *TransactionBlock) AllCommands() []*RedisCommand {
+	commands := []*RedisCommand{tb.Multi}
+	commands = append(commands, tb.Commands...)
+	if tb.End != nil {
+		commands = append(commands, tb.End)
+	}
+	return commands
+}
+
+// IsDiscarded indica si el bloque termina con DISCARD
+func (tb *TransactionBlock) IsDiscarded() bool {
+	return tb.End != nil && strings.EqualFold(tb.End.Command.Value, "DISCARD")
+}
 
 // Program representa el programa completo (puede contener múltiples comandos)
 type Program struct {
 	Statements []Statement
+	Comments   []*Comment // todos los comentarios del script, en orden
 }
 
 func (p *Program) String() string {
@@ -146,3 +207,24 @@ func (p *Program) String() string {
 }
 func (p *Program) Type() string { return "Program" }
 
+// Commands devuelve todos los comandos del programa en orden de ejecución,
+// aplanando los bloques de transacción
+func (p *Program) Commands() []*RedisCommand {
+	commands := []*RedisCommand{}
+	for _, stmt := range p.Statements {
+		switch s := stmt.(type) {
+		case *RedisCommand:
+			commands = append(commands, s)
+		case *TransactionBlock:
+			commands = append(commands, s.AllCommands()...)
+		}
+	}
+	return commands
+}
+func (p *Program) Span() Span {
+	if len(p.Statements) == 0 {
+		return Span{}
+	}
+	return JoinSpans(p.Statements[0].Span(), p.Statements[len(p.Statements)-1].Span())
+}
+
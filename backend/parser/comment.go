@@ -0,0 +1,65 @@
+package parser
+
+import (
+	"redis-analyzer-api/lexer"
+)
+
+// Comment es un comentario # de un script. Un comentario empieza con un #
+// al principio de una palabra, fuera de comillas, y llega hasta el final
+// de la línea.
+type Comment struct {
+	Text     string `json:"text"`     // texto tras el #
+	Trailing bool   `json:"trailing"` // el comentario sigue a un comando en la misma línea
+	Loc      Span   `json:"span"`
+}
+
+// readComment guarda un token COMMENT del lexer. El comentario es final si
+// el token anterior no es un salto de línea ni el inicio del script.
+func (p *Parser) readComment(tok lexer.Token) {
+	previous := p.curToken
+	p.comments = append(p.comments, &Comment{
+		Text:     tok.Literal,
+		Trailing: previous.Line != 0 && previous.Type != lexer.NEWLINE,
+		Loc:      TokenSpan(tok),
+	})
+}
+
+// Comments devuelve los comentarios leídos hasta ahora, en orden
+func (p *Parser) Comments() []*Comment {
+	return p.comments
+}
+
+// attachComments asigna a cada comando el comentario final de su última
+// línea y los comentarios en líneas propias que lo preceden sin líneas en
+// blanco entre medias
+func attachComments(commands []*RedisCommand, comments []*Comment) {
+	next := 0
+	for _, cmd := range commands {
+		start, end := cmd.Loc.Start, cmd.Loc.End
+
+		// Comentarios anteriores al comando: el bloque pegado a su primera
+		// línea es el comentario inicial
+		var leading []*Comment
+		for ; next < len(comments) && comments[next].Loc.Start.Offset < start.Offset; next++ {
+			c := comments[next]
+			line := c.Loc.Start.Line
+			switch {
+			case c.Trailing:
+				leading = nil
+			case len(leading) > 0 && line != leading[len(leading)-1].Loc.Start.Line+1:
+				leading = []*Comment{c}
+			default:
+				leading = append(leading, c)
+			}
+		}
+		if len(leading) > 0 && leading[len(leading)-1].Loc.Start.Line == start.Line-1 {
+			cmd.Leading = leading
+		}
+
+		// Comentario final en la última línea del comando
+		if next < len(comments) && comments[next].Trailing && comments[next].Loc.Start.Line == end.Line {
+			cmd.Trailing = comments[next]
+			next++
+		}
+	}
+}
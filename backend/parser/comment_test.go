@@ -0,0 +1,131 @@
+package parser
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestComments(t *testing.T) {
+	input := "# setup\nSET k \"a # b\" # trailing\nGET a#b 'c\\' # d'\n"
+	program, diagnostics := ParseCommandsWithDiagnostics(input)
+	if len(diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics: %v", diagnostics)
+	}
+
+	commands := program.Commands()
+	if len(commands) != 2 || commands[0].Arguments[1].(*StringLiteral).Value != "a # b" ||
+		commands[1].Arguments[0].String() != "a#b" || commands[1].Arguments[1].(*StringLiteral).Value != "c' # d" {
+		t.Fatalf("unexpected commands: %v", program)
+	}
+
+	expected := []Comment{
+		{Text: " setup", Trailing: false, Loc: Span{Start: Pos{Offset: 0, Line: 1, Column: 1}, End: Pos{Offset: 7, Line: 1, Column: 8}}},
+		{Text: " trailing", Trailing: true, Loc: Span{Start: Pos{Offset: 22, Line: 2, Column: 15}, End: Pos{Offset: 32, Line: 2, Column: 25}}},
+	}
+	if len(program.Comments) != len(expected) {
+		t.Fatalf("expected %d comments, got %d", len(expected), len(program.Comments))
+	}
+	for i, c := range program.Comments {
+		if *c != expected[i] {
+			t.Errorf("comment %d: expected %+v, got %+v", i, expected[i], *c)
+		}
+	}
+
+	if len(commands[0].Leading) != 1 || commands[0].Leading[0] != program.Comments[0] || commands[0].Trailing != program.Comments[1] {
+		t.Errorf("expected SET to carry both comments, got %+v and %+v", commands[0].Leading, commands[0].Trailing)
+	}
+	if len(commands[1].Leading) != 0 || commands[1].Trailing != nil {
+		t.Errorf("expected GET without comments, got %+v and %+v", commands[1].Leading, commands[1].Trailing)
+	}
+}
+
+func TestAttachComments(t *testing.T) {
+	input := "# a\n\n# b\n# c\nMULTI # d\n  # e\n  INCR x\nEXEC\n# f\n\nHSET h \\\n  f v # g\n# h\n"
+	program, diagnostics := ParseCommandsWithDiagnostics(input)
+	if len(diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics: %v", diagnostics)
+	}
+
+	tests := []struct {
+		leading  []string
+		trailing string
+	}{
+		{leading: []string{" b", " c"}, trailing: " d"}, // MULTI
+		{leading: []string{" e"}},                       // INCR
+		{},                                              // EXEC
+		{trailing: " g"},                                // HSET
+	}
+
+	commands := program.Commands()
+	if len(commands) != len(tests) {
+		t.Fatalf("expected %d commands, got %d", len(tests), len(commands))
+	}
+	for i, tt := range tests {
+		cmd := commands[i]
+		leading := []string{}
+		for _, c := range cmd.Leading {
+			leading = append(leading, c.Text)
+		}
+		trailing := ""
+		if cmd.Trailing != nil {
+			trailing = cmd.Trailing.Text
+		}
+		if len(leading) != len(tt.leading) || trailing != tt.trailing {
+			t.Errorf("%s: expected %q and %q, got %q and %q", cmd.Command.Value, tt.leading, tt.trailing, leading, trailing)
+			continue
+		}
+		for j := range leading {
+			if leading[j] != tt.leading[j] {
+				t.Errorf("%s: expected %q, got %q", cmd.Command.Value, tt.leading, leading)
+			}
+		}
+	}
+	if len(program.Comments) != 8 {
+		t.Errorf("expected all comments in Program.Comments, got %d", len(program.Comments))
+	}
+}
+
+func TestParseCommandHash(t *testing.T) {
+	// Un comando suelto no tiene comentarios: # es un carácter más
+	tests := map[string][]string{
+		"SET a=b #x":                  {"a=b", "#x"},
+		"GET #":                       {"#"},
+		"SORT mylist BY nosort GET #": {"mylist", "BY", "nosort", "GET", "#"},
+		"HSET h tag #blue":            {"h", "tag", "#blue"},
+	}
+	for input, expected := range tests {
+		cmd, errors := ParseCommand(input)
+		if len(errors) != 0 || cmd == nil {
+			t.Fatalf("%s: unexpected errors %v", input, errors)
+		}
+		var args []string
+		for _, arg := range cmd.Arguments {
+			args = append(args, arg.String())
+		}
+		if !reflect.DeepEqual(args, expected) {
+			t.Errorf("%s: expected arguments %v, got %v", input, expected, args)
+		}
+		if cmd.Trailing != nil || cmd.Span().End.Offset != len(input) {
+			t.Errorf("%s: expected no comment, got %+v", input, cmd.Trailing)
+		}
+	}
+}
+
+func TestCommentsJSON(t *testing.T) {
+	program, _ := ParseCommandsWithDiagnostics("# a\nGET k # b\n")
+	data, err := json.Marshal(program)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	decoded, err := DecodeProgram(data)
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+
+	cmd := decoded.Commands()[0]
+	if len(decoded.Comments) != 2 || len(cmd.Leading) != 1 || cmd.Leading[0].Text != " a" ||
+		cmd.Trailing == nil || *cmd.Trailing != *program.Comments[1] {
+		t.Errorf("expected comments to survive the round trip, got %s", data)
+	}
+}
@@ -0,0 +1,188 @@
+package parser
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"redis-analyzer-api/lexer"
+)
+
+// DefaultFormatWidth es el ancho a partir del cual se parten en líneas de
+// continuación los comandos con listas de argumentos repetidos
+const DefaultFormatWidth = 80
+
+// FormatOptions añade a Format lo que depende de la gramática de cada
+// comando; semantic.Analyzer.FormatOptions las construye a partir de su
+// tabla de comandos
+type FormatOptions struct {
+	// Keywords indica qué argumentos del comando son subcomandos o tokens de
+	// la gramática, que se escriben en mayúsculas. nil no cambia ninguno.
+	Keywords func(cmd *RedisCommand) []bool
+
+	// Wrap devuelve dónde empieza la lista repetida del final del comando y
+	// cuántos argumentos tiene cada elemento (p.ej. field value en HSET).
+	// Si el comando no cabe en Width, cada elemento va en su propia línea.
+	Wrap func(cmd *RedisCommand) (start, size int, ok bool)
+
+	Width int // 0 usa DefaultFormatWidth
+}
+
+// Format devuelve el programa en forma canónica: un comando por línea con
+// el nombre en mayúsculas, los argumentos sin comillas salvo cuando hacen
+// falta, y los comentarios y líneas en blanco (como mucho una seguida) del
+// script original. El resultado se parsea al mismo programa.
+func Format(program *Program) string {
+	return FormatWithOptions(program, FormatOptions{})
+}
+
+// formatItem es un comando o un comentario en una línea propia
+type formatItem struct {
+	start, end int // primera y última línea en el script original
+	offset     int
+	text       string
+}
+
+// FormatWithOptions es Format con las opciones que dependen de la gramática
+func FormatWithOptions(program *Program, options FormatOptions) string {
+	if options.Width <= 0 {
+		options.Width = DefaultFormatWidth
+	}
+
+	items := []*formatItem{}
+	attached := map[*Comment]bool{}
+	for _, cmd := range program.Commands() {
+		item := &formatItem{
+			start:  cmd.Span().Start.Line,
+			end:    cmd.Span().End.Line,
+			offset: cmd.Span().Start.Offset,
+			text:   formatCommand(cmd, options),
+		}
+		if cmd.Trailing != nil {
+			item.text += " #" + cmd.Trailing.Text
+			attached[cmd.Trailing] = true
+		}
+		items = append(items, item)
+	}
+	// El resto de comentarios, incluidos los iniciales de cada comando, van
+	// en su propia línea
+	for _, c := range program.Comments {
+		if attached[c] {
+			continue
+		}
+		line := c.Loc.Start.Line
+		items = append(items, &formatItem{start: line, end: line, offset: c.Loc.Start.Offset, text: "#" + c.Text})
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].offset < items[j].offset })
+
+	var b strings.Builder
+	for i, item := range items {
+		if i > 0 && item.start > items[i-1].end+1 {
+			b.WriteString("\n")
+		}
+		b.WriteString(strings.TrimRight(item.text, " \t\r") + "\n")
+	}
+	return b.String()
+}
+
+// formatCommand escribe un comando en una línea o, si es demasiado largo y
+// termina en una lista repetida, con un elemento de la lista por línea
+func formatCommand(cmd *RedisCommand, options FormatOptions) string {
+	var keywords []bool
+	if options.Keywords != nil {
+		keywords = options.Keywords(cmd)
+	}
+
+	words := []string{strings.ToUpper(cmd.Command.Value)}
+	for i, arg := range cmd.Arguments {
+		words = append(words, FormatArgument(arg, i < len(keywords) && keywords[i]))
+	}
+
+	line := strings.Join(words, " ")
+	if utf8.RuneCountInString(line) <= options.Width || options.Wrap == nil {
+		return line
+	}
+	start, size, ok := options.Wrap(cmd)
+	if !ok || size <= 0 || len(cmd.Arguments)-start <= size {
+		return line
+	}
+
+	// words[0] es el nombre del comando: el argumento i es words[i+1]
+	lines := []string{strings.Join(words[:start+1], " ")}
+	for i := start; i < len(cmd.Arguments); i += size {
+		lines = append(lines, "  "+strings.Join(words[i+1:min(i+size, len(cmd.Arguments))+1], " "))
+	}
+	return strings.Join(lines, " \\\n")
+}
+
+// FormatArgument escribe un argumento en forma canónica: los números y los
+// patrones como en el original y las cadenas sin comillas si el lexer las
+// lee igual, o entre comillas dobles con sus secuencias de escape. Las
+// palabras clave se escriben en mayúsculas.
+func FormatArgument(arg Expression, keyword bool) string {
+	upper := func(s string) string {
+		if keyword {
+			return strings.ToUpper(s)
+		}
+		return s
+	}
+
+	switch a := arg.(type) {
+	case *StringLiteral:
+		return FormatValue(upper(a.Value))
+	case *IntegerLiteral:
+		if a.Token.Literal != "" {
+			return a.Token.Literal
+		}
+	case *FloatLiteral:
+		if a.Token.Literal != "" {
+			return a.Token.Literal
+		}
+	case *RangeExpression:
+		return "[" + FormatArgument(a.Start, false) + "," + FormatArgument(a.End, false) + "]"
+	case *OptionExpression:
+		if a.Value != nil {
+			return FormatArgument(a.Option, true) + " " + FormatArgument(a.Value, false)
+		}
+		return FormatArgument(a.Option, true)
+	}
+	return upper(arg.String())
+}
+
+// FormatValue escribe un valor sin comillas si el lexer lo lee como un único
+// argumento con el mismo valor y, si no, con lexer.Quote
+func FormatValue(value string) string {
+	if canBeBare(value) {
+		return value
+	}
+	return lexer.Quote(value)
+}
+
+// canBeBare indica si un valor puede escribirse sin comillas
+func canBeBare(value string) bool {
+	// Comillas, comentarios y barras en cualquier posición: el lexer trata
+	// distinto una comilla o un # pegados a una palabra (a"b, it's, a#b)
+	if value == "" || strings.ContainsAny(value, `"'#\`) {
+		return false
+	}
+	for _, r := range value {
+		if r == utf8.RuneError || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return false
+		}
+	}
+
+	cmd, diagnostics := ParseCommandWithDiagnostics("X " + value)
+	if len(diagnostics) > 0 || cmd == nil || len(cmd.Arguments) != 1 {
+		return false
+	}
+	switch a := cmd.Arguments[0].(type) {
+	case *Identifier, *KeywordExpression, *PatternExpression:
+		return a.String() == value
+	case *IntegerLiteral:
+		return a.Token.Literal == value
+	case *FloatLiteral:
+		return a.Token.Literal == value
+	}
+	return false
+}
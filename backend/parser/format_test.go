@@ -0,0 +1,157 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"redis-analyzer-api/lexer"
+)
+
+func TestFormat(t *testing.T) {
+	input := strings.Join([]string{
+		"",
+		"# Datos iniciales",
+		"set user:1 \"Juan\"   ex 60 # una hora",
+		"",
+		"",
+		"hset h 'a b' \"\" \"#x\" \"tab\\there\"",
+		"multi",
+		"  incr \"007\"",
+		"exec",
+		"ZADD z 1.50 \"1.50\" -5 \"-\"",
+		"ping",
+		"",
+	}, "\n")
+
+	expected := strings.Join([]string{
+		"# Datos iniciales",
+		"SET user:1 Juan ex 60 # una hora",
+		"",
+		"HSET h \"a b\" \"\" \"#x\" \"tab\\there\"",
+		"MULTI",
+		"INCR 007",
+		"EXEC",
+		"ZADD z 1.50 1.50 -5 -",
+		"PING",
+		"",
+	}, "\n")
+
+	program, diagnostics := ParseCommandsWithDiagnostics(input)
+	if len(diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics: %v", diagnostics)
+	}
+	formatted := Format(program)
+	if formatted != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, formatted)
+	}
+
+	// El resultado es estable y conserva los valores de los argumentos
+	again, _ := ParseCommandsWithDiagnostics(formatted)
+	if Format(again) != formatted {
+		t.Errorf("expected formatting to be idempotent, got:\n%s", Format(again))
+	}
+	if got, want := commandValues(again), commandValues(program); got != want {
+		t.Errorf("expected the same commands, got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// commandValues devuelve el nombre y los valores de los argumentos de cada
+// comando tal como se envían a Redis, sin comillas ni secuencias de escape
+func commandValues(program *Program) string {
+	lines := []string{}
+	for _, cmd := range program.Commands() {
+		words := []string{strings.ToUpper(cmd.Command.Value)}
+		for _, arg := range cmd.Arguments {
+			switch a := arg.(type) {
+			case *StringLiteral:
+				words = append(words, a.Value)
+			case *IntegerLiteral:
+				words = append(words, a.Token.Literal)
+			case *FloatLiteral:
+				words = append(words, a.Token.Literal)
+			default:
+				words = append(words, arg.String())
+			}
+		}
+		lines = append(lines, strings.Join(words, "|"))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestFormatWithOptions(t *testing.T) {
+	program, _ := ParseCommandsWithDiagnostics("hset h f1 v1 f2 v2 f3 v3\nget k")
+	options := FormatOptions{
+		Keywords: func(cmd *RedisCommand) []bool {
+			return []bool{false, true}
+		},
+		Wrap: func(cmd *RedisCommand) (int, int, bool) {
+			return 1, 2, strings.EqualFold(cmd.Command.Value, "HSET")
+		},
+		Width: 10,
+	}
+
+	expected := "HSET h \\\n  F1 v1 \\\n  f2 v2 \\\n  f3 v3\nGET k\n"
+	formatted := FormatWithOptions(program, options)
+	if formatted != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, formatted)
+	}
+
+	// Las líneas de continuación se parsean como un único comando
+	again, diagnostics := ParseCommandsWithDiagnostics(formatted)
+	if len(diagnostics) != 0 || len(again.Statements) != 2 || len(again.Commands()[0].Arguments) != 7 {
+		t.Errorf("expected the wrapped command to parse back, got %v (%v)", again, diagnostics)
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	tests := map[string]string{
+		"hello":        "hello",
+		"user:{1}:*":   "user:{1}:*",
+		"café":         "café",
+		"10":           "10",
+		"010":          "010",
+		"a b":          `"a b"`,
+		"":             `""`,
+		"'x":           `"'x"`,
+		"#x":           `"#x"`,
+		"a#b":          `"a#b"`,
+		"it's":         `"it's"`,
+		`a"b`:          `"a\"b"`,
+		`a\b`:          `"a\\b"`,
+		`\`:            `"\\"`,
+		"[1,5]":        `"[1,5]"`,
+		"say \"hi\"\n": `"say \"hi\"\n"`,
+		"\x00\xff":     `"\x00\xff"`,
+	}
+	for value, expected := range tests {
+		if got := FormatValue(value); got != expected {
+			t.Errorf("FormatValue(%q): expected %s, got %s", value, expected, got)
+		}
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	values := []string{`a"bA`, "it's", `a'b"c`, "a#b", `a\b`, "a b", "a\tb", `x"`, "x'", "#", "[1,2]", "user:{1}:*", "-"}
+	for _, value := range values {
+		program, diagnostics := ParseCommandsWithDiagnostics("SET k " + lexer.Quote(value))
+		if len(diagnostics) != 0 {
+			t.Fatalf("%q: unexpected diagnostics: %v", value, diagnostics)
+		}
+		formatted := Format(program)
+		again, diagnostics := ParseCommandsWithDiagnostics(formatted)
+		if len(diagnostics) != 0 {
+			t.Errorf("%q: formatted as %q, which does not parse: %v", value, formatted, diagnostics)
+			continue
+		}
+		if got, want := commandValues(again), "SET|k|"+value; got != want {
+			t.Errorf("%q: formatted as %q, which parses as %q", value, formatted, got)
+		}
+	}
+}
+
+func TestRedisCommandString(t *testing.T) {
+	cmd, _ := ParseCommand("PING")
+	if cmd.String() != "PING" {
+		t.Errorf("expected no trailing space, got %q", cmd.String())
+	}
+}
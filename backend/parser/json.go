@@ -0,0 +1,385 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"redis-analyzer-api/lexer"
+)
+
+// jsonNode es la representación JSON estable de cualquier nodo del AST.
+// Los literales usan "value" y "token"; los nodos compuestos usan los
+// campos de sus hijos. En OptionExpression "value" contiene un nodo.
+type jsonNode struct {
+	Type       string          `json:"type"`
+	Span       Span            `json:"span"`
+	Value      json.RawMessage `json:"value,omitempty"`
+	Token      *string         `json:"token,omitempty"`
+	Command    *jsonNode       `json:"command,omitempty"`
+	Arguments  []*jsonNode     `json:"arguments,omitempty"`
+	Start      *jsonNode       `json:"start,omitempty"`
+	End        *jsonNode       `json:"end,omitempty"`
+	Option     *jsonNode       `json:"option,omitempty"`
+	Statements []*jsonNode     `json:"statements,omitempty"`
+	Multi      *jsonNode       `json:"multi,omitempty"`
+	Commands   []*jsonNode     `json:"commands,omitempty"`
+	Close      *jsonNode       `json:"close,omitempty"`
+	Comments   []*Comment      `json:"comments,omitempty"`
+	Leading    []*Comment      `json:"leading,omitempty"`
+	Trailing   *Comment        `json:"trailing,omitempty"`
+}
+
+// MarshalNode codifica un nodo del AST como JSON
+func MarshalNode(node Node) ([]byte, error) {
+	n, err := toJSONNode(node)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(n)
+}
+
+// DecodeNode decodifica un nodo del AST generado por MarshalNode
+func DecodeNode(data []byte) (Node, error) {
+	var n jsonNode
+	if err := json.Unmarshal(data, &n); err != nil {
+		return nil, fmt.Errorf("invalid AST JSON: %w", err)
+	}
+	return fromJSONNode(&n)
+}
+
+// DecodeProgram decodifica un Program codificado como JSON
+func DecodeProgram(data []byte) (*Program, error) {
+	program := &Program{}
+	if err := json.Unmarshal(data, program); err != nil {
+		return nil, err
+	}
+	return program, nil
+}
+
+// DecodeCommand decodifica un RedisCommand codificado como JSON
+func DecodeCommand(data []byte) (*RedisCommand, error) {
+	cmd := &RedisCommand{}
+	if err := json.Unmarshal(data, cmd); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+func (p *Program) MarshalJSON() ([]byte, error)            { return MarshalNode(p) }
+func (rc *RedisCommand) MarshalJSON() ([]byte, error)      { return MarshalNode(rc) }
+func (tb *TransactionBlock) MarshalJSON() ([]byte, error)  { return MarshalNode(tb) }
+func (i *Identifier) MarshalJSON() ([]byte, error)         { return MarshalNode(i) }
+func (sl *StringLiteral) MarshalJSON() ([]byte, error)     { return MarshalNode(sl) }
+func (il *IntegerLiteral) MarshalJSON() ([]byte, error)    { return MarshalNode(il) }
+func (fl *FloatLiteral) MarshalJSON() ([]byte, error)      { return MarshalNode(fl) }
+func (ke *KeywordExpression) MarshalJSON() ([]byte, error) { return MarshalNode(ke) }
+func (pe *PatternExpression) MarshalJSON() ([]byte, error) { return MarshalNode(pe) }
+func (re *RangeExpression) MarshalJSON() ([]byte, error)   { return MarshalNode(re) }
+func (oe *OptionExpression) MarshalJSON() ([]byte, error)  { return MarshalNode(oe) }
+
+// UnmarshalJSON decodifica un Program generado por MarshalJSON
+func (p *Program) UnmarshalJSON(data []byte) error {
+	node, err := DecodeNode(data)
+	if err != nil {
+		return err
+	}
+	program, ok := node.(*Program)
+	if !ok {
+		return fmt.Errorf("expected Program node, got %s", node.Type())
+	}
+	*p = *program
+	return nil
+}
+
+// UnmarshalJSON decodifica un RedisCommand generado por MarshalJSON
+func (rc *RedisCommand) UnmarshalJSON(data []byte) error {
+	node, err := DecodeNode(data)
+	if err != nil {
+		return err
+	}
+	cmd, ok := node.(*RedisCommand)
+	if !ok {
+		return fmt.Errorf("expected RedisCommand node, got %s", node.Type())
+	}
+	*rc = *cmd
+	return nil
+}
+
+// toJSONNode convierte un nodo del AST en su representación JSON
+func toJSONNode(node Node) (*jsonNode, error) {
+	n := &jsonNode{Type: node.Type(), Span: node.Span()}
+
+	var err error
+	switch v := node.(type) {
+	case *Program:
+		n.Statements = []*jsonNode{}
+		for _, stmt := range v.Statements {
+			child, err := toJSONNode(stmt)
+			if err != nil {
+				return nil, err
+			}
+			n.Statements = append(n.Statements, child)
+		}
+		n.Comments = v.Comments
+	case *RedisCommand:
+		n.Leading, n.Trailing = v.Leading, v.Trailing
+		if n.Command, err = toJSONNode(v.Command); err != nil {
+			return nil, err
+		}
+		n.Arguments = []*jsonNode{}
+		for _, arg := range v.Arguments {
+			child, err := toJSONNode(arg)
+			if err != nil {
+				return nil, err
+			}
+			n.Arguments = append(n.Arguments, child)
+		}
+	case *TransactionBlock:
+		if n.Multi, err = toJSONNode(v.Multi); err != nil {
+			return nil, err
+		}
+		n.Commands = []*jsonNode{}
+		for _, cmd := range v.Commands {
+			child, err := toJSONNode(cmd)
+			if err != nil {
+				return nil, err
+			}
+			n.Commands = append(n.Commands, child)
+		}
+		if v.End != nil {
+			n.Close, err = toJSONNode(v.End)
+		}
+	case *Identifier:
+		err = n.setLiteral(v.Value, v.Token.Literal)
+	case *StringLiteral:
+		err = n.setLiteral(v.Value, v.Raw)
+	case *IntegerLiteral:
+		err = n.setLiteral(v.Value, v.Token.Literal)
+	case *FloatLiteral:
+		err = n.setLiteral(v.Value, v.Token.Literal)
+	case *KeywordExpression:
+		err = n.setLiteral(v.Value, v.Token.Literal)
+	case *PatternExpression:
+		// Un patrón agrupa varios tokens; su texto original es el valor completo
+		err = n.setLiteral(v.Value, v.Value)
+	case *RangeExpression:
+		if n.Start, err = toJSONNode(v.Start); err != nil {
+			return nil, err
+		}
+		n.End, err = toJSONNode(v.End)
+	case *OptionExpression:
+		if n.Option, err = toJSONNode(v.Option); err != nil {
+			return nil, err
+		}
+		if v.Value != nil {
+			var value *jsonNode
+			if value, err = toJSONNode(v.Value); err == nil {
+				n.Value, err = json.Marshal(value)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("cannot encode node of type %s", node.Type())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// setLiteral guarda el valor y el texto original de un literal
+func (n *jsonNode) setLiteral(value interface{}, token string) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	n.Value = data
+	n.Token = &token
+	return nil
+}
+
+// fromJSONNode reconstruye un nodo del AST a partir de su representación JSON
+func fromJSONNode(n *jsonNode) (Node, error) {
+	if n == nil {
+		return nil, fmt.Errorf("missing AST node")
+	}
+
+	switch n.Type {
+	case "Program":
+		program := &Program{Statements: []Statement{}}
+		for _, child := range n.Statements {
+			node, err := fromJSONNode(child)
+			if err != nil {
+				return nil, err
+			}
+			stmt, ok := node.(Statement)
+			if !ok {
+				return nil, fmt.Errorf("%s is not a statement", node.Type())
+			}
+			program.Statements = append(program.Statements, stmt)
+		}
+		program.Comments = n.Comments
+		return program, nil
+	case "RedisCommand":
+		node, err := fromJSONNode(n.Command)
+		if err != nil {
+			return nil, err
+		}
+		command, ok := node.(*Identifier)
+		if !ok {
+			return nil, fmt.Errorf("command must be an Identifier, got %s", node.Type())
+		}
+		cmd := &RedisCommand{Command: command, Arguments: []Expression{}, Loc: n.Span, Leading: n.Leading, Trailing: n.Trailing}
+		for _, child := range n.Arguments {
+			arg, err := expressionFromJSON(child)
+			if err != nil {
+				return nil, err
+			}
+			cmd.Arguments = append(cmd.Arguments, arg)
+		}
+		return cmd, nil
+	case "TransactionBlock":
+		multi, err := commandFromJSON(n.Multi)
+		if err != nil {
+			return nil, err
+		}
+		block := &TransactionBlock{Multi: multi, Commands: []*RedisCommand{}, Loc: n.Span}
+		for _, child := range n.Commands {
+			cmd, err := commandFromJSON(child)
+			if err != nil {
+				return nil, err
+			}
+			block.Commands = append(block.Commands, cmd)
+		}
+		if n.Close != nil {
+			if block.End, err = commandFromJSON(n.Close); err != nil {
+				return nil, err
+			}
+		}
+		return block, nil
+	}
+
+	return expressionFromJSON(n)
+}
+
+// commandFromJSON reconstruye un RedisCommand anidado en otro nodo
+func commandFromJSON(n *jsonNode) (*RedisCommand, error) {
+	node, err := fromJSONNode(n)
+	if err != nil {
+		return nil, err
+	}
+	cmd, ok := node.(*RedisCommand)
+	if !ok {
+		return nil, fmt.Errorf("expected RedisCommand node, got %s", node.Type())
+	}
+	return cmd, nil
+}
+
+// expressionFromJSON reconstruye una expresión del AST
+func expressionFromJSON(n *jsonNode) (Expression, error) {
+	if n == nil {
+		return nil, fmt.Errorf("missing AST node")
+	}
+
+	switch n.Type {
+	case "Identifier":
+		var value string
+		if err := n.decodeValue(&value); err != nil {
+			return nil, err
+		}
+		return &Identifier{Token: n.token(lexer.IDENT, value), Value: value, Loc: n.Span}, nil
+	case "StringLiteral":
+		var value string
+		if err := n.decodeValue(&value); err != nil {
+			return nil, err
+		}
+		lit := &StringLiteral{Token: n.token(lexer.STRING, lexer.Quote(value)), Value: value, Loc: n.Span}
+		// "token" guarda el texto original; el literal del token es el valor
+		lit.Raw, lit.Token.Literal = lit.Token.Raw, value
+		return lit, nil
+	case "IntegerLiteral":
+		var value int64
+		if err := n.decodeValue(&value); err != nil {
+			return nil, err
+		}
+		return &IntegerLiteral{Token: n.token(lexer.INT, fmt.Sprint(value)), Value: value, Loc: n.Span}, nil
+	case "FloatLiteral":
+		var value float64
+		if err := n.decodeValue(&value); err != nil {
+			return nil, err
+		}
+		lit := &FloatLiteral{Value: value, Loc: n.Span}
+		lit.Token = n.token(lexer.FLOAT, lit.String())
+		return lit, nil
+	case "KeywordExpression":
+		var value string
+		if err := n.decodeValue(&value); err != nil {
+			return nil, err
+		}
+		return &KeywordExpression{Token: n.token(lexer.LookupIdent(strings.ToUpper(value)), value), Value: value, Loc: n.Span}, nil
+	case "PatternExpression":
+		var value string
+		if err := n.decodeValue(&value); err != nil {
+			return nil, err
+		}
+		return &PatternExpression{Token: n.token(lexer.IDENT, value), Value: value, Loc: n.Span}, nil
+	case "RangeExpression":
+		start, err := expressionFromJSON(n.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := expressionFromJSON(n.End)
+		if err != nil {
+			return nil, err
+		}
+		return &RangeExpression{Start: start, End: end, Loc: n.Span}, nil
+	case "OptionExpression":
+		option, err := expressionFromJSON(n.Option)
+		if err != nil {
+			return nil, err
+		}
+		expr := &OptionExpression{Option: option, Loc: n.Span}
+		if len(n.Value) > 0 && !bytes.Equal(n.Value, []byte("null")) {
+			var value jsonNode
+			if err := json.Unmarshal(n.Value, &value); err != nil {
+				return nil, fmt.Errorf("invalid option value: %w", err)
+			}
+			if expr.Value, err = expressionFromJSON(&value); err != nil {
+				return nil, err
+			}
+		}
+		return expr, nil
+	}
+
+	return nil, fmt.Errorf("unknown AST node type %q", n.Type)
+}
+
+// decodeValue decodifica el valor de un literal
+func (n *jsonNode) decodeValue(target interface{}) error {
+	if len(n.Value) == 0 {
+		return fmt.Errorf("%s node without value", n.Type)
+	}
+	if err := json.Unmarshal(n.Value, target); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", n.Type, err)
+	}
+	return nil
+}
+
+// token reconstruye el token original a partir del texto y el rango guardados
+func (n *jsonNode) token(tokenType lexer.TokenType, fallback string) lexer.Token {
+	literal := fallback
+	if n.Token != nil {
+		literal = *n.Token
+	}
+	return lexer.Token{
+		Type:      tokenType,
+		Literal:   literal,
+		Raw:       literal,
+		Position:  n.Span.Start.Offset,
+		Line:      n.Span.Start.Line,
+		Column:    n.Span.Start.Column,
+		End:       n.Span.End.Offset,
+		EndLine:   n.Span.End.Line,
+		EndColumn: n.Span.End.Column,
+	}
+}
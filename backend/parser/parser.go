@@ -3,6 +3,7 @@ package parser
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"redis-analyzer-api/lexer"
 )
 
@@ -13,7 +14,10 @@ type Parser struct {
 	curToken  lexer.Token
 	peekToken lexer.Token
 	
-	errors []string
+	errors      []string
+	diagnostics []Diagnostic
+	lexerErrors int // errores del lexer ya trasladados al parser
+	comments    []*Comment
 }
 
 // New crea un nuevo parser
@@ -34,6 +38,27 @@ func New(l *lexer.Lexer) *Parser {
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
 	p.peekToken = p.lexer.NextToken()
+	
+	// Los comentarios no forman parte de los comandos: se guardan aparte
+	for p.peekToken.Type == lexer.COMMENT {
+		p.readComment(p.peekToken)
+		p.peekToken = p.lexer.NextToken()
+	}
+	
+	// Trasladar los errores léxicos producidos al leer el nuevo token
+	for _, err := range p.lexer.Errors()[p.lexerErrors:] {
+		p.errors = append(p.errors, err.Error())
+		p.diagnostics = append(p.diagnostics, Diagnostic{
+			Span: Span{
+				Start: Pos{Offset: err.Position, Line: err.Line, Column: err.Column},
+				End:   Pos{Offset: err.End, Line: err.EndLine, Column: err.EndColumn},
+			},
+			Severity: SeverityError,
+			Code:     err.Code,
+			Message:  err.Message,
+		})
+	}
+	p.lexerErrors = len(p.lexer.Errors())
 }
 
 // Errors devuelve los errores de parsing
@@ -41,10 +66,21 @@ func (p *Parser) Errors() []string {
 	return p.errors
 }
 
-// addError añade un error al parser
-func (p *Parser) addError(msg string) {
+// Diagnostics devuelve los errores de parsing como diagnósticos estructurados
+func (p *Parser) Diagnostics() []Diagnostic {
+	return p.diagnostics
+}
+
+// addError añade un error al parser anclado al token actual
+func (p *Parser) addError(code, msg string) {
 	p.errors = append(p.errors, fmt.Sprintf("Parser error at line %d, column %d: %s", 
 		p.curToken.Line, p.curToken.Column, msg))
+	p.diagnostics = append(p.diagnostics, Diagnostic{
+		Span:     TokenSpan(p.curToken),
+		Severity: SeverityError,
+		Code:     code,
+		Message:  msg,
+	})
 }
 
 // ParseProgram parsea el programa completo
@@ -60,6 +96,8 @@ func (p *Parser) ParseProgram() *Program {
 		p.nextToken()
 	}
 	
+	program.Comments = p.comments
+	attachComments(program.Commands(), p.comments)
 	return program
 }
 
@@ -75,14 +113,54 @@ func (p *Parser) parseStatement() Statement {
 		return nil
 	}
 	
-	// En Redis, todas las declaraciones son comandos
-	return p.parseRedisCommand()
+	// En Redis, todas las declaraciones son comandos; MULTI abre un bloque
+	// de transacción que agrupa los comandos hasta EXEC o DISCARD
+	cmd := p.parseRedisCommand()
+	if cmd == nil {
+		return nil
+	}
+	if strings.EqualFold(cmd.Command.Value, "MULTI") {
+		return p.parseTransactionBlock(cmd)
+	}
+	return cmd
 }
 
-// parseRedisCommand parsea un comando Redis
+// parseTransactionBlock parsea los comandos que siguen a MULTI hasta EXEC o
+// DISCARD. Un MULTI anidado se conserva dentro del bloque para que el
+// analizador semántico pueda reportarlo.
+func (p *Parser) parseTransactionBlock(multi *RedisCommand) *TransactionBlock {
+	block := &TransactionBlock{Multi: multi, Commands: []*RedisCommand{}, Loc: multi.Loc}
+	
+	for {
+		p.nextToken()
+		for p.curToken.Type == lexer.NEWLINE {
+			p.nextToken()
+		}
+		if p.curToken.Type == lexer.EOF {
+			return block
+		}
+		
+		cmd := p.parseRedisCommand()
+		if cmd == nil {
+			continue
+		}
+		block.Loc = JoinSpans(block.Loc, cmd.Loc)
+		
+		name := strings.ToUpper(cmd.Command.Value)
+		if name == "EXEC" || name == "DISCARD" {
+			block.End = cmd
+			return block
+		}
+		block.Commands = append(block.Commands, cmd)
+	}
+}
+
+// parseRedisCommand parsea un comando Redis. Las palabras clave del lexer
+// también pueden ser nombres de comando (TYPE k, y COUNT o MATCH en tablas
+// de comandos propias).
 func (p *Parser) parseRedisCommand() *RedisCommand {
-	if p.curToken.Type != lexer.IDENT {
-		p.addError(fmt.Sprintf("expected command identifier, got %s", p.curToken.Type))
+	if p.curToken.Type != lexer.IDENT && !p.curToken.Type.IsKeyword() {
+		p.addError("EXPECTED_COMMAND", fmt.Sprintf("expected command identifier, got %s", p.curToken.Type))
 		return nil
 	}
 	
@@ -90,7 +168,9 @@ func (p *Parser) parseRedisCommand() *RedisCommand {
 	cmd.Command = &Identifier{
 		Token: p.curToken,
 		Value: p.curToken.Literal,
+		Loc:   TokenSpan(p.curToken),
 	}
+	cmd.Loc = cmd.Command.Loc
 	
 	// Parsear argumentos
 	cmd.Arguments = []Expression{}
@@ -100,6 +180,7 @@ func (p *Parser) parseRedisCommand() *RedisCommand {
 		arg := p.parseExpression()
 		if arg != nil {
 			cmd.Arguments = append(cmd.Arguments, arg)
+			cmd.Loc = JoinSpans(cmd.Loc, arg.Span())
 		}
 	}
 	
@@ -108,28 +189,50 @@ func (p *Parser) parseRedisCommand() *RedisCommand {
 
 // parseExpression parsea una expresión
 func (p *Parser) parseExpression() Expression {
+	// Tokens pegados a un símbolo de patrón forman un patrón (ej: user:*, a*,
+	// {user:1}:profile)
+	if p.curToken.Type != lexer.STRING && p.peekAdjacent() && isPatternToken(p.peekToken.Type) {
+		return p.parsePatternExpression()
+	}
+	
 	switch p.curToken.Type {
 	case lexer.IDENT:
-		// Verificar si es parte de un patrón (ej: user:*)
-		if p.peekToken.Type == lexer.COLON {
-			return p.parsePatternExpression()
-		}
 		return p.parseIdentifier()
 	case lexer.STRING:
 		return p.parseStringLiteral()
 	case lexer.INT:
-		return p.parseIntegerLiteral()
+		if lit := p.parseIntegerLiteral(); lit != nil {
+			return lit
+		}
+		return nil
 	case lexer.FLOAT:
-		return p.parseFloatLiteral()
+		if lit := p.parseFloatLiteral(); lit != nil {
+			return lit
+		}
+		return nil
 	case lexer.EX, lexer.PX, lexer.NX, lexer.XX, lexer.WITHSCORES, 
 		 lexer.LIMIT, lexer.COUNT, lexer.MATCH, lexer.TYPE:
 		return p.parseKeywordExpression()
 	case lexer.ASTERISK, lexer.QUESTION:
 		return p.parsePatternExpression()
+	case lexer.BRACE_L:
+		// Una llave pegada al resto del argumento abre un hash tag ({user}x)
+		if p.peekAdjacent() {
+			return p.parsePatternExpression()
+		}
+		return p.parseIdentifier()
 	case lexer.BRACKET_L:
-		return p.parseRangeExpression()
+		// Evitar devolver un *RangeExpression nil envuelto en la interfaz
+		if expr := p.parseRangeExpression(); expr != nil {
+			return expr
+		}
+		return nil
+	case lexer.COLON, lexer.BRACKET_R, lexer.PAREN_L, lexer.PAREN_R,
+		 lexer.BRACE_R, lexer.COMMA, lexer.PIPE, lexer.PLUS, lexer.MINUS:
+		// Un símbolo suelto es un argumento más (ej: XRANGE s - +)
+		return p.parseIdentifier()
 	default:
-		p.addError(fmt.Sprintf("unexpected token: %s", p.curToken.Type))
+		p.addError("UNEXPECTED_TOKEN", fmt.Sprintf("unexpected token: %s", p.curToken.Type))
 		return nil
 	}
 }
@@ -139,6 +242,7 @@ func (p *Parser) parseIdentifier() *Identifier {
 	return &Identifier{
 		Token: p.curToken,
 		Value: p.curToken.Literal,
+		Loc:   TokenSpan(p.curToken),
 	}
 }
 
@@ -147,16 +251,18 @@ func (p *Parser) parseStringLiteral() *StringLiteral {
 	return &StringLiteral{
 		Token: p.curToken,
 		Value: p.curToken.Literal,
+		Raw:   p.curToken.Raw,
+		Loc:   TokenSpan(p.curToken),
 	}
 }
 
 // parseIntegerLiteral parsea un entero literal
 func (p *Parser) parseIntegerLiteral() *IntegerLiteral {
-	lit := &IntegerLiteral{Token: p.curToken}
+	lit := &IntegerLiteral{Token: p.curToken, Loc: TokenSpan(p.curToken)}
 	
-	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
+	value, err := strconv.ParseInt(p.curToken.Literal, 10, 64)
 	if err != nil {
-		p.addError(fmt.Sprintf("could not parse %q as integer", p.curToken.Literal))
+		p.addError("INVALID_INTEGER", fmt.Sprintf("could not parse %q as integer", p.curToken.Literal))
 		return nil
 	}
 	
@@ -166,11 +272,11 @@ func (p *Parser) parseIntegerLiteral() *IntegerLiteral {
 
 // parseFloatLiteral parsea un flotante literal
 func (p *Parser) parseFloatLiteral() *FloatLiteral {
-	lit := &FloatLiteral{Token: p.curToken}
+	lit := &FloatLiteral{Token: p.curToken, Loc: TokenSpan(p.curToken)}
 	
 	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
 	if err != nil {
-		p.addError(fmt.Sprintf("could not parse %q as float", p.curToken.Literal))
+		p.addError("INVALID_FLOAT", fmt.Sprintf("could not parse %q as float", p.curToken.Literal))
 		return nil
 	}
 	
@@ -183,16 +289,17 @@ func (p *Parser) parseKeywordExpression() *KeywordExpression {
 	return &KeywordExpression{
 		Token: p.curToken,
 		Value: p.curToken.Literal,
+		Loc:   TokenSpan(p.curToken),
 	}
 }
 
 // parsePatternExpression parsea patrones con wildcards
 func (p *Parser) parsePatternExpression() *PatternExpression {
 	pattern := p.curToken.Literal
+	start := TokenSpan(p.curToken)
 	
-	// Si hay más símbolos de patrón consecutivos, combinarlos
-	for p.peekToken.Type == lexer.ASTERISK || p.peekToken.Type == lexer.QUESTION ||
-		p.peekToken.Type == lexer.IDENT || p.peekToken.Type == lexer.COLON {
+	// Combinar los tokens pegados al actual, sin espacios entre ellos
+	for p.peekAdjacent() && p.peekToken.Type != lexer.STRING {
 		p.nextToken()
 		pattern += p.curToken.Literal
 	}
@@ -200,25 +307,41 @@ func (p *Parser) parsePatternExpression() *PatternExpression {
 	return &PatternExpression{
 		Token: p.curToken,
 		Value: pattern,
+		Loc:   JoinSpans(start, TokenSpan(p.curToken)),
 	}
 }
 
+// peekAdjacent indica si el siguiente token empieza justo donde termina el
+// actual, es decir, si ambos forman parte del mismo argumento
+func (p *Parser) peekAdjacent() bool {
+	return p.peekToken.Type != lexer.EOF && p.peekToken.Type != lexer.NEWLINE &&
+		p.peekToken.Position == p.curToken.End
+}
+
+// isPatternToken verifica si el token es un símbolo de patrón o una llave
+// de hash tag
+func isPatternToken(t lexer.TokenType) bool {
+	return t == lexer.COLON || t == lexer.ASTERISK || t == lexer.QUESTION ||
+		t == lexer.BRACE_L || t == lexer.BRACE_R
+}
+
 // parseRangeExpression parsea expresiones de rango [start, end]
 func (p *Parser) parseRangeExpression() *RangeExpression {
 	if p.curToken.Type != lexer.BRACKET_L {
-		p.addError("expected '['")
+		p.addError("INVALID_RANGE", "expected '['")
 		return nil
 	}
 	
+	start := TokenSpan(p.curToken)
 	p.nextToken() // consumir '['
 	
-	start := p.parseExpression()
-	if start == nil {
+	startExpr := p.parseExpression()
+	if startExpr == nil {
 		return nil
 	}
 	
 	if p.peekToken.Type != lexer.COMMA {
-		p.addError("expected ',' in range expression")
+		p.addError("INVALID_RANGE", "expected ',' in range expression")
 		return nil
 	}
 	p.nextToken() // consumir ','
@@ -230,26 +353,52 @@ func (p *Parser) parseRangeExpression() *RangeExpression {
 	}
 	
 	if p.peekToken.Type != lexer.BRACKET_R {
-		p.addError("expected ']'")
+		p.addError("INVALID_RANGE", "expected ']'")
 		return nil
 	}
 	p.nextToken() // consumir ']'
 	
 	return &RangeExpression{
-		Start: start,
+		Start: startExpr,
 		End:   end,
+		Loc:   JoinSpans(start, TokenSpan(p.curToken)),
 	}
 }
 
 // ParseCommand parsea un solo comando Redis desde una cadena
 func ParseCommand(input string) (*RedisCommand, []string) {
-	l := lexer.New(input)
+	l := lexer.NewCommand(input)
 	p := New(l)
 	
 	cmd := p.parseRedisCommand()
 	return cmd, p.Errors()
 }
 
+// ParseCommand parsea un único comando desde la posición actual
+func (p *Parser) ParseCommand() *RedisCommand {
+	return p.parseRedisCommand()
+}
+
+// ParseCommandWithDiagnostics parsea un comando y devuelve los errores
+// como diagnósticos estructurados
+func ParseCommandWithDiagnostics(input string) (*RedisCommand, []Diagnostic) {
+	l := lexer.NewCommand(input)
+	p := New(l)
+	
+	cmd := p.parseRedisCommand()
+	return cmd, p.Diagnostics()
+}
+
+// ParseCommandsWithDiagnostics parsea múltiples comandos y devuelve los
+// errores como diagnósticos estructurados
+func ParseCommandsWithDiagnostics(input string) (*Program, []Diagnostic) {
+	l := lexer.New(input)
+	p := New(l)
+	
+	program := p.ParseProgram()
+	return program, p.Diagnostics()
+}
+
 // ParseCommands parsea múltiples comandos Redis
 func ParseCommands(input string) (*Program, []string) {
 	l := lexer.New(input)
@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"encoding/json"
+	"fmt"
 	"strings"
 	"testing"
 )
@@ -36,6 +38,35 @@ func TestParseSimpleCommand(t *testing.T) {
 	}
 }
 
+func TestParseKeywordCommand(t *testing.T) {
+	tests := []struct {
+		input     string
+		command   string
+		arguments int
+	}{
+		{"TYPE k", "TYPE", 1},
+		{"type k", "type", 1},
+		{"COUNT a b", "COUNT", 2},
+		{"MATCH", "MATCH", 0},
+	}
+	
+	for _, tt := range tests {
+		program, diagnostics := ParseCommandsWithDiagnostics(tt.input)
+		if len(diagnostics) != 0 {
+			t.Errorf("%q: unexpected diagnostics: %v", tt.input, diagnostics)
+			continue
+		}
+		commands := program.Commands()
+		if len(commands) != 1 {
+			t.Errorf("%q: expected 1 command, got %d", tt.input, len(commands))
+			continue
+		}
+		if commands[0].Command.Value != tt.command || len(commands[0].Arguments) != tt.arguments {
+			t.Errorf("%q: expected %s with %d arguments, got %s", tt.input, tt.command, tt.arguments, commands[0])
+		}
+	}
+}
+
 func TestParseSetCommandWithExpiration(t *testing.T) {
 	input := `SET mykey "hello world" EX 60`
 	
@@ -299,3 +330,338 @@ func TestParseErrors(t *testing.T) {
 	}
 }
 
+
+func TestNodeSpans(t *testing.T) {
+	input := `SET key "hello" EX 60
+SCAN 0 MATCH user:* COUNT 10`
+	
+	program, errors := ParseCommands(input)
+	if len(errors) != 0 {
+		t.Fatalf("parser had %d errors: %v", len(errors), errors)
+	}
+	
+	tests := []struct {
+		node     Node
+		expected Span
+	}{
+		{program.Statements[0], Span{Pos{0, 1, 1}, Pos{21, 1, 22}}},
+		{program.Statements[0].(*RedisCommand).Command, Span{Pos{0, 1, 1}, Pos{3, 1, 4}}},
+		{program.Statements[0].(*RedisCommand).Arguments[1], Span{Pos{8, 1, 9}, Pos{15, 1, 16}}},
+		{program.Statements[0].(*RedisCommand).Arguments[3], Span{Pos{19, 1, 20}, Pos{21, 1, 22}}},
+		{program.Statements[1], Span{Pos{22, 2, 1}, Pos{50, 2, 29}}},
+		{program.Statements[1].(*RedisCommand).Arguments[2], Span{Pos{35, 2, 14}, Pos{41, 2, 20}}},
+	}
+	
+	for i, tt := range tests {
+		if tt.node.Span() != tt.expected {
+			t.Errorf("node[%d] %q wrong span. expected=%+v, got=%+v", i, tt.node.String(), tt.expected, tt.node.Span())
+		}
+	}
+}
+
+func TestParseDiagnostics(t *testing.T) {
+	_, diagnostics := ParseCommandsWithDiagnostics("GET key\n123 key")
+	
+	if len(diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %d: %v", len(diagnostics), diagnostics)
+	}
+	
+	diag := diagnostics[0]
+	if diag.Code != "EXPECTED_COMMAND" || diag.Severity != SeverityError {
+		t.Errorf("wrong diagnostic. got %s %s", diag.Severity, diag.Code)
+	}
+	expected := Span{Pos{8, 2, 1}, Pos{11, 2, 4}}
+	if diag.Span != expected {
+		t.Errorf("wrong span. expected=%+v, got=%+v", expected, diag.Span)
+	}
+}
+
+func TestFloatLiteralString(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"INCRBYFLOAT counter 1.5", "1.5"},
+		{"INCRBYFLOAT counter -0.25", "-0.25"},
+		{"INCRBYFLOAT counter 3.0", "3"},
+	}
+	
+	for _, tt := range tests {
+		cmd, errors := ParseCommand(tt.input)
+		if len(errors) != 0 {
+			t.Fatalf("parser had %d errors: %v", len(errors), errors)
+		}
+		if got := cmd.Arguments[1].String(); got != tt.expected {
+			t.Errorf("wrong float string for %q. expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	input := `SET key "hello world" EX 60
+ZADD scores 1.5 alice
+SCAN 0 MATCH user:* COUNT 10
+ZRANGE scores [1,5]`
+	
+	program, errors := ParseCommands(input)
+	if len(errors) != 0 {
+		t.Fatalf("parser had %d errors: %v", len(errors), errors)
+	}
+	
+	data, err := json.Marshal(program)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	
+	decoded, err := DecodeProgram(data)
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	
+	if decoded.String() != program.String() {
+		t.Errorf("round trip changed the program. expected=%q, got=%q", program.String(), decoded.String())
+	}
+	
+	again, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatalf("marshal of decoded program failed: %v", err)
+	}
+	if string(again) != string(data) {
+		t.Errorf("round trip is not stable.\nfirst=%s\nsecond=%s", data, again)
+	}
+	
+	for i, stmt := range program.Statements {
+		if decoded.Statements[i].Span() != stmt.Span() {
+			t.Errorf("statement %d wrong span. expected=%+v, got=%+v", i, stmt.Span(), decoded.Statements[i].Span())
+		}
+	}
+	
+	zadd := decoded.Statements[1].(*RedisCommand)
+	score, ok := zadd.Arguments[1].(*FloatLiteral)
+	if !ok || score.Value != 1.5 || score.Token.Literal != "1.5" {
+		t.Errorf("expected FloatLiteral 1.5, got %#v", zadd.Arguments[1])
+	}
+}
+
+func TestJSONEncoding(t *testing.T) {
+	cmd, errors := ParseCommand("INCRBY counter 5")
+	if len(errors) != 0 {
+		t.Fatalf("parser had %d errors: %v", len(errors), errors)
+	}
+	
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	
+	var tree map[string]interface{}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if tree["type"] != "RedisCommand" {
+		t.Errorf("expected type RedisCommand, got %v", tree["type"])
+	}
+	
+	arg := tree["arguments"].([]interface{})[1].(map[string]interface{})
+	if arg["type"] != "IntegerLiteral" || arg["value"] != float64(5) || arg["token"] != "5" {
+		t.Errorf("unexpected argument encoding: %v", arg)
+	}
+	
+	for _, invalid := range []string{`{"type":"Bogus"}`, `{"type":"IntegerLiteral","value":"x"}`, `{"type":"RedisCommand"}`} {
+		if _, err := DecodeNode([]byte(invalid)); err == nil {
+			t.Errorf("expected error decoding %s", invalid)
+		}
+	}
+}
+
+func TestParseBracketArguments(t *testing.T) {
+	// Como en redis-cli, los corchetes sin formar un rango son argumentos sueltos
+	cmd, errors := ParseCommand("ZRANGEBYLEX k [a [b")
+	if len(errors) != 0 {
+		t.Fatalf("parser had %d errors: %v", len(errors), errors)
+	}
+	if len(cmd.Arguments) != 3 || cmd.Arguments[1].String() != "[a" || cmd.Arguments[2].String() != "[b" {
+		t.Errorf("expected bare bracket arguments, got %s", cmd.String())
+	}
+}
+
+func TestParseTransactionBlock(t *testing.T) {
+	input := `WATCH balance
+MULTI
+DECRBY balance 10
+INCRBY savings 10
+EXEC
+GET balance`
+	
+	program, errors := ParseCommands(input)
+	if len(errors) != 0 {
+		t.Fatalf("parser had %d errors: %v", len(errors), errors)
+	}
+	
+	if len(program.Statements) != 3 {
+		t.Fatalf("expected 3 statements, got %d", len(program.Statements))
+	}
+	
+	block, ok := program.Statements[1].(*TransactionBlock)
+	if !ok {
+		t.Fatalf("expected TransactionBlock, got %s", program.Statements[1].Type())
+	}
+	if block.Multi.Command.Value != "MULTI" || block.End == nil || block.End.Command.Value != "EXEC" {
+		t.Errorf("wrong block delimiters: %q", block.String())
+	}
+	if len(block.Commands) != 2 || block.Commands[1].Command.Value != "INCRBY" {
+		t.Errorf("expected 2 queued commands, got %d", len(block.Commands))
+	}
+	if block.IsDiscarded() {
+		t.Errorf("block should not be discarded")
+	}
+	expected := Span{Pos{14, 2, 1}, Pos{60, 5, 5}}
+	if block.Span() != expected {
+		t.Errorf("wrong block span. expected=%+v, got=%+v", expected, block.Span())
+	}
+	if len(program.Commands()) != 6 {
+		t.Errorf("expected 6 flattened commands, got %d", len(program.Commands()))
+	}
+	
+	tests := []struct {
+		input      string
+		queued     int
+		terminated bool
+		discarded  bool
+	}{
+		{"MULTI\nSET a 1\nDISCARD", 1, true, true},
+		{"multi\nSET a 1\nexec", 1, true, false},
+		{"MULTI\nSET a 1", 1, false, false},
+		{"MULTI\nMULTI\nEXEC", 1, true, false},
+		{"MULTI\n\nEXEC", 0, true, false},
+	}
+	
+	for _, tt := range tests {
+		program, errors := ParseCommands(tt.input)
+		if len(errors) != 0 {
+			t.Fatalf("parser had %d errors: %v", len(errors), errors)
+		}
+		block, ok := program.Statements[0].(*TransactionBlock)
+		if !ok || len(program.Statements) != 1 {
+			t.Fatalf("%q: expected a single TransactionBlock, got %d statements", tt.input, len(program.Statements))
+		}
+		if len(block.Commands) != tt.queued || (block.End != nil) != tt.terminated || block.IsDiscarded() != tt.discarded {
+			t.Errorf("%q: wrong block. queued=%d terminated=%v discarded=%v", tt.input, len(block.Commands), block.End != nil, block.IsDiscarded())
+		}
+	}
+	
+	data, err := json.Marshal(program)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	decoded, err := DecodeProgram(data)
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if decoded.String() != program.String() {
+		t.Errorf("round trip changed the program. expected=%q, got=%q", program.String(), decoded.String())
+	}
+}
+
+func TestParseStringEscapes(t *testing.T) {
+	cmd, errors := ParseCommand(`SET key "say \"hi\"\n" 'it\'s'`)
+	if len(errors) != 0 {
+		t.Fatalf("parser had %d errors: %v", len(errors), errors)
+	}
+	
+	tests := []struct {
+		value string
+		raw   string
+	}{
+		{"say \"hi\"\n", `"say \"hi\"\n"`},
+		{"it's", `'it\'s'`},
+	}
+	
+	for i, tt := range tests {
+		lit, ok := cmd.Arguments[i+1].(*StringLiteral)
+		if !ok {
+			t.Fatalf("argument %d is not a StringLiteral: %T", i+1, cmd.Arguments[i+1])
+		}
+		if lit.Value != tt.value || lit.Raw != tt.raw {
+			t.Errorf("argument %d: expected value=%q raw=%q, got value=%q raw=%q", i+1, tt.value, tt.raw, lit.Value, lit.Raw)
+		}
+	}
+	
+	if cmd.String() != `SET key "say \"hi\"\n" "it's"` {
+		t.Errorf("wrong command string: %s", cmd.String())
+	}
+	
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	decoded, err := DecodeCommand(data)
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if lit := decoded.Arguments[1].(*StringLiteral); lit.Value != tests[0].value || lit.Raw != tests[0].raw {
+		t.Errorf("round trip lost the string: value=%q raw=%q", lit.Value, lit.Raw)
+	}
+}
+
+func TestParseUnterminatedString(t *testing.T) {
+	_, diagnostics := ParseCommandsWithDiagnostics("GET key\nSET key \"abc")
+	
+	if len(diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %d: %v", len(diagnostics), diagnostics)
+	}
+	
+	diag := diagnostics[0]
+	if diag.Code != "UNTERMINATED_STRING" || diag.Severity != SeverityError {
+		t.Errorf("wrong diagnostic. got %s %s", diag.Severity, diag.Code)
+	}
+	expected := Span{Pos{16, 2, 9}, Pos{20, 2, 13}}
+	if diag.Span != expected {
+		t.Errorf("wrong span. expected=%+v, got=%+v", expected, diag.Span)
+	}
+}
+
+func TestParseBareArguments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string // tipo(valor) de cada argumento
+	}{
+		{"XADD s * f v", []string{"Identifier(s)", "PatternExpression(*)", "Identifier(f)", "Identifier(v)"}},
+		{"KEYS a*", []string{"PatternExpression(a*)"}},
+		{"SCAN 0 MATCH user:*:x", []string{"IntegerLiteral(0)", "KeywordExpression(MATCH)", "PatternExpression(user:*:x)"}},
+		{"XRANGE s - +", []string{"Identifier(s)", "Identifier(-)", "Identifier(+)"}},
+		{"ZRANGEBYSCORE z -inf (5", []string{"Identifier(z)", "Identifier(-inf)", "Identifier((5)"}},
+		{"GET user.profile", []string{"Identifier(user.profile)"}},
+		{"FT.SEARCH idx hello", []string{"Identifier(idx)", "Identifier(hello)"}},
+		{"GET {user:1}:profile", []string{"PatternExpression({user:1}:profile)"}},
+		{"KEYS {user}:*", []string{"PatternExpression({user}:*)"}},
+		{"MGET {a}b x{a}", []string{"PatternExpression({a}b)", "PatternExpression(x{a})"}},
+		{"SET { }", []string{"Identifier({)", "Identifier(})"}},
+		{"SET zip 09", []string{"Identifier(zip)", "IntegerLiteral(9)"}},
+		{"SET k 08", []string{"Identifier(k)", "IntegerLiteral(8)"}},
+		{"SET id 99999999999999999999", []string{"Identifier(id)", "Identifier(99999999999999999999)"}},
+		{"SET id -99999999999999999999", []string{"Identifier(id)", "Identifier(-99999999999999999999)"}},
+		{"GET user:99999999999999999999", []string{"Identifier(user:99999999999999999999)"}},
+	}
+	
+	for _, tt := range tests {
+		cmd, errors := ParseCommand(tt.input)
+		if len(errors) != 0 {
+			t.Fatalf("%q: parser had %d errors: %v", tt.input, len(errors), errors)
+		}
+		
+		got := []string{}
+		for _, arg := range cmd.Arguments {
+			got = append(got, fmt.Sprintf("%s(%s)", arg.Type(), arg.String()))
+		}
+		if strings.Join(got, " ") != strings.Join(tt.expected, " ") {
+			t.Errorf("%q: expected %v, got %v", tt.input, tt.expected, got)
+		}
+	}
+	
+	// Los errores del lexer llegan al parser como diagnósticos
+	_, diagnostics := ParseCommandsWithDiagnostics("GET k\xff")
+	if len(diagnostics) != 1 || diagnostics[0].Code != "INVALID_UTF8" {
+		t.Errorf("expected INVALID_UTF8 diagnostic, got %v", diagnostics)
+	}
+}
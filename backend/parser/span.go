@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"fmt"
+	"redis-analyzer-api/lexer"
+)
+
+// Pos representa una posición en el texto fuente
+type Pos struct {
+	Offset int `json:"offset"` // desplazamiento en bytes (0-based)
+	Line   int `json:"line"`   // línea (1-based)
+	Column int `json:"column"` // columna (1-based)
+}
+
+// Span representa el rango [Start, End) que ocupa un nodo en el texto fuente
+type Span struct {
+	Start Pos `json:"start"`
+	End   Pos `json:"end"`
+}
+
+// TokenSpan devuelve el rango ocupado por un token
+func TokenSpan(tok lexer.Token) Span {
+	return Span{
+		Start: Pos{Offset: tok.Position, Line: tok.Line, Column: tok.Column},
+		End:   Pos{Offset: tok.End, Line: tok.EndLine, Column: tok.EndColumn},
+	}
+}
+
+// JoinSpans devuelve el rango que va del inicio de from al final de to
+func JoinSpans(from, to Span) Span {
+	return Span{Start: from.Start, End: to.End}
+}
+
+// IsZero indica si el rango no tiene posición asignada
+func (s Span) IsZero() bool {
+	return s.Start.Line == 0
+}
+
+// String devuelve el rango como "línea:columna-línea:columna"
+func (s Span) String() string {
+	return fmt.Sprintf("%d:%d-%d:%d", s.Start.Line, s.Start.Column, s.End.Line, s.End.Column)
+}
+
+// Severity indica la gravedad de un diagnóstico
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Diagnostic es un error o aviso anclado a un rango del texto fuente
+type Diagnostic struct {
+	Span     Span     `json:"span"`
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code"`
+	Message  string   `json:"message"`
+}
+
+// String devuelve el diagnóstico como "línea:columna: severidad código mensaje"
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s %s %s", d.Span.Start.Line, d.Span.Start.Column, d.Severity, d.Code, d.Message)
+}
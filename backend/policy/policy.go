@@ -0,0 +1,328 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"redis-analyzer-api/parser"
+	"redis-analyzer-api/semantic"
+)
+
+// Effect indica si una regla permite o deniega los comandos que coinciden
+type Effect string
+
+const (
+	Allow Effect = "allow"
+	Deny  Effect = "deny"
+)
+
+// Policy es una lista ordenada de reglas que decide qué comandos validados
+// pueden ejecutarse. Se aplica la primera regla que coincida con el comando;
+// si ninguna coincide se aplica Default.
+type Policy struct {
+	Default Effect `json:"default" yaml:"default"` // vacío equivale a allow
+	Rules   []Rule `json:"rules" yaml:"rules"`
+}
+
+// Rule selecciona comandos por nombre, categoría y claves. Un selector vacío
+// coincide con cualquier comando; si hay varios deben coincidir todos.
+type Rule struct {
+	Name       string              `json:"name" yaml:"name"`
+	Effect     Effect              `json:"effect" yaml:"effect"`
+	Commands   []string            `json:"commands,omitempty" yaml:"commands"`     // "DEL", "CONFIG SET"; un contenedor incluye sus subcomandos
+	Categories []semantic.Category `json:"categories,omitempty" yaml:"categories"` // read, write o admin
+	// Keys son patrones glob de Redis. En una regla allow todas las claves
+	// del comando deben coincidir; en una regla deny basta con una. Los
+	// comandos sin claves no coinciden.
+	Keys []string `json:"keys,omitempty" yaml:"keys"`
+	// Args limita los argumentos de los comandos que coinciden con la regla:
+	// si se incumple alguno el comando se deniega con esta regla.
+	Args    []ArgConstraint `json:"args,omitempty" yaml:"args"`
+	Message string          `json:"message,omitempty" yaml:"message"` // motivo mostrado al denegar
+}
+
+// ArgConstraint limita el valor numérico de un argumento, identificado por
+// la opción que lo precede (COUNT en SCAN 0 COUNT 100) o por su posición
+type ArgConstraint struct {
+	Option string   `json:"option,omitempty" yaml:"option"`
+	Index  *int     `json:"index,omitempty" yaml:"index"` // posición tras el nombre del comando (0-based)
+	Min    *float64 `json:"min,omitempty" yaml:"min"`
+	Max    *float64 `json:"max,omitempty" yaml:"max"`
+}
+
+// Denial describe por qué la política rechazó un comando
+type Denial struct {
+	Rule    string // nombre de la regla, o "default"
+	Command string
+	Reason  string
+}
+
+func (d *Denial) Error() string {
+	return fmt.Sprintf("command %s denied by policy rule %q: %s", d.Command, d.Rule, d.Reason)
+}
+
+// SemanticError convierte la denegación en un error semántico de tipo
+// POLICY anclado al comando
+func (d *Denial) SemanticError(cmd *parser.RedisCommand) semantic.SemanticError {
+	return semantic.SemanticError{
+		Message: fmt.Sprintf("Denied by policy rule '%s': %s", d.Rule, d.Reason),
+		Command: d.Command,
+		Type:    "POLICY",
+		Span:    cmd.Span(),
+		Rule:    d.Rule,
+	}
+}
+
+// LoadFile lee una política en JSON o YAML según la extensión del archivo
+func LoadFile(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	var policy Policy
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &policy)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &policy)
+	default:
+		return nil, fmt.Errorf("unsupported policy file %s (expected .json, .yaml or .yml)", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %w", path, err)
+	}
+
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// Validate comprueba que las reglas estén bien formadas
+func (p *Policy) Validate() error {
+	switch p.Default {
+	case "", Allow, Deny:
+	default:
+		return fmt.Errorf("invalid default effect %q (expected allow or deny)", p.Default)
+	}
+
+	names := map[string]bool{}
+	for i, rule := range p.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("policy rule %d has no name", i+1)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("duplicate policy rule %q", rule.Name)
+		}
+		names[rule.Name] = true
+
+		if rule.Effect != Allow && rule.Effect != Deny {
+			return fmt.Errorf("rule %q: invalid effect %q (expected allow or deny)", rule.Name, rule.Effect)
+		}
+		for _, category := range rule.Categories {
+			switch category {
+			case semantic.CategoryRead, semantic.CategoryWrite, semantic.CategoryAdmin:
+			default:
+				return fmt.Errorf("rule %q: unknown category %q (expected read, write or admin)", rule.Name, category)
+			}
+		}
+		for _, pattern := range rule.Keys {
+			if pattern == "" {
+				return fmt.Errorf("rule %q: empty key pattern", rule.Name)
+			}
+		}
+		for _, arg := range rule.Args {
+			if (arg.Option == "") == (arg.Index == nil) {
+				return fmt.Errorf("rule %q: argument constraints need exactly one of option or index", rule.Name)
+			}
+			if arg.Min == nil && arg.Max == nil {
+				return fmt.Errorf("rule %q: argument constraint without min or max", rule.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Commands devuelve los nombres de comando que usan las reglas, en
+// mayúsculas y sin repetir ("DEL", "CONFIG SET")
+func (p *Policy) Commands() []string {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, rule := range p.Rules {
+		for _, name := range rule.Commands {
+			name = strings.ToUpper(strings.Join(strings.Fields(name), " "))
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// Evaluate decide si el comando puede ejecutarse. Devuelve nil si la
+// política lo permite.
+func (p *Policy) Evaluate(analyzer *semantic.Analyzer, cmd *parser.RedisCommand) *Denial {
+	name := strings.ToUpper(cmd.Command.Value)
+	category := analyzer.CommandCategory(cmd)
+	keys := analyzer.Keys(cmd)
+
+	for _, rule := range p.Rules {
+		if !rule.matches(cmd, category, keys) {
+			continue
+		}
+		if reason := rule.violatedArg(analyzer, cmd); reason != "" {
+			return &Denial{Rule: rule.Name, Command: name, Reason: reason}
+		}
+		if rule.Effect == Allow {
+			return nil
+		}
+		reason := rule.Message
+		if reason == "" {
+			reason = fmt.Sprintf("%s commands are not allowed", category)
+		}
+		return &Denial{Rule: rule.Name, Command: name, Reason: reason}
+	}
+
+	if p.Default == Deny {
+		return &Denial{Rule: "default", Command: name, Reason: "no policy rule allows this command"}
+	}
+	return nil
+}
+
+// Check evalúa el comando y, si se deniega, añade un error POLICY al
+// resultado de validación. Los comandos ya inválidos no se evalúan.
+func (p *Policy) Check(analyzer *semantic.Analyzer, cmd *parser.RedisCommand, result *semantic.ValidationResult) {
+	if !result.Valid {
+		return
+	}
+	if denial := p.Evaluate(analyzer, cmd); denial != nil {
+		result.AddError(denial.SemanticError(cmd))
+	}
+}
+
+// matches indica si los selectores de la regla coinciden con el comando
+func (r Rule) matches(cmd *parser.RedisCommand, category semantic.Category, keys []semantic.KeyRef) bool {
+	if len(r.Commands) > 0 && !matchesCommand(r.Commands, cmd) {
+		return false
+	}
+	if len(r.Categories) > 0 && !containsCategory(r.Categories, category) {
+		return false
+	}
+	if len(r.Keys) > 0 {
+		if len(keys) == 0 {
+			return false
+		}
+		matched := 0
+		for _, key := range keys {
+			if matchAny(r.Keys, key.Name) {
+				matched++
+			}
+		}
+		if r.Effect == Allow {
+			return matched == len(keys)
+		}
+		return matched > 0
+	}
+	return true
+}
+
+// violatedArg devuelve la descripción de la primera restricción de
+// argumentos que el comando incumple, o "" si las cumple todas. Los
+// argumentos ausentes no incumplen ninguna restricción. El valor de una
+// opción es el argumento que la gramática enlaza a su token; los comandos
+// sin gramática usan la palabra que sigue a la opción.
+func (r Rule) violatedArg(analyzer *semantic.Analyzer, cmd *parser.RedisCommand) string {
+	args := make([]string, len(cmd.Arguments))
+	for i, arg := range cmd.Arguments {
+		args[i] = argumentText(arg)
+	}
+
+	for _, constraint := range r.Args {
+		label := strings.ToUpper(constraint.Option)
+		var values []string
+		if constraint.Index != nil {
+			label = fmt.Sprintf("argument %d", *constraint.Index)
+			if *constraint.Index < len(args) {
+				values = append(values, args[*constraint.Index])
+			}
+		} else if bound, ok := analyzer.OptionValues(cmd, constraint.Option); ok {
+			for _, arg := range bound {
+				values = append(values, argumentText(arg))
+			}
+		} else {
+			for i := 0; i+1 < len(args); i++ {
+				if strings.EqualFold(args[i], constraint.Option) {
+					values = append(values, args[i+1])
+				}
+			}
+		}
+
+		for _, text := range values {
+			value, err := strconv.ParseFloat(text, 64)
+			switch {
+			case err != nil:
+				return fmt.Sprintf("%s must be a number, got %q", label, text)
+			case constraint.Min != nil && value < *constraint.Min:
+				return fmt.Sprintf("%s must be >= %s, got %s", label, formatNumber(*constraint.Min), text)
+			case constraint.Max != nil && value > *constraint.Max:
+				return fmt.Sprintf("%s must be <= %s, got %s", label, formatNumber(*constraint.Max), text)
+			}
+		}
+	}
+	return ""
+}
+
+// matchesCommand indica si el comando coincide con alguno de los nombres.
+// "CONFIG" coincide con todos los subcomandos de CONFIG y "CONFIG SET"
+// solo con ese subcomando.
+func matchesCommand(names []string, cmd *parser.RedisCommand) bool {
+	name := strings.ToUpper(cmd.Command.Value)
+	full := name
+	if len(cmd.Arguments) > 0 {
+		full = name + " " + strings.ToUpper(argumentText(cmd.Arguments[0]))
+	}
+	for _, candidate := range names {
+		candidate = strings.ToUpper(strings.Join(strings.Fields(candidate), " "))
+		if candidate == name || candidate == full {
+			return true
+		}
+	}
+	return false
+}
+
+func containsCategory(categories []semantic.Category, category semantic.Category) bool {
+	for _, c := range categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAny(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if MatchGlob(pattern, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// argumentText devuelve el texto de un argumento tal como lo recibe Redis
+func argumentText(arg parser.Expression) string {
+	if sl, ok := arg.(*parser.StringLiteral); ok {
+		return sl.Value
+	}
+	return arg.String()
+}
+
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
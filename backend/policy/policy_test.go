@@ -0,0 +1,152 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"redis-analyzer-api/parser"
+	"redis-analyzer-api/semantic"
+)
+
+const testPolicy = `
+default: allow
+rules:
+  - name: no-flush
+    effect: deny
+    commands: [FLUSHDB, FLUSHALL]
+    message: flushing is disabled
+  - name: no-config-set
+    effect: deny
+    commands: ["config set"]
+  - name: sandbox-writes
+    effect: allow
+    categories: [write]
+    keys: ["sandbox:*"]
+  - name: protect-prod
+    effect: deny
+    keys: ["prod:*"]
+  - name: other-writes
+    effect: deny
+    categories: [write]
+  - name: scan-count
+    effect: allow
+    commands: [SCAN]
+    args:
+      - option: COUNT
+        max: 1000
+`
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		match   bool
+	}{
+		{"*", "anything", true},
+		{"sandbox:*", "sandbox:a:b", true},
+		{"sandbox:*", "prod:a", false},
+		{"user:?", "user:1", true},
+		{"user:?", "user:12", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{"a\\*b", "a*b", true},
+		{"a\\*b", "axb", false},
+		{"*:*:end", "a:b:end", true},
+		{"a/*", "a/b/c", true},
+	}
+
+	for _, tt := range tests {
+		if got := MatchGlob(tt.pattern, tt.s); got != tt.match {
+			t.Errorf("MatchGlob(%q, %q) = %v, expected %v", tt.pattern, tt.s, got, tt.match)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(testPolicy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	analyzer := semantic.New()
+
+	tests := []struct {
+		input string
+		rule  string // regla que deniega; vacío si se permite
+	}{
+		{"GET prod:user", "protect-prod"},
+		{"GET user:1", ""},
+		{"FLUSHDB", "no-flush"},
+		{"CONFIG SET maxmemory 1mb", "no-config-set"},
+		{"CONFIG GET maxmemory", ""},
+		{"SET sandbox:a 1", ""},
+		{"MSET sandbox:a 1 sandbox:b 2", ""},
+		{"MSET sandbox:a 1 other:b 2", "other-writes"},
+		{"SET user:1 x", "other-writes"},
+		{"SCAN 0 COUNT 100", ""},
+		{"SCAN 0 MATCH * COUNT 5000", "scan-count"},
+		{"SCAN 0", ""},
+		{"SCAN 0 MATCH COUNT", ""},
+		{"SCAN 0 MATCH COUNT COUNT 5", ""},
+		{"SCAN 0 MATCH COUNT COUNT 5000", "scan-count"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			cmd, errs := parser.ParseCommand(tt.input)
+			if len(errs) > 0 {
+				t.Fatalf("Parse errors: %v", errs)
+			}
+			denial := policy.Evaluate(analyzer, cmd)
+			switch {
+			case tt.rule == "" && denial != nil:
+				t.Errorf("Expected the command to be allowed, got %v", denial)
+			case tt.rule != "" && (denial == nil || denial.Rule != tt.rule):
+				t.Errorf("Expected a denial by %s, got %v", tt.rule, denial)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	policy := &Policy{Default: Deny, Rules: []Rule{{Name: "reads", Effect: Allow, Categories: []semantic.Category{semantic.CategoryRead}}}}
+	analyzer := semantic.New()
+
+	cmd, _ := parser.ParseCommand("DEL a")
+	result := analyzer.ValidateCommand(cmd)
+	policy.Check(analyzer, cmd, &result)
+
+	if result.Valid || len(result.Errors) != 1 {
+		t.Fatalf("Expected one policy error, got %+v", result.Errors)
+	}
+	if err := result.Errors[0]; err.Type != "POLICY" || err.Rule != "default" || err.Span != cmd.Span() {
+		t.Errorf("Unexpected policy error: %+v", err)
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]string{
+		"unknown.txt":   `default: allow`,
+		"effect.yaml":   "rules:\n  - name: a\n    effect: block\n",
+		"unnamed.json":  `{"rules": [{"effect": "deny"}]}`,
+		"category.json": `{"rules": [{"name": "a", "effect": "deny", "categories": ["dangerous"]}]}`,
+		"args.json":     `{"rules": [{"name": "a", "effect": "allow", "args": [{"option": "COUNT"}]}]}`,
+		"default.json":  `{"default": "maybe"}`,
+	}
+
+	for name, content := range tests {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadFile(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
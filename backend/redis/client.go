@@ -5,36 +5,33 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 	
 	"github.com/redis/go-redis/v9"
 	"redis-analyzer-api/parser"
+	"redis-analyzer-api/policy"
 	"redis-analyzer-api/semantic"
 )
 
 // Client representa el cliente Redis con capacidades de análisis
 type Client struct {
-	rdb      *redis.Client
-	analyzer *semantic.Analyzer
-	ctx      context.Context
-}
-
-// Config contiene la configuración para conectar a Redis
-type Config struct {
-	Host     string
-	Port     int
-	Password string
-	DB       int
+	rdb       redis.UniversalClient
+	analyzer  *semantic.Analyzer
+	policy    *policy.Policy // nil permite cualquier comando válido
+	configErr error          // error al cargar la configuración TLS, devuelto por Connect
 }
 
 // ExecutionResult contiene el resultado de ejecutar un comando
 type ExecutionResult struct {
 	Success      bool
 	Result       interface{}
+	Reply        *Reply
 	Error        string
 	ExecutionTime time.Duration
 	Command      string
 	Validation   *semantic.ValidationResult
+	Err          error // error de red o *TimeoutError; nil si Redis respondió
 }
 
 // DatabaseInfo contiene información sobre la base de datos Redis
@@ -45,28 +42,87 @@ type DatabaseInfo struct {
 	Stats        map[string]string
 	KeyCount     int64
 	DatabaseSize int64
+	Masters      int // número de masters consultados (más de uno en cluster)
 }
 
-// NewClient crea un nuevo cliente Redis
+// NewClient crea un nuevo cliente Redis para el despliegue descrito en la
+// configuración. En modo cluster el analizador reporta CROSSSLOT como error
+// y en modo solo lectura rechaza los comandos que no son de lectura.
+// Los errores al cargar los archivos TLS se devuelven en Connect. Las
+// operaciones respetan el plazo del contexto que reciben en lugar de los
+// timeouts de lectura/escritura por defecto de go-redis.
 func NewClient(config Config) *Client {
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%d", config.Host, config.Port),
-		Password: config.Password,
-		DB:       config.DB,
-	})
+	analyzer := semantic.New()
+	tlsConfig, configErr := config.tlsConfig()
+	
+	var rdb redis.UniversalClient
+	switch config.Mode {
+	case ModeSentinel:
+		rdb = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:            config.MasterName,
+			SentinelAddrs:         config.SentinelAddrs,
+			SentinelPassword:      config.SentinelPassword,
+			Username:              config.Username,
+			Password:              config.Password,
+			DB:                    config.DB,
+			TLSConfig:             tlsConfig,
+			ReplicaOnly:           config.ReadOnly,
+			ContextTimeoutEnabled: true,
+		})
+	case ModeCluster:
+		rdb = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:                 config.ClusterAddrs,
+			Username:              config.Username,
+			Password:              config.Password,
+			TLSConfig:             tlsConfig,
+			ReadOnly:              config.ReadOnly,
+			ContextTimeoutEnabled: true,
+		})
+		analyzer.SetClusterMode(true)
+	default:
+		rdb = redis.NewClient(&redis.Options{
+			Addr:                  fmt.Sprintf("%s:%d", config.Host, config.Port),
+			Username:              config.Username,
+			Password:              config.Password,
+			DB:                    config.DB,
+			TLSConfig:             tlsConfig,
+			ContextTimeoutEnabled: true,
+		})
+	}
+	analyzer.SetReadOnly(config.ReadOnly)
 	
 	return &Client{
-		rdb:      rdb,
-		analyzer: semantic.New(),
-		ctx:      context.Background(),
+		rdb:       rdb,
+		analyzer:  analyzer,
+		configErr: configErr,
 	}
 }
 
-// Connect establece la conexión con Redis
-func (c *Client) Connect() error {
-	_, err := c.rdb.Ping(c.ctx).Result()
+// forEachMaster ejecuta fn en cada master: en cluster en todos los masters
+// de forma concurrente, y en otro caso en el único nodo de escritura
+func (c *Client) forEachMaster(ctx context.Context, fn func(rdb redis.Cmdable) error) error {
+	var err error
+	if cluster, ok := c.rdb.(*redis.ClusterClient); ok {
+		err = cluster.ForEachMaster(ctx, func(ctx context.Context, master *redis.Client) error {
+			return fn(master)
+		})
+	} else {
+		err = fn(c.rdb)
+	}
+	return wrapTimeout(err)
+}
+
+// Connect establece la conexión con Redis. Los errores son *ConnectError e
+// indican si falló la configuración, el handshake TLS o la autenticación; si
+// se agota el plazo de ctx envuelven un *TimeoutError.
+func (c *Client) Connect(ctx context.Context) error {
+	if c.configErr != nil {
+		return &ConnectError{Reason: ConnectReasonConfig, Err: c.configErr}
+	}
+	
+	_, err := c.rdb.Ping(ctx).Result()
 	if err != nil {
-		return fmt.Errorf("failed to connect to Redis: %w", err)
+		return newConnectError(err)
 	}
 	return nil
 }
@@ -76,8 +132,9 @@ func (c *Client) Close() error {
 	return c.rdb.Close()
 }
 
-// ExecuteCommand ejecuta un comando Redis después de analizarlo
-func (c *Client) ExecuteCommand(commandStr string) ExecutionResult {
+// ExecuteCommand ejecuta un comando Redis después de analizarlo. Si se agota
+// el plazo de ctx, result.Err es un *TimeoutError.
+func (c *Client) ExecuteCommand(ctx context.Context, commandStr string) ExecutionResult {
 	start := time.Now()
 	
 	result := ExecutionResult{
@@ -94,8 +151,11 @@ func (c *Client) ExecuteCommand(commandStr string) ExecutionResult {
 		return result
 	}
 	
-	// Validar semánticamente
+	// Validar semánticamente y aplicar la política de comandos; los
+	// comandos que cambian el estado de la conexión no se ejecutan
 	validation := c.analyzer.ValidateCommand(cmd)
+	c.CheckPolicy(cmd, &validation)
+	c.checkConnectionState(cmd, &validation, true)
 	result.Validation = &validation
 	
 	if !validation.Valid {
@@ -105,33 +165,59 @@ func (c *Client) ExecuteCommand(commandStr string) ExecutionResult {
 	}
 	
 	// Ejecutar el comando
-	res, err := c.executeRedisCommand(cmd)
+	result.setReply(c.executeRedisCommand(ctx, cmd))
+	
+	result.ExecutionTime = time.Since(start)
+	return result
+}
+
+// setReply guarda la respuesta de Redis en el resultado. Las respuestas de
+// error de Redis y los errores de red marcan el comando como fallido.
+func (r *ExecutionResult) setReply(reply *Reply, err error) {
 	if err != nil {
-		result.Error = err.Error()
+		r.Error = err.Error()
+		r.Err = err
+		return
+	}
+	
+	r.Reply = reply
+	r.Result = reply.Value()
+	if reply.Type == ReplyError {
+		r.Error = reply.Str
 	} else {
-		result.Success = true
-		result.Result = res
+		r.Success = true
+	}
+}
+
+// executeRedisCommand ejecuta el comando Redis parseado. Los comandos con
+// una ruta tipada en executeFastPath se ejecutan a través de go-redis; el
+// resto se envía tal cual con Do, por lo que basta con que el analizador
+// acepte el comando para poder ejecutarlo.
+func (c *Client) executeRedisCommand(ctx context.Context, cmd *parser.RedisCommand) (*Reply, error) {
+	if reply, handled, err := c.executeFastPath(ctx, cmd); handled {
+		return reply, err
 	}
 	
-	result.ExecutionTime = time.Since(start)
-	return result
+	return newReply(c.rdb.Do(ctx, c.commandArgs(cmd)...).Result())
 }
 
-// executeRedisCommand ejecuta el comando Redis parseado
-func (c *Client) executeRedisCommand(cmd *parser.RedisCommand) (interface{}, error) {
+// executeFastPath ejecuta los comandos que tienen un wrapper tipado en
+// go-redis. Devuelve handled=false si el comando debe ir por la ruta genérica.
+func (c *Client) executeFastPath(ctx context.Context, cmd *parser.RedisCommand) (*Reply, bool, error) {
 	commandName := strings.ToUpper(cmd.Command.Value)
 	
 	switch commandName {
 	case "GET":
 		if len(cmd.Arguments) != 1 {
-			return nil, fmt.Errorf("GET requires exactly 1 argument")
+			return nil, false, nil
 		}
 		key := c.extractStringValue(cmd.Arguments[0])
-		return c.rdb.Get(c.ctx, key).Result()
+		reply, err := newReply(c.rdb.Get(ctx, key).Result())
+		return reply, true, err
 		
 	case "SET":
 		if len(cmd.Arguments) < 2 {
-			return nil, fmt.Errorf("SET requires at least 2 arguments")
+			return nil, false, nil
 		}
 		key := c.extractStringValue(cmd.Arguments[0])
 		value := c.extractStringValue(cmd.Arguments[1])
@@ -141,62 +227,81 @@ func (c *Client) executeRedisCommand(cmd *parser.RedisCommand) (interface{}, err
 		var nx, xx bool
 		
 		for i := 2; i < len(cmd.Arguments); i++ {
-			arg := cmd.Arguments[i]
-			if arg.Type() == "KeywordExpression" {
-				option := strings.ToUpper(arg.String())
-				switch option {
-				case "EX":
-					if i+1 < len(cmd.Arguments) {
-						if seconds, err := c.extractIntValue(cmd.Arguments[i+1]); err == nil {
-							expiration = time.Duration(seconds) * time.Second
-							i++
-						}
-					}
-				case "PX":
-					if i+1 < len(cmd.Arguments) {
-						if millis, err := c.extractIntValue(cmd.Arguments[i+1]); err == nil {
-							expiration = time.Duration(millis) * time.Millisecond
-							i++
-						}
-					}
-				case "NX":
-					nx = true
-				case "XX":
-					xx = true
+			option := strings.ToUpper(c.extractStringValue(cmd.Arguments[i]))
+			switch option {
+			case "EX", "PX":
+				if i+1 >= len(cmd.Arguments) {
+					return nil, false, nil
+				}
+				amount, err := c.extractIntValue(cmd.Arguments[i+1])
+				if err != nil {
+					return nil, false, nil
+				}
+				if option == "EX" {
+					expiration = time.Duration(amount) * time.Second
+				} else {
+					expiration = time.Duration(amount) * time.Millisecond
 				}
+				i++
+			case "NX":
+				nx = true
+			case "XX":
+				xx = true
+			default:
+				// GET, KEEPTTL, EXAT... se delegan a la ruta genérica
+				return nil, false, nil
 			}
 		}
 		
 		// Ejecutar SET con opciones
-		if nx {
-			return c.rdb.SetNX(c.ctx, key, value, expiration).Result()
-		} else if xx {
-			return c.rdb.SetXX(c.ctx, key, value, expiration).Result()
-		} else {
-			return c.rdb.Set(c.ctx, key, value, expiration).Result()
+		if nx || xx {
+			var ok bool
+			var err error
+			if nx {
+				ok, err = c.rdb.SetNX(ctx, key, value, expiration).Result()
+			} else {
+				ok, err = c.rdb.SetXX(ctx, key, value, expiration).Result()
+			}
+			if err != nil {
+				reply, err := newReply(nil, err)
+				return reply, true, err
+			}
+			if !ok {
+				return nilReply(), true, nil
+			}
+			return statusReply("OK"), true, nil
+		}
+		
+		status, err := c.rdb.Set(ctx, key, value, expiration).Result()
+		if err != nil {
+			reply, err := newReply(nil, err)
+			return reply, true, err
 		}
+		return statusReply(status), true, nil
 		
 	case "DEL":
 		if len(cmd.Arguments) == 0 {
-			return nil, fmt.Errorf("DEL requires at least 1 argument")
+			return nil, false, nil
 		}
 		keys := make([]string, len(cmd.Arguments))
 		for i, arg := range cmd.Arguments {
 			keys[i] = c.extractStringValue(arg)
 		}
-		return c.rdb.Del(c.ctx, keys...).Result()
+		reply, err := newReply(c.rdb.Del(ctx, keys...).Result())
+		return reply, true, err
 		
 	case "HGET":
 		if len(cmd.Arguments) != 2 {
-			return nil, fmt.Errorf("HGET requires exactly 2 arguments")
+			return nil, false, nil
 		}
 		key := c.extractStringValue(cmd.Arguments[0])
 		field := c.extractStringValue(cmd.Arguments[1])
-		return c.rdb.HGet(c.ctx, key, field).Result()
+		reply, err := newReply(c.rdb.HGet(ctx, key, field).Result())
+		return reply, true, err
 		
 	case "HSET":
 		if len(cmd.Arguments) < 3 {
-			return nil, fmt.Errorf("HSET requires at least 3 arguments")
+			return nil, false, nil
 		}
 		key := c.extractStringValue(cmd.Arguments[0])
 		
@@ -206,107 +311,31 @@ func (c *Client) executeRedisCommand(cmd *parser.RedisCommand) (interface{}, err
 			fields = append(fields, c.extractStringValue(cmd.Arguments[i]))
 		}
 		
-		return c.rdb.HSet(c.ctx, key, fields...).Result()
-		
-	case "ZADD":
-		if len(cmd.Arguments) < 3 {
-			return nil, fmt.Errorf("ZADD requires at least 3 arguments")
-		}
-		key := c.extractStringValue(cmd.Arguments[0])
-		
-		// Construir miembros con scores
-		members := make([]redis.Z, 0)
-		for i := 1; i < len(cmd.Arguments); i += 2 {
-			if i+1 >= len(cmd.Arguments) {
-				break
-			}
-			score, err := c.extractFloatValue(cmd.Arguments[i])
-			if err != nil {
-				return nil, fmt.Errorf("invalid score: %v", err)
-			}
-			member := c.extractStringValue(cmd.Arguments[i+1])
-			members = append(members, redis.Z{Score: score, Member: member})
-		}
-		
-		return c.rdb.ZAdd(c.ctx, key, members...).Result()
-		
-	case "ZRANGE":
-		if len(cmd.Arguments) < 3 {
-			return nil, fmt.Errorf("ZRANGE requires at least 3 arguments")
-		}
-		key := c.extractStringValue(cmd.Arguments[0])
-		start, err := c.extractIntValue(cmd.Arguments[1])
-		if err != nil {
-			return nil, fmt.Errorf("invalid start index: %v", err)
-		}
-		stop, err := c.extractIntValue(cmd.Arguments[2])
-		if err != nil {
-			return nil, fmt.Errorf("invalid stop index: %v", err)
-		}
-		
-		// Verificar si hay WITHSCORES
-		withScores := false
-		for i := 3; i < len(cmd.Arguments); i++ {
-			if strings.ToUpper(cmd.Arguments[i].String()) == "WITHSCORES" {
-				withScores = true
-				break
-			}
-		}
-		
-		if withScores {
-			return c.rdb.ZRangeWithScores(c.ctx, key, start, stop).Result()
-		} else {
-			return c.rdb.ZRange(c.ctx, key, start, stop).Result()
-		}
-		
-	case "SCAN":
-		if len(cmd.Arguments) < 1 {
-			return nil, fmt.Errorf("SCAN requires at least 1 argument")
-		}
-		cursor, err := c.extractIntValue(cmd.Arguments[0])
-		if err != nil {
-			return nil, fmt.Errorf("invalid cursor: %v", err)
-		}
-		
-		// Opciones por defecto
-		match := ""
-		count := int64(10)
-		
-		// Parsear opciones
-		for i := 1; i < len(cmd.Arguments); i++ {
-			if cmd.Arguments[i].Type() == "KeywordExpression" {
-				option := strings.ToUpper(cmd.Arguments[i].String())
-				switch option {
-				case "MATCH":
-					if i+1 < len(cmd.Arguments) {
-						match = c.extractStringValue(cmd.Arguments[i+1])
-						i++
-					}
-				case "COUNT":
-					if i+1 < len(cmd.Arguments) {
-						if c, err := c.extractIntValue(cmd.Arguments[i+1]); err == nil {
-							count = c
-							i++
-						}
-					}
-				}
+		reply, err := newReply(c.rdb.HSet(ctx, key, fields...).Result())
+		return reply, true, err
+	}
+	
+	return nil, false, nil
+}
+
+// commandArgs convierte el comando parseado en la lista de argumentos
+// que se envía a Redis (nombre del comando incluido)
+func (c *Client) commandArgs(cmd *parser.RedisCommand) []interface{} {
+	args := make([]interface{}, 0, len(cmd.Arguments)+1)
+	args = append(args, cmd.Command.Value)
+	
+	for _, arg := range cmd.Arguments {
+		if opt, ok := arg.(*parser.OptionExpression); ok {
+			args = append(args, c.extractStringValue(opt.Option))
+			if opt.Value != nil {
+				args = append(args, c.extractStringValue(opt.Value))
 			}
+			continue
 		}
-		
-		keys, nextCursor, err := c.rdb.Scan(c.ctx, uint64(cursor), match, count).Result()
-		if err != nil {
-			return nil, err
-		}
-		
-		// Devolver un mapa con las claves y el cursor
-		return map[string]interface{}{
-			"keys":   keys,
-			"cursor": nextCursor,
-		}, nil
-		
-	default:
-		return nil, fmt.Errorf("command %s not implemented", commandName)
+		args = append(args, c.extractStringValue(arg))
 	}
+	
+	return args
 }
 
 // extractStringValue extrae el valor string de una expresión
@@ -318,9 +347,18 @@ func (c *Client) extractStringValue(expr parser.Expression) string {
 		return e.Value
 	case *parser.PatternExpression:
 		return e.Value
+	case *parser.KeywordExpression:
+		return e.Value
 	case *parser.IntegerLiteral:
+		// Los números se envían como se escribieron (01234, 1.50)
+		if e.Token.Literal != "" {
+			return e.Token.Literal
+		}
 		return strconv.FormatInt(e.Value, 10)
 	case *parser.FloatLiteral:
+		if e.Token.Literal != "" {
+			return e.Token.Literal
+		}
 		return strconv.FormatFloat(e.Value, 'f', -1, 64)
 	default:
 		return expr.String()
@@ -357,18 +395,44 @@ func (c *Client) extractFloatValue(expr parser.Expression) (float64, error) {
 	}
 }
 
-// GetDatabaseInfo obtiene información sobre la base de datos Redis
-func (c *Client) GetDatabaseInfo() (DatabaseInfo, error) {
+// GetDatabaseInfo obtiene información sobre la base de datos Redis. En
+// cluster se suman las claves y los contadores numéricos de todos los masters.
+func (c *Client) GetDatabaseInfo(ctx context.Context) (DatabaseInfo, error) {
 	info := DatabaseInfo{
 		Memory:  make(map[string]string),
 		Clients: make(map[string]string),
 		Stats:   make(map[string]string),
 	}
 	
-	// Obtener información del servidor
-	infoResult, err := c.rdb.Info(c.ctx).Result()
-	if err != nil {
-		return info, err
+	var mu sync.Mutex
+	err := c.forEachMaster(ctx, func(rdb redis.Cmdable) error {
+		// Obtener información del servidor
+		infoResult, err := rdb.Info(ctx).Result()
+		if err != nil {
+			return err
+		}
+		node := parseServerInfo(infoResult)
+		
+		// Obtener número de claves
+		if dbSize, err := rdb.DBSize(ctx).Result(); err == nil {
+			node.KeyCount = dbSize
+		}
+		
+		mu.Lock()
+		defer mu.Unlock()
+		mergeDatabaseInfo(&info, node)
+		return nil
+	})
+	
+	return info, err
+}
+
+// parseServerInfo extrae los campos relevantes de la salida de INFO
+func parseServerInfo(infoResult string) DatabaseInfo {
+	info := DatabaseInfo{
+		Memory:  make(map[string]string),
+		Clients: make(map[string]string),
+		Stats:   make(map[string]string),
 	}
 	
 	// Parsear la información
@@ -394,47 +458,87 @@ func (c *Client) GetDatabaseInfo() (DatabaseInfo, error) {
 		}
 	}
 	
-	// Obtener número de claves
-	dbSize, err := c.rdb.DBSize(c.ctx).Result()
-	if err == nil {
-		info.KeyCount = dbSize
+	return info
+}
+
+// mergeDatabaseInfo acumula la información de un master en el total
+func mergeDatabaseInfo(total *DatabaseInfo, node DatabaseInfo) {
+	total.Masters++
+	total.KeyCount += node.KeyCount
+	if total.Version == "" {
+		total.Version = node.Version
 	}
 	
-	return info, nil
+	first := total.Masters == 1
+	mergeInfoFields(total.Memory, node.Memory, first)
+	mergeInfoFields(total.Clients, node.Clients, first)
+	mergeInfoFields(total.Stats, node.Stats, first)
 }
 
-// ListKeys lista las claves que coinciden con un patrón
-func (c *Client) ListKeys(pattern string, limit int) ([]string, error) {
+// mergeInfoFields suma los valores enteros de cada campo. Los valores que no
+// son enteros (p.ej. used_memory_human) solo se conservan si todos los
+// masters coinciden.
+func mergeInfoFields(total, node map[string]string, first bool) {
+	if first {
+		for key, value := range node {
+			total[key] = value
+		}
+		return
+	}
+	
+	for key, value := range total {
+		a, errA := strconv.ParseInt(value, 10, 64)
+		b, errB := strconv.ParseInt(node[key], 10, 64)
+		switch {
+		case errA == nil && errB == nil:
+			total[key] = strconv.FormatInt(a+b, 10)
+		case value != node[key]:
+			delete(total, key)
+		}
+	}
+}
+
+// ListKeys lista las claves que coinciden con un patrón. En cluster se
+// recorren todos los masters hasta reunir limit claves.
+func (c *Client) ListKeys(ctx context.Context, pattern string, limit int) ([]string, error) {
 	if pattern == "" {
 		pattern = "*"
 	}
 	
+	var mu sync.Mutex
 	keys := make([]string, 0)
-	iter := c.rdb.Scan(c.ctx, 0, pattern, int64(limit)).Iterator()
-	
-	for iter.Next(c.ctx) {
-		keys = append(keys, iter.Val())
-		if len(keys) >= limit {
-			break
+	err := c.forEachMaster(ctx, func(rdb redis.Cmdable) error {
+		iter := rdb.Scan(ctx, 0, pattern, int64(limit)).Iterator()
+		for iter.Next(ctx) {
+			mu.Lock()
+			full := len(keys) >= limit
+			if !full {
+				keys = append(keys, iter.Val())
+			}
+			mu.Unlock()
+			if full {
+				break
+			}
 		}
-	}
+		return iter.Err()
+	})
 	
-	return keys, iter.Err()
+	return keys, err
 }
 
 // GetKeyInfo obtiene información sobre una clave específica
-func (c *Client) GetKeyInfo(key string) (map[string]interface{}, error) {
+func (c *Client) GetKeyInfo(ctx context.Context, key string) (map[string]interface{}, error) {
 	info := make(map[string]interface{})
 	
 	// Tipo de la clave
-	keyType, err := c.rdb.Type(c.ctx, key).Result()
+	keyType, err := c.rdb.Type(ctx, key).Result()
 	if err != nil {
-		return nil, err
+		return nil, wrapTimeout(err)
 	}
 	info["type"] = keyType
 	
 	// TTL
-	ttl, err := c.rdb.TTL(c.ctx, key).Result()
+	ttl, err := c.rdb.TTL(ctx, key).Result()
 	if err == nil {
 		info["ttl"] = ttl.Seconds()
 	}
@@ -442,27 +546,27 @@ func (c *Client) GetKeyInfo(key string) (map[string]interface{}, error) {
 	// Tamaño (aproximado)
 	switch keyType {
 	case "string":
-		length, err := c.rdb.StrLen(c.ctx, key).Result()
+		length, err := c.rdb.StrLen(ctx, key).Result()
 		if err == nil {
 			info["length"] = length
 		}
 	case "list":
-		length, err := c.rdb.LLen(c.ctx, key).Result()
+		length, err := c.rdb.LLen(ctx, key).Result()
 		if err == nil {
 			info["length"] = length
 		}
 	case "set":
-		length, err := c.rdb.SCard(c.ctx, key).Result()
+		length, err := c.rdb.SCard(ctx, key).Result()
 		if err == nil {
 			info["length"] = length
 		}
 	case "hash":
-		length, err := c.rdb.HLen(c.ctx, key).Result()
+		length, err := c.rdb.HLen(ctx, key).Result()
 		if err == nil {
 			info["length"] = length
 		}
 	case "zset":
-		length, err := c.rdb.ZCard(c.ctx, key).Result()
+		length, err := c.rdb.ZCard(ctx, key).Result()
 		if err == nil {
 			info["length"] = length
 		}
@@ -471,8 +575,21 @@ func (c *Client) GetKeyInfo(key string) (map[string]interface{}, error) {
 	return info, nil
 }
 
-// FlushDatabase limpia la base de datos actual
-func (c *Client) FlushDatabase() error {
-	return c.rdb.FlushDB(c.ctx).Err()
+// FlushDatabase limpia la base de datos actual (en cluster, la de todos
+// los masters). En modo solo lectura devuelve ErrReadOnly y si la política
+// deniega FLUSHDB un *policy.Denial.
+func (c *Client) FlushDatabase(ctx context.Context) error {
+	if c.ReadOnly() {
+		return ErrReadOnly
+	}
+	if c.policy != nil {
+		cmd, _ := parser.ParseCommand("FLUSHDB")
+		if denial := c.policy.Evaluate(c.analyzer, cmd); denial != nil {
+			return denial
+		}
+	}
+	return c.forEachMaster(ctx, func(rdb redis.Cmdable) error {
+		return rdb.FlushDB(ctx).Err()
+	})
 }
 
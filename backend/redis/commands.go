@@ -0,0 +1,323 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"redis-analyzer-api/parser"
+	"redis-analyzer-api/policy"
+	"redis-analyzer-api/semantic"
+)
+
+// Analyzer devuelve el analizador semántico usado por el cliente
+func (c *Client) Analyzer() *semantic.Analyzer {
+	return c.analyzer
+}
+
+// ReadOnly indica si el cliente solo admite comandos de lectura
+func (c *Client) ReadOnly() bool {
+	return c.analyzer.ReadOnly()
+}
+
+// PoolStats resume el estado del pool de conexiones de go-redis. En cluster
+// es la suma de los pools de todos los nodos.
+type PoolStats struct {
+	Hits       uint32 // conexiones libres encontradas en el pool
+	Misses     uint32 // veces que hubo que abrir una conexión nueva
+	Timeouts   uint32 // esperas por una conexión que agotaron el plazo
+	TotalConns uint32 // conexiones abiertas
+	IdleConns  uint32 // conexiones libres
+	StaleConns uint32 // conexiones caducadas retiradas del pool
+}
+
+// PoolStats devuelve las estadísticas del pool de conexiones
+func (c *Client) PoolStats() PoolStats {
+	stats := c.rdb.PoolStats()
+	return PoolStats{
+		Hits:       stats.Hits,
+		Misses:     stats.Misses,
+		Timeouts:   stats.Timeouts,
+		TotalConns: stats.TotalConns,
+		IdleConns:  stats.IdleConns,
+		StaleConns: stats.StaleConns,
+	}
+}
+
+// SetPolicy fija la política que decide qué comandos validados pueden
+// ejecutarse. nil la desactiva.
+func (c *Client) SetPolicy(p *policy.Policy) {
+	c.policy = p
+}
+
+// CheckPolicy añade un error POLICY al resultado si la política deniega el
+// comando
+func (c *Client) CheckPolicy(cmd *parser.RedisCommand, result *semantic.ValidationResult) {
+	if c.policy != nil {
+		c.policy.Check(c.analyzer, cmd, result)
+	}
+}
+
+// checkProgramPolicy aplica la política a cada comando de un programa y
+// rechaza los que cambiarían el estado de una conexión del pool, incluidos
+// los WATCH que no preceden a un bloque MULTI/EXEC. Los
+// resultados de validación siguen el orden de ValidateProgram: uno por
+// comando, incluidos MULTI, los encolados y EXEC/DISCARD.
+func (c *Client) checkProgramPolicy(program *parser.Program, results []semantic.ValidationResult) {
+	i := 0
+	check := func(cmd *parser.RedisCommand) {
+		if i < len(results) {
+			c.CheckPolicy(cmd, &results[i])
+			c.checkConnectionState(cmd, &results[i], false)
+		}
+		i++
+	}
+	for j, stmt := range program.Statements {
+		switch s := stmt.(type) {
+		case *parser.RedisCommand:
+			check(s)
+			if i <= len(results) {
+				checkWatch(s, program.Statements[j+1:], &results[i-1])
+			}
+		case *parser.TransactionBlock:
+			for _, cmd := range s.AllCommands() {
+				check(cmd)
+			}
+		}
+	}
+}
+
+// LoadCommandDocs carga la tabla de comandos del servidor conectado usando
+// COMMAND DOCS y COMMAND, de modo que el analizador conozca exactamente los
+// comandos (y módulos) disponibles en esa versión de Redis. La tabla debe
+// incluir los comandos de required y los que nombra la política; si falta
+// alguno se devuelve un error y se conserva la tabla actual.
+func (c *Client) LoadCommandDocs(ctx context.Context, required ...string) error {
+	docsReply, err := c.rdb.Do(ctx, "COMMAND", "DOCS").Result()
+	if err != nil {
+		return fmt.Errorf("failed to run COMMAND DOCS: %w", wrapTimeout(err))
+	}
+	infoReply, err := c.rdb.Do(ctx, "COMMAND").Result()
+	if err != nil {
+		return fmt.Errorf("failed to run COMMAND: %w", wrapTimeout(err))
+	}
+
+	docs := make(map[string]semantic.CommandDoc)
+	collectCommandDocs(docs, docsReply)
+	collectCommandInfo(docs, infoReply)
+
+	if len(docs) == 0 {
+		return fmt.Errorf("server returned an empty command table")
+	}
+
+	specs := semantic.SpecsFromDocs(docs)
+	if c.policy != nil {
+		required = append(required, c.policy.Commands()...)
+	}
+	var missing []string
+	for _, name := range required {
+		if _, ok := specs[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("server command table is missing %s", strings.Join(missing, ", "))
+	}
+
+	c.analyzer.LoadCommands(specs)
+	return nil
+}
+
+// collectCommandDocs convierte la respuesta de COMMAND DOCS (RESP2 o RESP3)
+func collectCommandDocs(docs map[string]semantic.CommandDoc, reply interface{}) {
+	for name, value := range replyMap(reply) {
+		fields := replyMap(value)
+		docName := commandDocName(name)
+
+		doc := docs[docName]
+		doc.Summary = replyString(fields["summary"])
+		doc.Since = replyString(fields["since"])
+		doc.Group = replyString(fields["group"])
+		doc.Complexity = replyString(fields["complexity"])
+		doc.DocFlags = replyStrings(fields["doc_flags"])
+		doc.Arguments = argumentDocs(fields["arguments"])
+		if words := strings.Fields(docName); len(words) == 2 {
+			doc.Container = words[0]
+		}
+		docs[docName] = doc
+
+		if subcommands, ok := fields["subcommands"]; ok {
+			collectCommandDocs(docs, subcommands)
+		}
+	}
+}
+
+// collectCommandInfo añade aridad, flags, categorías y key specs desde COMMAND
+func collectCommandInfo(docs map[string]semantic.CommandDoc, reply interface{}) {
+	entries, ok := reply.([]interface{})
+	if !ok {
+		return
+	}
+
+	for _, entry := range entries {
+		info, ok := entry.([]interface{})
+		if !ok || len(info) < 3 {
+			continue
+		}
+
+		docName := commandDocName(replyString(info[0]))
+		doc := docs[docName]
+		doc.Arity = int(replyInt(info[1]))
+		doc.CommandFlags = replyStrings(info[2])
+		if len(info) > 6 {
+			doc.ACLCategories = replyStrings(info[6])
+		}
+		if len(info) > 8 {
+			doc.KeySpecs = keySpecDocs(info[8])
+		}
+		docs[docName] = doc
+
+		if len(info) > 9 {
+			collectCommandInfo(docs, info[9])
+		}
+	}
+}
+
+// argumentDocs convierte la lista de argumentos de COMMAND DOCS
+func argumentDocs(value interface{}) []semantic.ArgumentDoc {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	args := make([]semantic.ArgumentDoc, 0, len(items))
+	for _, item := range items {
+		fields := replyMap(item)
+		arg := semantic.ArgumentDoc{
+			Name:        replyString(fields["name"]),
+			Type:        replyString(fields["type"]),
+			Token:       replyString(fields["token"]),
+			DisplayText: replyString(fields["display_text"]),
+			Since:       replyString(fields["since"]),
+			Arguments:   argumentDocs(fields["arguments"]),
+		}
+		if index, ok := fields["key_spec_index"]; ok {
+			keySpecIndex := int(replyInt(index))
+			arg.KeySpecIndex = &keySpecIndex
+		}
+		for _, flag := range replyStrings(fields["flags"]) {
+			switch flag {
+			case "optional":
+				arg.Optional = true
+			case "multiple":
+				arg.Multiple = true
+			case "multiple_token":
+				arg.MultipleToken = true
+			}
+		}
+		args = append(args, arg)
+	}
+	return args
+}
+
+// keySpecDocs convierte las key specs de la respuesta de COMMAND
+func keySpecDocs(value interface{}) []semantic.KeySpecDoc {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	specs := make([]semantic.KeySpecDoc, 0, len(items))
+	for _, item := range items {
+		fields := replyMap(item)
+		var spec semantic.KeySpecDoc
+		spec.Flags = replyStrings(fields["flags"])
+
+		begin := replyMap(fields["begin_search"])
+		beginSpec := replyMap(begin["spec"])
+		spec.BeginSearch.Type = replyString(begin["type"])
+		spec.BeginSearch.Spec.Index = int(replyInt(beginSpec["index"]))
+		spec.BeginSearch.Spec.Keyword = replyString(beginSpec["keyword"])
+		spec.BeginSearch.Spec.StartFrom = int(replyInt(beginSpec["startfrom"]))
+
+		find := replyMap(fields["find_keys"])
+		findSpec := replyMap(find["spec"])
+		spec.FindKeys.Type = replyString(find["type"])
+		spec.FindKeys.Spec.LastKey = int(replyInt(findSpec["lastkey"]))
+		spec.FindKeys.Spec.Step = int(replyInt(findSpec["keystep"]))
+		spec.FindKeys.Spec.Limit = int(replyInt(findSpec["limit"]))
+		spec.FindKeys.Spec.KeyNumIdx = int(replyInt(findSpec["keynumidx"]))
+		spec.FindKeys.Spec.FirstKey = int(replyInt(findSpec["firstkey"]))
+
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
+// commandDocName normaliza nombres como "client|kill" a "CLIENT KILL"
+func commandDocName(name string) string {
+	return strings.ToUpper(strings.ReplaceAll(name, "|", " "))
+}
+
+// replyMap interpreta un mapa RESP3 o una lista plana clave/valor de RESP2
+func replyMap(value interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		for k, val := range v {
+			result[fmt.Sprint(k)] = val
+		}
+	case map[string]interface{}:
+		for k, val := range v {
+			result[k] = val
+		}
+	case []interface{}:
+		for i := 0; i+1 < len(v); i += 2 {
+			result[fmt.Sprint(v[i])] = v[i+1]
+		}
+	}
+
+	return result
+}
+
+// replyString convierte un valor de respuesta en cadena ("" si es nil)
+func replyString(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// replyInt convierte un valor de respuesta en entero (0 si no lo es)
+func replyInt(value interface{}) int64 {
+	switch v := value.(type) {
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	}
+	return 0
+}
+
+// replyStrings convierte una lista o conjunto de la respuesta en cadenas
+func replyStrings(value interface{}) []string {
+	var items []interface{}
+
+	switch v := value.(type) {
+	case []interface{}:
+		items = v
+	case map[interface{}]bool:
+		for k := range v {
+			items = append(items, k)
+		}
+	default:
+		return nil
+	}
+
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		result = append(result, replyString(item))
+	}
+	return result
+}
@@ -0,0 +1,111 @@
+package redis
+
+import (
+	"fmt"
+	"strings"
+
+	"redis-analyzer-api/parser"
+	"redis-analyzer-api/semantic"
+)
+
+// connectionCommands cambian el estado de la conexión en la que se ejecutan
+// (base de datos, usuario, protocolo, modo suscripción...). El cliente usa
+// un pool de conexiones compartido, así que ese estado afectaría a las
+// peticiones que reciban después la misma conexión.
+var connectionCommands = map[string]bool{
+	"SELECT":          true,
+	"AUTH":            true,
+	"HELLO":           true,
+	"RESET":           true,
+	"QUIT":            true,
+	"READONLY":        true,
+	"READWRITE":       true,
+	"ASKING":          true,
+	"MONITOR":         true,
+	"SUBSCRIBE":       true,
+	"PSUBSCRIBE":      true,
+	"SSUBSCRIBE":      true,
+	"UNSUBSCRIBE":     true,
+	"PUNSUBSCRIBE":    true,
+	"SUNSUBSCRIBE":    true,
+	"SYNC":            true,
+	"PSYNC":           true,
+	"CLIENT REPLY":    true,
+	"CLIENT SETNAME":  true,
+	"CLIENT SETINFO":  true,
+	"CLIENT TRACKING": true,
+	"CLIENT CACHING":  true,
+	"CLIENT NO-EVICT": true,
+	"CLIENT NO-TOUCH": true,
+}
+
+// transactionCommands solo tienen sentido junto al resto de su bloque
+// MULTI/EXEC, que ExecuteProgram y ExecuteTransaction envían en una misma
+// conexión
+var transactionCommands = map[string]bool{
+	"MULTI":   true,
+	"EXEC":    true,
+	"DISCARD": true,
+	"WATCH":   true,
+	"UNWATCH": true,
+}
+
+// checkConnectionState añade un error CONNECTION_STATE si el comando
+// cambiaría el estado de una conexión del pool. standalone indica que el
+// comando se ejecuta solo (ExecuteCommand), donde tampoco se aceptan los
+// comandos de transacción.
+func (c *Client) checkConnectionState(cmd *parser.RedisCommand, result *semantic.ValidationResult, standalone bool) {
+	if !result.Valid {
+		return
+	}
+
+	name := strings.ToUpper(cmd.Command.Value)
+	full := name
+	if len(cmd.Arguments) > 0 {
+		full = name + " " + strings.ToUpper(c.extractStringValue(cmd.Arguments[0]))
+	}
+
+	var message string
+	switch {
+	case connectionCommands[name] || connectionCommands[full]:
+		if !connectionCommands[name] {
+			name = full
+		}
+		message = fmt.Sprintf("%s changes the state of the connection and cannot run on the shared connection pool", name)
+	case standalone && transactionCommands[name]:
+		message = fmt.Sprintf("%s only runs as part of a MULTI/EXEC block sent as a script", name)
+	default:
+		return
+	}
+	result.AddError(semantic.SemanticError{
+		Message: message,
+		Command: name,
+		Type:    "CONNECTION_STATE",
+		Span:    cmd.Span(),
+	})
+}
+
+// checkWatch añade un error CONNECTION_STATE a un WATCH que no va seguido
+// (tras otros WATCH) de un bloque MULTI/EXEC. Solo los WATCH de un bloque se
+// envían en su misma conexión; uno suelto no vigilaría nada y dejaría las
+// claves vigiladas en una conexión del pool.
+func checkWatch(cmd *parser.RedisCommand, rest []parser.Statement, result *semantic.ValidationResult) {
+	if !result.Valid || !strings.EqualFold(cmd.Command.Value, "WATCH") {
+		return
+	}
+	for _, stmt := range rest {
+		if _, ok := stmt.(*parser.TransactionBlock); ok {
+			return
+		}
+		next, ok := stmt.(*parser.RedisCommand)
+		if !ok || !strings.EqualFold(next.Command.Value, "WATCH") {
+			break
+		}
+	}
+	result.AddError(semantic.SemanticError{
+		Message: "WATCH must be followed by a MULTI/EXEC block",
+		Command: "WATCH",
+		Type:    "CONNECTION_STATE",
+		Span:    cmd.Span(),
+	})
+}
@@ -0,0 +1,124 @@
+package redis
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ErrReadOnly indica que la operación modificaría datos y el cliente está en
+// modo solo lectura
+var ErrReadOnly = errors.New("operation not allowed in read-only mode")
+
+// Motivos por los que puede fallar la conexión con Redis
+const (
+	ConnectReasonConfig  = "config"  // la configuración no se pudo cargar
+	ConnectReasonTLS     = "tls"     // el handshake TLS falló
+	ConnectReasonAuth    = "auth"    // Redis rechazó las credenciales
+	ConnectReasonNetwork = "network" // cualquier otro error de red
+)
+
+// ConnectError describe por qué falló Client.Connect
+type ConnectError struct {
+	Reason string // uno de los ConnectReason*
+	Err    error
+}
+
+func (e *ConnectError) Error() string {
+	switch e.Reason {
+	case ConnectReasonConfig:
+		return fmt.Sprintf("failed to connect to Redis: invalid configuration: %v", e.Err)
+	case ConnectReasonTLS:
+		return fmt.Sprintf("failed to connect to Redis: TLS handshake failed: %v", e.Err)
+	case ConnectReasonAuth:
+		return fmt.Sprintf("failed to connect to Redis: authentication failed: %v", e.Err)
+	default:
+		return fmt.Sprintf("failed to connect to Redis: %v", e.Err)
+	}
+}
+
+func (e *ConnectError) Unwrap() error {
+	return e.Err
+}
+
+// TimeoutError indica que una operación contra Redis no terminó antes de que
+// venciera el plazo de su contexto o el timeout de lectura/escritura
+type TimeoutError struct {
+	Err error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("Redis operation timed out: %v", e.Err)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// Timeout permite tratar el error como cualquier net.Error de timeout
+func (e *TimeoutError) Timeout() bool {
+	return true
+}
+
+// IsTimeout indica si err es o envuelve un *TimeoutError
+func IsTimeout(err error) bool {
+	var timeoutErr *TimeoutError
+	return errors.As(err, &timeoutErr)
+}
+
+// wrapTimeout envuelve en un *TimeoutError los errores causados por un plazo
+// vencido y devuelve el resto sin cambios. La cancelación del contexto (p.ej.
+// el cliente HTTP cerró la conexión) no se considera un timeout.
+func wrapTimeout(err error) error {
+	if err == nil || IsTimeout(err) {
+		return err
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return &TimeoutError{Err: err}
+	}
+	return err
+}
+
+// newConnectError clasifica un error de conexión según su causa
+func newConnectError(err error) *ConnectError {
+	reason := ConnectReasonNetwork
+	switch {
+	case isTLSError(err):
+		reason = ConnectReasonTLS
+	case isAuthError(err):
+		reason = ConnectReasonAuth
+	}
+	return &ConnectError{Reason: reason, Err: wrapTimeout(err)}
+}
+
+// isTLSError indica si el error procede de la verificación de certificados
+// o del handshake TLS
+func isTLSError(err error) bool {
+	var (
+		verifyErr    *tls.CertificateVerificationError
+		recordErr    tls.RecordHeaderError
+		alertErr     tls.AlertError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+	return errors.As(err, &verifyErr) || errors.As(err, &recordErr) || errors.As(err, &alertErr) ||
+		errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) ||
+		strings.Contains(err.Error(), "tls: ")
+}
+
+// isAuthError indica si Redis rechazó el usuario o la contraseña
+func isAuthError(err error) bool {
+	msg := err.Error()
+	for _, prefix := range []string{"WRONGPASS", "NOAUTH", "NOPERM"} {
+		if strings.HasPrefix(msg, prefix) {
+			return true
+		}
+	}
+	return strings.HasPrefix(msg, "ERR") && strings.Contains(msg, "AUTH") && strings.Contains(msg, "password")
+}
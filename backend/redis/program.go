@@ -0,0 +1,331 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"redis-analyzer-api/parser"
+	"redis-analyzer-api/semantic"
+)
+
+// ProgramOptions controla cómo se ejecuta un programa de varios comandos
+type ProgramOptions struct {
+	// StopOnError ejecuta las sentencias una a una y se detiene en la
+	// primera que falle. Por defecto se envían en pipeline.
+	StopOnError bool
+
+	// CheckKeyTypes consulta el tipo que las claves del programa tienen en
+	// el servidor antes de validarlo, de modo que un GET sobre un hash que
+	// ya existe se detecte como WRONGTYPE sin enviar ningún comando
+	CheckKeyTypes bool
+}
+
+// ProgramResult contiene el resultado de ejecutar un programa completo
+type ProgramResult struct {
+	Success       bool
+	Statements    []StatementResult
+	Error         string
+	ExecutionTime time.Duration
+	Validation    []semantic.ValidationResult
+	Err           error // primer error de red o *TimeoutError de las sentencias
+}
+
+// StatementResult contiene el resultado de una sentencia del programa: un
+// comando simple o un bloque MULTI/EXEC junto con sus WATCH previos
+type StatementResult struct {
+	Statement     string
+	Line          int
+	Success       bool
+	Error         string
+	Err           error // error de red o *TimeoutError; nil si Redis respondió
+	ExecutionTime time.Duration
+	Command       *ExecutionResult
+	Transaction   *TransactionResult
+}
+
+// programStatement es una sentencia lista para ejecutarse con sus
+// resultados de validación
+type programStatement struct {
+	command     *parser.RedisCommand
+	block       *parser.TransactionBlock
+	watchKeys   []string
+	text        string
+	line        int
+	validations []semantic.ValidationResult
+}
+
+// ExecuteProgram analiza y ejecuta un programa de comandos separados por
+// saltos de línea. Todo el programa se valida antes de enviar nada a Redis.
+// En modo pipeline los tiempos de cada sentencia corresponden al lote en el
+// que se envió.
+func (c *Client) ExecuteProgram(ctx context.Context, input string, options ProgramOptions) ProgramResult {
+	start := time.Now()
+
+	result := ProgramResult{Statements: []StatementResult{}}
+
+	program, parseErrors := parser.ParseCommands(input)
+	if len(parseErrors) > 0 {
+		result.Error = fmt.Sprintf("Parse errors: %v", parseErrors)
+		result.ExecutionTime = time.Since(start)
+		return result
+	}
+
+	var types semantic.KeyTypes
+	if options.CheckKeyTypes {
+		var err error
+		if types, err = c.KeyTypes(ctx, program); err != nil {
+			result.Error = fmt.Sprintf("Key types: %v", err)
+			result.Err = err
+			result.ExecutionTime = time.Since(start)
+			return result
+		}
+	}
+
+	result.Validation = c.analyzer.ValidateProgramWithTypes(program, types)
+	c.checkProgramPolicy(program, result.Validation)
+	for _, validation := range result.Validation {
+		if !validation.Valid {
+			result.Error = fmt.Sprintf("Semantic errors: %v", validation.Errors)
+			result.ExecutionTime = time.Since(start)
+			return result
+		}
+	}
+
+	statements := c.programStatements(program, result.Validation)
+	if options.StopOnError {
+		c.executeSequential(ctx, statements, &result)
+	} else {
+		c.executePipelined(ctx, statements, &result)
+	}
+
+	result.Success = result.Error == ""
+	for _, stmt := range result.Statements {
+		if !stmt.Success {
+			result.Success = false
+		}
+		if result.Err == nil {
+			result.Err = stmt.Err
+		}
+	}
+
+	result.ExecutionTime = time.Since(start)
+	return result
+}
+
+// KeyTypes devuelve el tipo que tiene en el servidor cada clave usada por el
+// programa, obtenido con GetKeyInfo (TYPE). Las claves que no existen tienen
+// tipo "none".
+func (c *Client) KeyTypes(ctx context.Context, program *parser.Program) (semantic.KeyTypes, error) {
+	types := semantic.KeyTypes{}
+	for _, cmd := range program.Commands() {
+		for _, key := range c.analyzer.Keys(cmd) {
+			if _, seen := types[key.Name]; seen {
+				continue
+			}
+			info, err := c.GetKeyInfo(ctx, key.Name)
+			if err != nil {
+				return nil, err
+			}
+			keyType, _ := info["type"].(string)
+			types[key.Name] = keyType
+		}
+	}
+	return types, nil
+}
+
+// programStatements reparte los resultados de validación entre las
+// sentencias y agrupa los WATCH inmediatamente anteriores a un bloque
+// MULTI/EXEC con ese bloque, ya que deben ejecutarse en su misma conexión
+func (c *Client) programStatements(program *parser.Program, validations []semantic.ValidationResult) []programStatement {
+	statements := []programStatement{}
+	var watches []programStatement
+	offset := 0
+
+	flushWatches := func() {
+		statements = append(statements, watches...)
+		watches = nil
+	}
+
+	for _, stmt := range program.Statements {
+		switch s := stmt.(type) {
+		case *parser.RedisCommand:
+			ps := programStatement{
+				command:     s,
+				text:        s.String(),
+				line:        s.Span().Start.Line,
+				validations: validations[offset : offset+1],
+			}
+			offset++
+
+			if strings.EqualFold(s.Command.Value, "WATCH") {
+				watches = append(watches, ps)
+				continue
+			}
+			flushWatches()
+			statements = append(statements, ps)
+		case *parser.TransactionBlock:
+			count := len(s.AllCommands())
+			ps := programStatement{
+				block:       s,
+				watchKeys:   []string{},
+				text:        s.String(),
+				line:        s.Span().Start.Line,
+				validations: validations[offset : offset+count],
+			}
+			offset += count
+
+			texts := []string{}
+			for _, watch := range watches {
+				for _, arg := range watch.command.Arguments {
+					ps.watchKeys = append(ps.watchKeys, c.extractStringValue(arg))
+				}
+				texts = append(texts, watch.text)
+			}
+			if len(watches) > 0 {
+				ps.line = watches[0].line
+				ps.text = strings.Join(append(texts, ps.text), "\n")
+			}
+			watches = nil
+			statements = append(statements, ps)
+		}
+	}
+	flushWatches()
+
+	return statements
+}
+
+// executeSequential ejecuta las sentencias una a una y se detiene en la
+// primera que falle
+func (c *Client) executeSequential(ctx context.Context, statements []programStatement, result *ProgramResult) {
+	for i, stmt := range statements {
+		start := time.Now()
+		var stmtResult StatementResult
+
+		if stmt.block != nil {
+			stmtResult = c.runTransactionStatement(ctx, stmt)
+		} else {
+			execResult := ExecutionResult{Command: stmt.text, Validation: &stmt.validations[0]}
+			execResult.setReply(c.executeRedisCommand(ctx, stmt.command))
+			execResult.ExecutionTime = time.Since(start)
+			stmtResult = commandStatementResult(stmt, execResult)
+		}
+		stmtResult.ExecutionTime = time.Since(start)
+		result.Statements = append(result.Statements, stmtResult)
+
+		if !stmtResult.Success {
+			if remaining := len(statements) - i - 1; remaining > 0 {
+				result.Error = fmt.Sprintf("Execution stopped at line %d; %d statement(s) not executed", stmt.line, remaining)
+			}
+			return
+		}
+	}
+}
+
+// executePipelined envía los comandos simples consecutivos en un único
+// pipeline. Los bloques MULTI/EXEC se ejecutan por separado como
+// transacción, manteniendo el orden del programa.
+func (c *Client) executePipelined(ctx context.Context, statements []programStatement, result *ProgramResult) {
+	batch := []programStatement{}
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		result.Statements = append(result.Statements, c.runPipeline(ctx, batch)...)
+		batch = batch[:0]
+	}
+
+	for _, stmt := range statements {
+		if stmt.block == nil {
+			batch = append(batch, stmt)
+			continue
+		}
+
+		flush()
+		start := time.Now()
+		stmtResult := c.runTransactionStatement(ctx, stmt)
+		stmtResult.ExecutionTime = time.Since(start)
+		result.Statements = append(result.Statements, stmtResult)
+	}
+	flush()
+}
+
+// runPipeline envía un lote de comandos simples en un pipeline de go-redis
+func (c *Client) runPipeline(ctx context.Context, batch []programStatement) []StatementResult {
+	start := time.Now()
+
+	pipe := c.rdb.Pipeline()
+	cmds := make([]*redis.Cmd, 0, len(batch))
+	for _, stmt := range batch {
+		cmds = append(cmds, pipe.Do(ctx, c.commandArgs(stmt.command)...))
+	}
+	// Los errores de cada comando se leen de su propio Cmd
+	_, execErr := pipe.Exec(ctx)
+
+	elapsed := time.Since(start)
+	results := make([]StatementResult, 0, len(batch))
+	for i, stmt := range batch {
+		execResult := ExecutionResult{Command: stmt.text, Validation: &stmt.validations[0], ExecutionTime: elapsed}
+		val, err := cmds[i].Result()
+		if val == nil && err == nil && execErr != nil {
+			// El pipeline falló antes de leer la respuesta de este comando
+			// (una respuesta nil de Redis llega como redis.Nil)
+			err = execErr
+		}
+		execResult.setReply(newReply(val, err))
+
+		stmtResult := commandStatementResult(stmt, execResult)
+		stmtResult.ExecutionTime = elapsed
+		results = append(results, stmtResult)
+	}
+	return results
+}
+
+// runTransactionStatement ejecuta un bloque MULTI/EXEC del programa
+func (c *Client) runTransactionStatement(ctx context.Context, stmt programStatement) StatementResult {
+	start := time.Now()
+	tx := TransactionResult{Results: []ExecutionResult{}, Validation: stmt.validations}
+
+	// Los comandos encolados van después de MULTI en la validación
+	queued := stmt.validations[1 : 1+len(stmt.block.Commands)]
+	c.executeTransaction(ctx, stmt.block, stmt.watchKeys, queued, &tx)
+	tx.ExecutionTime = time.Since(start)
+
+	return StatementResult{
+		Statement:   stmt.text,
+		Line:        stmt.line,
+		Success:     tx.Success,
+		Error:       tx.Error,
+		Err:         transactionErr(tx),
+		Transaction: &tx,
+	}
+}
+
+// commandStatementResult construye el resultado de un comando simple
+func commandStatementResult(stmt programStatement, execResult ExecutionResult) StatementResult {
+	return StatementResult{
+		Statement: stmt.text,
+		Line:      stmt.line,
+		Success:   execResult.Success,
+		Error:     execResult.Error,
+		Err:       execResult.Err,
+		Command:   &execResult,
+	}
+}
+
+// transactionErr devuelve el error de red del bloque o, si no lo hay, el del
+// primer comando encolado que lo tenga
+func transactionErr(tx TransactionResult) error {
+	if tx.Err != nil {
+		return tx.Err
+	}
+	for _, result := range tx.Results {
+		if result.Err != nil {
+			return result.Err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package redis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ReplyType identifica el tipo de una respuesta de Redis
+type ReplyType string
+
+const (
+	ReplyStatus  ReplyType = "status"
+	ReplyError   ReplyType = "error"
+	ReplyInteger ReplyType = "integer"
+	ReplyBulk    ReplyType = "bulk"
+	ReplyArray   ReplyType = "array"
+	ReplyNil     ReplyType = "nil"
+)
+
+// Reply representa una respuesta de Redis como un árbol tipado.
+// Str se usa en respuestas status, error y bulk; Integer en respuestas
+// integer y Elements en respuestas array.
+type Reply struct {
+	Type     ReplyType
+	Str      string
+	Integer  int64
+	Elements []*Reply
+}
+
+// Value convierte la respuesta en un valor Go simple (string, int64,
+// []interface{} o nil) para serializarla sin información de tipo
+func (r *Reply) Value() interface{} {
+	if r == nil {
+		return nil
+	}
+	switch r.Type {
+	case ReplyInteger:
+		return r.Integer
+	case ReplyArray:
+		values := make([]interface{}, len(r.Elements))
+		for i, elem := range r.Elements {
+			values[i] = elem.Value()
+		}
+		return values
+	case ReplyNil:
+		return nil
+	default:
+		return r.Str
+	}
+}
+
+// String devuelve una representación similar a la de redis-cli
+func (r *Reply) String() string {
+	if r == nil {
+		return "(nil)"
+	}
+	switch r.Type {
+	case ReplyStatus:
+		return r.Str
+	case ReplyError:
+		return "(error) " + r.Str
+	case ReplyInteger:
+		return fmt.Sprintf("(integer) %d", r.Integer)
+	case ReplyBulk:
+		return strconv.Quote(r.Str)
+	case ReplyArray:
+		if len(r.Elements) == 0 {
+			return "(empty array)"
+		}
+		result := ""
+		for i, elem := range r.Elements {
+			if i > 0 {
+				result += "\n"
+			}
+			result += fmt.Sprintf("%d) %s", i+1, elem.String())
+		}
+		return result
+	default:
+		return "(nil)"
+	}
+}
+
+// MarshalJSON codifica la respuesta como {"type": ..., "value": ...}
+func (r *Reply) MarshalJSON() ([]byte, error) {
+	out := struct {
+		Type  ReplyType   `json:"type"`
+		Value interface{} `json:"value"`
+	}{Type: r.Type}
+
+	switch r.Type {
+	case ReplyInteger:
+		out.Value = r.Integer
+	case ReplyArray:
+		elements := r.Elements
+		if elements == nil {
+			elements = []*Reply{}
+		}
+		out.Value = elements
+	case ReplyNil:
+		out.Value = nil
+	default:
+		out.Value = r.Str
+	}
+
+	return json.Marshal(out)
+}
+
+// statusReply crea una respuesta de tipo status
+func statusReply(s string) *Reply {
+	return &Reply{Type: ReplyStatus, Str: s}
+}
+
+// integerReply crea una respuesta de tipo integer
+func integerReply(n int64) *Reply {
+	return &Reply{Type: ReplyInteger, Integer: n}
+}
+
+// bulkReply crea una respuesta de tipo bulk
+func bulkReply(s string) *Reply {
+	return &Reply{Type: ReplyBulk, Str: s}
+}
+
+// nilReply crea una respuesta nula
+func nilReply() *Reply {
+	return &Reply{Type: ReplyNil}
+}
+
+// newReply convierte el resultado de un redis.Cmd genérico en un Reply.
+// go-redis no distingue entre respuestas status y bulk, por lo que las
+// cadenas se devuelven como bulk; los tipos de RESP3 se aplanan a sus
+// equivalentes de RESP2. Los errores de Redis se convierten en respuestas
+// de tipo error y el resto de errores (red, timeouts) se devuelven tal cual.
+func newReply(val interface{}, err error) (*Reply, error) {
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nilReply(), nil
+		}
+		var redisErr redis.Error
+		if errors.As(err, &redisErr) {
+			return &Reply{Type: ReplyError, Str: redisErr.Error()}, nil
+		}
+		return nil, wrapTimeout(err)
+	}
+	return replyFromValue(val), nil
+}
+
+// replyFromValue convierte un valor devuelto por go-redis en un Reply
+func replyFromValue(val interface{}) *Reply {
+	switch v := val.(type) {
+	case nil:
+		return nilReply()
+	case redis.Error:
+		return &Reply{Type: ReplyError, Str: v.Error()}
+	case string:
+		return bulkReply(v)
+	case int64:
+		return integerReply(v)
+	case bool:
+		if v {
+			return integerReply(1)
+		}
+		return integerReply(0)
+	case float64:
+		return bulkReply(strconv.FormatFloat(v, 'f', -1, 64))
+	case *big.Int:
+		return bulkReply(v.String())
+	case []interface{}:
+		elements := make([]*Reply, len(v))
+		for i, elem := range v {
+			elements[i] = replyFromValue(elem)
+		}
+		return &Reply{Type: ReplyArray, Elements: elements}
+	case map[interface{}]interface{}:
+		// Aplanar el mapa como en RESP2 (clave, valor, clave, valor...)
+		keys := make([]interface{}, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+		elements := make([]*Reply, 0, 2*len(v))
+		for _, k := range keys {
+			elements = append(elements, replyFromValue(k), replyFromValue(v[k]))
+		}
+		return &Reply{Type: ReplyArray, Elements: elements}
+	default:
+		return bulkReply(fmt.Sprint(v))
+	}
+}
@@ -0,0 +1,173 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"redis-analyzer-api/parser"
+	"redis-analyzer-api/semantic"
+)
+
+// TransactionResult contiene el resultado de ejecutar un bloque MULTI/EXEC
+type TransactionResult struct {
+	Success       bool
+	Results       []ExecutionResult // un resultado por comando encolado
+	Error         string
+	Discarded     bool // el bloque termina con DISCARD y no se envió a Redis
+	Aborted       bool // una clave vigilada con WATCH cambió antes de EXEC
+	ExecutionTime time.Duration
+	Validation    []semantic.ValidationResult
+	Err           error // error de red o *TimeoutError; nil si Redis respondió
+}
+
+// ExecuteTransaction analiza y ejecuta un bloque MULTI/EXEC de forma atómica.
+// La entrada puede empezar con comandos WATCH, cuyas claves se vigilan
+// durante la transacción, seguidos de un único bloque MULTI ... EXEC.
+func (c *Client) ExecuteTransaction(ctx context.Context, input string) TransactionResult {
+	start := time.Now()
+
+	result := TransactionResult{Results: []ExecutionResult{}}
+
+	program, parseErrors := parser.ParseCommands(input)
+	if len(parseErrors) > 0 {
+		result.Error = fmt.Sprintf("Parse errors: %v", parseErrors)
+		result.ExecutionTime = time.Since(start)
+		return result
+	}
+
+	// Validar semánticamente todo el programa, incluido el bloque
+	result.Validation = c.analyzer.ValidateProgram(program)
+	c.checkProgramPolicy(program, result.Validation)
+	for _, validation := range result.Validation {
+		if !validation.Valid {
+			result.Error = fmt.Sprintf("Semantic errors: %v", validation.Errors)
+			result.ExecutionTime = time.Since(start)
+			return result
+		}
+	}
+
+	block, watchKeys, err := c.transactionParts(program)
+	if err != nil {
+		result.Error = err.Error()
+		result.ExecutionTime = time.Since(start)
+		return result
+	}
+
+	// Los resultados de validación de los comandos encolados van después de
+	// los WATCH y del propio MULTI
+	offset := len(program.Statements)
+	queued := result.Validation[offset : offset+len(block.Commands)]
+	c.executeTransaction(ctx, block, watchKeys, queued, &result)
+
+	result.ExecutionTime = time.Since(start)
+	return result
+}
+
+// transactionParts separa los WATCH iniciales del bloque de transacción
+func (c *Client) transactionParts(program *parser.Program) (*parser.TransactionBlock, []string, error) {
+	var block *parser.TransactionBlock
+	watchKeys := []string{}
+
+	for _, stmt := range program.Statements {
+		if block != nil {
+			return nil, nil, fmt.Errorf("unexpected statement after EXEC: %s", stmt.String())
+		}
+
+		switch s := stmt.(type) {
+		case *parser.TransactionBlock:
+			block = s
+		case *parser.RedisCommand:
+			if !strings.EqualFold(s.Command.Value, "WATCH") {
+				return nil, nil, fmt.Errorf("expected WATCH or MULTI, got %s", strings.ToUpper(s.Command.Value))
+			}
+			for _, arg := range s.Arguments {
+				watchKeys = append(watchKeys, c.extractStringValue(arg))
+			}
+		}
+	}
+
+	if block == nil {
+		return nil, nil, fmt.Errorf("no MULTI/EXEC block found")
+	}
+	return block, watchKeys, nil
+}
+
+// executeTransaction envía los comandos encolados del bloque en un
+// TxPipeline de go-redis, vigilando las claves indicadas, y rellena un
+// resultado por comando. Si el bloque termina con DISCARD no se envía nada.
+func (c *Client) executeTransaction(ctx context.Context, block *parser.TransactionBlock, watchKeys []string, validations []semantic.ValidationResult, result *TransactionResult) {
+	if block.IsDiscarded() {
+		result.Discarded = true
+		result.Success = true
+		return
+	}
+
+	start := time.Now()
+	cmds := make([]*redis.Cmd, 0, len(block.Commands))
+	queue := func(pipe redis.Pipeliner) error {
+		for _, cmd := range block.Commands {
+			cmds = append(cmds, pipe.Do(ctx, c.commandArgs(cmd)...))
+		}
+		return nil
+	}
+
+	var err error
+	if len(watchKeys) == 0 {
+		// Sin WATCH no hace falta una conexión dedicada; además, en Redis
+		// Cluster Watch exige al menos una clave
+		_, err = c.rdb.TxPipelined(ctx, queue)
+	} else {
+		err = c.rdb.Watch(ctx, func(tx *redis.Tx) error {
+			_, err := tx.TxPipelined(ctx, queue)
+			return err
+		}, watchKeys...)
+	}
+
+	if errors.Is(err, redis.TxFailedErr) {
+		result.Aborted = true
+		result.Error = "transaction aborted: a watched key was modified"
+		return
+	}
+
+	// Los comandos se ejecutan juntos en EXEC; cada uno recibe el tiempo total
+	elapsed := time.Since(start)
+	result.Success = true
+	for i, cmd := range block.Commands {
+		execResult := ExecutionResult{Command: cmd.String(), ExecutionTime: elapsed}
+		if i < len(validations) {
+			execResult.Validation = &validations[i]
+		}
+
+		if i < len(cmds) {
+			execResult.setReply(newReply(cmds[i].Result()))
+		} else {
+			execResult.Error = "command was not executed"
+		}
+
+		if !execResult.Success {
+			result.Success = false
+		}
+		result.Results = append(result.Results, execResult)
+	}
+
+	// Errores de red o de conexión que no pertenecen a ningún comando
+	if err != nil && result.Error == "" && !isRedisReplyError(err) {
+		result.Err = wrapTimeout(err)
+		result.Error = result.Err.Error()
+		result.Success = false
+	}
+}
+
+// isRedisReplyError indica si el error es una respuesta de error de Redis o
+// un nil, que ya se reportan en el resultado del comando correspondiente
+func isRedisReplyError(err error) bool {
+	if errors.Is(err, redis.Nil) {
+		return true
+	}
+	var redisErr redis.Error
+	return errors.As(err, &redisErr)
+}
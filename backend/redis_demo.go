@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -18,10 +19,11 @@ func main() {
 	}
 	
 	client := redis.NewClient(config)
+	ctx := context.Background()
 	
 	// Conectar a Redis
 	fmt.Println("Conectando a Redis...")
-	if err := client.Connect(); err != nil {
+	if err := client.Connect(ctx); err != nil {
 		fmt.Printf("❌ Error conectando a Redis: %v\n", err)
 		fmt.Println("Asegúrate de que Redis esté ejecutándose en localhost:6379")
 		return
@@ -31,7 +33,7 @@ func main() {
 	
 	// Obtener información de la base de datos
 	fmt.Println("=== Información de la Base de Datos ===")
-	dbInfo, err := client.GetDatabaseInfo()
+	dbInfo, err := client.GetDatabaseInfo(ctx)
 	if err != nil {
 		fmt.Printf("Error obteniendo información: %v\n", err)
 	} else {
@@ -76,7 +78,7 @@ func main() {
 		fmt.Println(strings.Repeat("-", 50))
 		
 		// Ejecutar comando
-		result := client.ExecuteCommand(cmd)
+		result := client.ExecuteCommand(ctx, cmd)
 		
 		// Mostrar resultado
 		if result.Success {
@@ -115,7 +117,7 @@ func main() {
 	
 	// Listar claves
 	fmt.Println("Listando claves que empiezan con 'usuario:'")
-	keys, err := client.ListKeys("usuario:*", 10)
+	keys, err := client.ListKeys(ctx, "usuario:*", 10)
 	if err != nil {
 		fmt.Printf("Error listando claves: %v\n", err)
 	} else {
@@ -126,7 +128,7 @@ func main() {
 	if len(keys) > 0 {
 		key := keys[0]
 		fmt.Printf("\nInformación de la clave '%s':\n", key)
-		keyInfo, err := client.GetKeyInfo(key)
+		keyInfo, err := client.GetKeyInfo(ctx, key)
 		if err != nil {
 			fmt.Printf("Error obteniendo información: %v\n", err)
 		} else {
@@ -143,7 +145,7 @@ func main() {
 	
 	successCount := 0
 	for i := 0; i < iterations; i++ {
-		result := client.ExecuteCommand(fmt.Sprintf(`SET perf:key%d "value%d"`, i, i))
+		result := client.ExecuteCommand(ctx, fmt.Sprintf(`SET perf:key%d "value%d"`, i, i))
 		if result.Success {
 			successCount++
 		}
@@ -166,7 +168,7 @@ func main() {
 	}
 	
 	for _, cmd := range cleanupCommands {
-		result := client.ExecuteCommand(cmd)
+		result := client.ExecuteCommand(ctx, cmd)
 		if result.Success {
 			fmt.Printf("✅ %s\n", cmd)
 		} else {
@@ -176,7 +178,7 @@ func main() {
 	
 	// Limpiar claves de rendimiento
 	for i := 0; i < iterations; i++ {
-		client.ExecuteCommand(fmt.Sprintf(`DEL perf:key%d`, i))
+		client.ExecuteCommand(ctx, fmt.Sprintf(`DEL perf:key%d`, i))
 	}
 	
 	fmt.Println("\n🎉 Demostración completada exitosamente!")
@@ -0,0 +1,244 @@
+package repl
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"redis-analyzer-api/parser"
+	"redis-analyzer-api/semantic"
+)
+
+// word es una palabra de la línea, como rango [start, end) de runas
+type word struct {
+	start, end int
+}
+
+// splitWords separa la línea en palabras respetando las comillas simples y
+// dobles. open indica que la última palabra tiene una comilla sin cerrar o
+// que la línea es un comentario #, donde no hay nada que completar. En el
+// resto de la línea # es un carácter más, como en ParseCommand.
+func splitWords(line []rune) (words []word, open bool) {
+	i := 0
+	for i < len(line) {
+		if unicode.IsSpace(line[i]) {
+			i++
+			continue
+		}
+
+		if line[i] == '#' && len(words) == 0 {
+			return words, true
+		}
+
+		w := word{start: i}
+		var quote rune
+		for i < len(line) && (quote != 0 || !unicode.IsSpace(line[i])) {
+			switch r := line[i]; {
+			case quote == '"' && r == '\\':
+				i++ // el carácter escapado no cierra la comilla
+			case quote != 0 && r == quote:
+				quote = 0
+			case quote == 0 && (r == '"' || r == '\''):
+				quote = r
+			}
+			i++
+		}
+		if i > len(line) {
+			i = len(line)
+		}
+		w.end = i
+		words = append(words, w)
+		open = quote != 0
+	}
+	return words, open
+}
+
+// Complete devuelve las palabras que pueden completar la que está bajo el
+// cursor y la posición (en runas) en la que empieza esa palabra. Completa
+// nombres de comando, subcomandos y los tokens de opción que la gramática
+// admite tras los argumentos ya escritos.
+func (r *REPL) Complete(line []rune, pos int) ([]string, int) {
+	text := line[:pos]
+	words, open := splitWords(text)
+	if open {
+		return nil, pos
+	}
+
+	start := pos
+	previous := words
+	if len(words) > 0 && words[len(words)-1].end == pos {
+		start = words[len(words)-1].start
+		previous = words[:len(words)-1]
+	}
+	prefix := string(text[start:pos])
+
+	specs := r.analyzer.GetCommandSpecs()
+	var container semantic.CommandSpec
+	if len(previous) == 1 {
+		container = specs[strings.ToUpper(string(text[previous[0].start:previous[0].end]))]
+	}
+
+	var options []string
+	switch {
+	case len(previous) == 0:
+		for name := range specs {
+			if !strings.Contains(name, " ") {
+				options = append(options, name)
+			}
+		}
+	case len(container.Subcommands) > 0:
+		options = container.Subcommands
+	default:
+		cmd, parseErrors := parser.ParseCommand(string(text[:start]))
+		if len(parseErrors) > 0 || cmd == nil {
+			return nil, start
+		}
+		for _, next := range r.analyzer.NextArguments(cmd) {
+			if next.IsToken {
+				options = append(options, next.Element.Token)
+			}
+		}
+	}
+
+	return matchPrefix(options, prefix), start
+}
+
+// matchPrefix filtra las opciones que empiezan por prefix, sin distinguir
+// mayúsculas, y las devuelve ordenadas en minúsculas si el prefijo lo está
+func matchPrefix(options []string, prefix string) []string {
+	upper := strings.ToUpper(prefix)
+	lower := prefix != "" && prefix == strings.ToLower(prefix) && prefix != upper
+
+	seen := map[string]bool{}
+	matches := []string{}
+	for _, option := range options {
+		option = strings.ToUpper(option)
+		if !strings.HasPrefix(option, upper) || seen[option] {
+			continue
+		}
+		seen[option] = true
+		if lower {
+			option = strings.ToLower(option)
+		}
+		matches = append(matches, option)
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+// Hint devuelve el texto que se muestra atenuado tras la línea: el primer
+// error ya detectable en lo escrito o, si no lo hay, los argumentos que
+// pueden seguir. isError indica que el texto es un error. Los errores de la
+// palabra que se está escribiendo y los de argumentos que aún faltan no se
+// muestran hasta que se pulsa Enter.
+func (r *REPL) Hint(line []rune) (hint string, isError bool) {
+	words, open := splitWords(line)
+	if open || len(words) == 0 {
+		return "", false
+	}
+
+	trailing := words[len(words)-1].end < len(line)
+	limit := len(string(line))
+	if !trailing {
+		limit = len(string(line[:words[len(words)-1].start]))
+	}
+
+	cmd, diagnostics := parser.ParseCommandWithDiagnostics(string(line))
+	if cmd == nil {
+		return "", false
+	}
+	validation := r.validate(cmd)
+	diagnostics = append(diagnostics, validation.Diagnostics...)
+	for _, d := range diagnostics {
+		if d.Severity != parser.SeverityError || d.Span.End.Offset > limit {
+			continue
+		}
+		if d.Code == "INSUFFICIENT_ARGS" || d.Code == "MISSING_OPTION_VALUE" {
+			continue
+		}
+		return d.Message, true
+	}
+
+	specs := r.analyzer.GetCommandSpecs()
+	spec, exists := specs[strings.ToUpper(cmd.Command.Value)]
+	if !exists || (!trailing && len(words) > 1) {
+		return "", false
+	}
+
+	if len(cmd.Arguments) == 0 && len(spec.Subcommands) > 0 {
+		subcommands := matchPrefix(spec.Subcommands, "")
+		hint = strings.Join(subcommands, " | ")
+	} else {
+		parts := []string{}
+		for _, next := range r.analyzer.NextArguments(cmd) {
+			text := next.Element.Name
+			if next.IsToken {
+				text = argumentSyntax(*next.Element, true)
+			}
+			if next.Optional {
+				text = "[" + text + "]"
+			}
+			parts = append(parts, text)
+		}
+		hint = strings.Join(parts, " ")
+	}
+
+	if hint != "" && !trailing {
+		hint = " " + hint
+	}
+	return hint, false
+}
+
+// Synopsis devuelve la sintaxis de un comando al estilo de la documentación
+// de Redis, p.ej. "GETEX key [EX seconds | PX milliseconds | PERSIST]"
+func Synopsis(spec semantic.CommandSpec) string {
+	parts := []string{spec.Name}
+	for _, arg := range spec.Arguments {
+		parts = append(parts, argumentSyntax(arg, false))
+	}
+	if len(spec.Arguments) == 0 && len(spec.Subcommands) > 0 {
+		parts = append(parts, "<"+strings.Join(matchPrefix(spec.Subcommands, ""), " | ")+">")
+	}
+	return strings.Join(parts, " ")
+}
+
+// argumentSyntax describe un argumento de la gramática. Con bare no se
+// añaden los corchetes de los argumentos opcionales.
+func argumentSyntax(arg semantic.ArgumentSpec, bare bool) string {
+	var value string
+	switch arg.Type {
+	case "pure-token":
+	case "oneof":
+		alternatives := make([]string, len(arg.Arguments))
+		for i, alt := range arg.Arguments {
+			alternatives[i] = argumentSyntax(alt, true)
+		}
+		value = strings.Join(alternatives, " | ")
+		if !arg.Optional && arg.Token == "" {
+			value = "<" + value + ">"
+		}
+	case "block":
+		items := make([]string, len(arg.Arguments))
+		for i, item := range arg.Arguments {
+			items[i] = argumentSyntax(item, false)
+		}
+		value = strings.Join(items, " ")
+	default:
+		value = arg.Name
+	}
+
+	if arg.Multiple && !arg.MultipleToken {
+		value += " [" + value + " ...]"
+	}
+	syntax := value
+	if arg.Token != "" {
+		syntax = strings.TrimSpace(arg.Token + " " + value)
+	}
+	if arg.Multiple && arg.MultipleToken {
+		syntax += " [" + syntax + " ...]"
+	}
+	if arg.Optional && !bare {
+		syntax = "[" + syntax + "]"
+	}
+	return syntax
+}
@@ -0,0 +1,273 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// errInterrupted indica que se pulsó Ctrl-C mientras se editaba la línea
+var errInterrupted = errors.New("interrupted")
+
+// editor lee líneas de un terminal en modo raw con edición, historial,
+// autocompletado con Tab y una pista atenuada tras el cursor
+type editor struct {
+	in       *bufio.Reader
+	out      io.Writer
+	width    func() int
+	history  *history
+	complete func(line []rune, pos int) ([]string, int)
+	hint     func(line []rune) (string, bool)
+
+	prompt string
+	line   []rune
+	pos    int
+}
+
+// readLine edita una línea hasta Enter. Devuelve io.EOF con Ctrl-D en una
+// línea vacía y errInterrupted con Ctrl-C.
+func (e *editor) readLine(prompt string) (string, error) {
+	e.prompt = prompt
+	e.line = nil
+	e.pos = 0
+
+	// Posición en el historial; len(entries) es la línea en edición
+	index := len(e.history.entries)
+	current := ""
+
+	e.refresh(true)
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch r {
+		case '\r', '\n':
+			e.pos = len(e.line)
+			e.refresh(false)
+			fmt.Fprint(e.out, "\n")
+			return string(e.line), nil
+		case 3: // Ctrl-C
+			fmt.Fprint(e.out, "^C\n")
+			return "", errInterrupted
+		case 4: // Ctrl-D
+			if len(e.line) == 0 {
+				fmt.Fprint(e.out, "\n")
+				return "", io.EOF
+			}
+			e.deleteAt(e.pos)
+		case 127, 8: // Backspace
+			if e.pos > 0 {
+				e.pos--
+				e.deleteAt(e.pos)
+			}
+		case '\t':
+			e.completeWord()
+		case 1: // Ctrl-A
+			e.pos = 0
+		case 5: // Ctrl-E
+			e.pos = len(e.line)
+		case 2: // Ctrl-B
+			e.move(-1)
+		case 6: // Ctrl-F
+			e.move(1)
+		case 11: // Ctrl-K
+			e.line = e.line[:e.pos]
+		case 21: // Ctrl-U
+			e.line = append([]rune{}, e.line[e.pos:]...)
+			e.pos = 0
+		case 23: // Ctrl-W
+			e.deleteWord()
+		case 12: // Ctrl-L
+			fmt.Fprint(e.out, "\x1b[H\x1b[2J")
+		case 16, 14: // Ctrl-P, Ctrl-N
+			index, current = e.browse(index, current, r == 16)
+		case 27:
+			switch e.readEscape() {
+			case "A":
+				index, current = e.browse(index, current, true)
+			case "B":
+				index, current = e.browse(index, current, false)
+			case "C":
+				e.move(1)
+			case "D":
+				e.move(-1)
+			case "H", "1~", "7~":
+				e.pos = 0
+			case "F", "4~", "8~":
+				e.pos = len(e.line)
+			case "3~":
+				e.deleteAt(e.pos)
+			}
+		default:
+			if r < 32 {
+				continue
+			}
+			e.line = append(e.line[:e.pos], append([]rune{r}, e.line[e.pos:]...)...)
+			e.pos++
+		}
+		e.refresh(true)
+	}
+}
+
+// readEscape lee el resto de una secuencia de escape (ESC [ ... o ESC O ...)
+// y devuelve su parte final, p.ej. "A" para la flecha arriba o "3~" para
+// Supr
+func (e *editor) readEscape() string {
+	r, _, err := e.in.ReadRune()
+	if err != nil || (r != '[' && r != 'O') {
+		return ""
+	}
+
+	var seq strings.Builder
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return ""
+		}
+		seq.WriteRune(r)
+		if r < '0' || r > '9' {
+			return seq.String()
+		}
+	}
+}
+
+func (e *editor) move(delta int) {
+	if pos := e.pos + delta; pos >= 0 && pos <= len(e.line) {
+		e.pos = pos
+	}
+}
+
+func (e *editor) deleteAt(pos int) {
+	if pos < len(e.line) {
+		e.line = append(e.line[:pos], e.line[pos+1:]...)
+	}
+}
+
+// deleteWord borra la palabra anterior al cursor
+func (e *editor) deleteWord() {
+	start := e.pos
+	for start > 0 && e.line[start-1] == ' ' {
+		start--
+	}
+	for start > 0 && e.line[start-1] != ' ' {
+		start--
+	}
+	e.line = append(e.line[:start], e.line[e.pos:]...)
+	e.pos = start
+}
+
+// browse recorre el historial; current guarda la línea que se estaba
+// editando para recuperarla al volver al final
+func (e *editor) browse(index int, current string, back bool) (int, string) {
+	entries := e.history.entries
+	if index == len(entries) {
+		current = string(e.line)
+	}
+	switch {
+	case back && index > 0:
+		index--
+	case !back && index < len(entries):
+		index++
+	default:
+		return index, current
+	}
+
+	if index == len(entries) {
+		e.line = []rune(current)
+	} else {
+		e.line = []rune(entries[index])
+	}
+	e.pos = len(e.line)
+	return index, current
+}
+
+// completeWord completa la palabra bajo el cursor. Con una única opción la
+// inserta completa; con varias inserta su prefijo común y, si no avanza,
+// las lista bajo la línea.
+func (e *editor) completeWord() {
+	options, start := e.complete(e.line, e.pos)
+	if len(options) == 0 {
+		fmt.Fprint(e.out, "\a")
+		return
+	}
+
+	replacement := options[0]
+	if len(options) == 1 {
+		if e.pos == len(e.line) {
+			replacement += " "
+		}
+	} else {
+		replacement = commonPrefix(options)
+		if utf8.RuneCountInString(replacement) <= e.pos-start {
+			fmt.Fprintf(e.out, "\n%s\n", strings.Join(options, "  "))
+			return
+		}
+	}
+
+	rest := append([]rune(replacement), e.line[e.pos:]...)
+	e.line = append(e.line[:start], rest...)
+	e.pos = start + utf8.RuneCountInString(replacement)
+}
+
+// refresh vuelve a dibujar la línea. Si no cabe en el terminal se muestra
+// la parte alrededor del cursor; la pista solo se muestra con el cursor al
+// final y si cabe.
+func (e *editor) refresh(withHint bool) {
+	promptWidth := utf8.RuneCountInString(e.prompt)
+	available := e.width() - promptWidth - 1
+	if available < 1 {
+		available = 1
+	}
+
+	offset := 0
+	if e.pos > available {
+		offset = e.pos - available
+	}
+	visible := e.line[offset:]
+	if len(visible) > available {
+		visible = visible[:available]
+	}
+
+	var b strings.Builder
+	b.WriteString("\r" + e.prompt + string(visible))
+	if withHint && e.pos == len(e.line) {
+		if hint, isError := e.hint(e.line); hint != "" {
+			room := available - len(visible)
+			if isError {
+				hint = "  ← " + hint
+			}
+			if room > 1 {
+				if runes := []rune(hint); len(runes) > room {
+					hint = string(runes[:room-1]) + "…"
+				}
+				color := colorDim
+				if isError {
+					color = colorRed
+				}
+				b.WriteString(color + hint + colorReset)
+			}
+		}
+	}
+	b.WriteString("\x1b[K\r")
+	if column := promptWidth + e.pos - offset; column > 0 {
+		fmt.Fprintf(&b, "\x1b[%dC", column)
+	}
+	fmt.Fprint(e.out, b.String())
+}
+
+// commonPrefix devuelve el prefijo común de las opciones
+func commonPrefix(options []string) string {
+	prefix := options[0]
+	for _, option := range options[1:] {
+		for !strings.HasPrefix(option, prefix) {
+			_, size := utf8.DecodeLastRuneInString(prefix)
+			prefix = prefix[:len(prefix)-size]
+		}
+	}
+	return prefix
+}
@@ -0,0 +1,111 @@
+package repl
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"redis-analyzer-api/parser"
+	"redis-analyzer-api/redis"
+)
+
+// Secuencias ANSI usadas en los terminales
+const (
+	colorReset  = "\x1b[0m"
+	colorRed    = "\x1b[31m"
+	colorYellow = "\x1b[33m"
+	colorDim    = "\x1b[90m"
+)
+
+// FormatReply devuelve la respuesta como la muestra redis-cli, con los
+// arrays anidados numerados y sangrados
+func FormatReply(reply *redis.Reply) string {
+	var b strings.Builder
+	writeReply(&b, reply, 0)
+	return b.String()
+}
+
+func writeReply(b *strings.Builder, reply *redis.Reply, indent int) {
+	if reply == nil || reply.Type != redis.ReplyArray || len(reply.Elements) == 0 {
+		b.WriteString(reply.String())
+		return
+	}
+
+	width := len(fmt.Sprint(len(reply.Elements)))
+	for i, elem := range reply.Elements {
+		if i > 0 {
+			b.WriteString("\n" + strings.Repeat(" ", indent))
+		}
+		label := fmt.Sprintf("%*d) ", width, i+1)
+		b.WriteString(label)
+		writeReply(b, elem, indent+len(label))
+	}
+}
+
+// formatResult devuelve el resultado de un comando: su respuesta o el error
+// que impidió obtenerla
+func formatResult(result redis.ExecutionResult) string {
+	switch {
+	case result.Err != nil:
+		return "(error) " + result.Err.Error()
+	case result.Reply != nil:
+		return FormatReply(result.Reply)
+	default:
+		return "(error) " + result.Error
+	}
+}
+
+// formatTransaction devuelve el resultado de EXEC: un array con la
+// respuesta de cada comando encolado, o (nil) si WATCH abortó la transacción
+func formatTransaction(result redis.TransactionResult) string {
+	switch {
+	case result.Err != nil:
+		return "(error) " + result.Err.Error()
+	case result.Discarded:
+		return "OK"
+	case result.Aborted:
+		return "(nil)"
+	case len(result.Results) == 0 && result.Error != "":
+		return "(error) " + result.Error
+	}
+
+	replies := &redis.Reply{Type: redis.ReplyArray}
+	for _, cmd := range result.Results {
+		reply := cmd.Reply
+		if reply == nil {
+			reply = &redis.Reply{Type: redis.ReplyError, Str: cmd.Error}
+		}
+		replies.Elements = append(replies.Elements, reply)
+	}
+	return FormatReply(replies)
+}
+
+// FormatDiagnostic describe un diagnóstico y marca su posición en la línea
+// con ^ bajo el rango afectado:
+//
+//	(error) Missing required argument 'value' (string) [INSUFFICIENT_ARGS]
+//	  SET k
+//	       ^
+func FormatDiagnostic(line string, d parser.Diagnostic, color bool) string {
+	label := fmt.Sprintf("(%s) %s [%s]", d.Severity, d.Message, d.Code)
+	if color {
+		switch d.Severity {
+		case parser.SeverityError:
+			label = colorRed + label + colorReset
+		case parser.SeverityWarning:
+			label = colorYellow + label + colorReset
+		}
+	}
+
+	start, end := d.Span.Start.Offset, d.Span.End.Offset
+	if d.Span.IsZero() || strings.Contains(line, "\n") || start > len(line) || end > len(line) || end < start {
+		return label
+	}
+
+	column := utf8.RuneCountInString(line[:start])
+	width := utf8.RuneCountInString(line[start:end])
+	if width == 0 {
+		width = 1
+	}
+	return fmt.Sprintf("%s\n  %s\n  %s%s", label, line, strings.Repeat(" ", column), strings.Repeat("^", width))
+}
@@ -0,0 +1,81 @@
+package repl
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// history contiene las líneas introducidas, de la más antigua a la más
+// reciente, y las añade al archivo de historial si se configuró uno
+type history struct {
+	entries []string
+	path    string
+	max     int
+}
+
+// loadHistory lee el archivo de historial conservando las últimas max
+// líneas, y lo recorta si tiene más. Un archivo inexistente no es un
+// error: se crea al añadir la primera línea.
+func loadHistory(path string, max int) (*history, error) {
+	h := &history{path: path, max: max}
+	if path == "" {
+		return h, nil
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return h, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			h.entries = append(h.entries, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return h, err
+	}
+
+	// Recortar el archivo para que no crezca sin límite
+	if len(h.entries) > max {
+		h.entries = h.entries[len(h.entries)-max:]
+		return h, os.WriteFile(path, []byte(strings.Join(h.entries, "\n")+"\n"), 0600)
+	}
+	return h, nil
+}
+
+// add añade una línea al historial, salvo que repita la anterior o sea un
+// AUTH, cuya contraseña no debe quedar en el archivo
+func (h *history) add(line string) error {
+	line = strings.TrimSpace(line)
+	if line == "" || (len(h.entries) > 0 && h.entries[len(h.entries)-1] == line) {
+		return nil
+	}
+	if fields := strings.Fields(line); strings.EqualFold(fields[0], "AUTH") {
+		return nil
+	}
+
+	h.entries = append(h.entries, line)
+	if len(h.entries) > h.max {
+		h.entries = h.entries[len(h.entries)-h.max:]
+	}
+	if h.path == "" {
+		return nil
+	}
+
+	file, err := os.OpenFile(h.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(line + "\n"); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,276 @@
+package repl
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"redis-analyzer-api/parser"
+	"redis-analyzer-api/redis"
+	"redis-analyzer-api/semantic"
+)
+
+// DefaultHistorySize es el número de líneas de historial que se conservan
+const DefaultHistorySize = 1000
+
+// Config configura una sesión interactiva
+type Config struct {
+	Prompt      string        // prefijo del prompt, p.ej. "127.0.0.1:6379"
+	HistoryFile string        // archivo de historial persistente; vacío lo desactiva
+	Timeout     time.Duration // plazo de cada comando (0 sin plazo)
+}
+
+// REPL es una sesión interactiva que analiza cada comando con el lexer, el
+// parser y el analizador semántico antes de enviarlo a Redis. Los comandos
+// con errores no se envían.
+type REPL struct {
+	client   *redis.Client
+	analyzer *semantic.Analyzer
+	config   Config
+	color    bool
+
+	// Transacción en curso: los WATCH y, tras MULTI, los comandos
+	// encolados se envían juntos con ExecuteTransaction al llegar EXEC
+	queue []string
+	multi bool
+}
+
+// New crea una sesión sobre el cliente dado
+func New(client *redis.Client, config Config) *REPL {
+	return &REPL{
+		client:   client,
+		analyzer: client.Analyzer(),
+		config:   config,
+	}
+}
+
+// Run lee comandos de in hasta fin de entrada, Ctrl-D o quit. Si in es un
+// terminal la línea se edita con historial, autocompletado y análisis en
+// vivo; si no, se lee línea a línea sin prompt, lo que permite ejecutar un
+// archivo (redis-analyzer repl < comandos.txt).
+func (r *REPL) Run(ctx context.Context, in io.Reader, out io.Writer) error {
+	file, ok := in.(*os.File)
+	if !ok || !isTerminal(int(file.Fd())) {
+		return r.runLines(ctx, in, out)
+	}
+	return r.runTerminal(ctx, file, out)
+}
+
+// runLines ejecuta las líneas de una entrada que no es un terminal
+func (r *REPL) runLines(ctx context.Context, in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		if r.eval(ctx, scanner.Text(), out) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// runTerminal ejecuta la sesión interactiva sobre un terminal
+func (r *REPL) runTerminal(ctx context.Context, file *os.File, out io.Writer) error {
+	fd := int(file.Fd())
+	r.color = true
+
+	h, err := loadHistory(r.config.HistoryFile, DefaultHistorySize)
+	if err != nil {
+		fmt.Fprintf(out, "(warning) historial: %v\n", err)
+	}
+	ed := &editor{
+		in:       bufio.NewReader(file),
+		out:      out,
+		width:    func() int { return terminalWidth(fd) },
+		history:  h,
+		complete: r.Complete,
+		hint:     r.Hint,
+	}
+
+	for {
+		restore, err := makeRaw(fd)
+		if err != nil {
+			return err
+		}
+		line, err := ed.readLine(r.prompt())
+		restore()
+
+		switch {
+		case errors.Is(err, errInterrupted):
+			continue
+		case errors.Is(err, io.EOF):
+			return nil
+		case err != nil:
+			return err
+		}
+
+		if err := h.add(line); err != nil {
+			fmt.Fprintf(out, "(warning) historial: %v\n", err)
+		}
+
+		// Ctrl-C cancela el comando en curso sin cerrar la sesión
+		cmdCtx, stop := signal.NotifyContext(ctx, os.Interrupt)
+		quit := r.eval(cmdCtx, line, out)
+		stop()
+		if quit {
+			return nil
+		}
+	}
+}
+
+// Prompt devuelve el prefijo del prompt para un despliegue, como en
+// redis-cli: host:puerto y la base de datos si no es la 0
+func Prompt(config redis.Config) string {
+	switch config.Mode {
+	case redis.ModeSentinel:
+		return config.MasterName
+	case redis.ModeCluster:
+		if len(config.ClusterAddrs) > 0 {
+			return config.ClusterAddrs[0]
+		}
+		return "cluster"
+	}
+	prompt := fmt.Sprintf("%s:%d", config.Host, config.Port)
+	if config.DB != 0 {
+		prompt += fmt.Sprintf("[%d]", config.DB)
+	}
+	return prompt
+}
+
+// prompt devuelve el prompt, con (TX) dentro de una transacción
+func (r *REPL) prompt() string {
+	prompt := r.config.Prompt
+	if r.multi {
+		prompt += "(TX)"
+	}
+	return prompt + "> "
+}
+
+// eval analiza y ejecuta una línea. Devuelve true si la sesión debe terminar.
+func (r *REPL) eval(ctx context.Context, line string, out io.Writer) bool {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return false
+	}
+
+	fields := strings.Fields(line)
+	switch strings.ToLower(fields[0]) {
+	case "quit", "exit":
+		return true
+	case "help":
+		fmt.Fprintln(out, r.help(fields[1:]))
+		return false
+	}
+
+	cmd, diagnostics := parser.ParseCommandWithDiagnostics(line)
+	if len(diagnostics) == 0 && cmd != nil {
+		validation := r.validate(cmd)
+		diagnostics = validation.Diagnostics
+	}
+	valid := cmd != nil
+	for _, d := range diagnostics {
+		fmt.Fprintln(out, FormatDiagnostic(line, d, r.color))
+		if d.Severity == parser.SeverityError {
+			valid = false
+		}
+	}
+	if !valid {
+		return false
+	}
+
+	fmt.Fprintln(out, r.execute(ctx, strings.ToUpper(cmd.Command.Value), line))
+	return false
+}
+
+// validate analiza el comando y le aplica la política del cliente
+func (r *REPL) validate(cmd *parser.RedisCommand) semantic.ValidationResult {
+	validation := r.analyzer.ValidateCommand(cmd)
+	r.client.CheckPolicy(cmd, &validation)
+	return validation
+}
+
+// execute envía un comando ya validado y devuelve su salida. Los WATCH y
+// los comandos entre MULTI y EXEC se guardan y se envían juntos en EXEC,
+// ya que cada comando suelto puede usar una conexión distinta del pool.
+func (r *REPL) execute(ctx context.Context, name, line string) string {
+	switch {
+	case name == "MULTI" && r.multi:
+		return "(error) ERR MULTI calls can not be nested"
+	case name == "MULTI":
+		r.multi = true
+		r.queue = append(r.queue, line)
+		return "OK"
+	case (name == "EXEC" || name == "DISCARD") && !r.multi:
+		return fmt.Sprintf("(error) ERR %s without MULTI", name)
+	case name == "EXEC" || name == "DISCARD":
+		script := strings.Join(append(r.queue, line), "\n")
+		r.queue, r.multi = nil, false
+
+		ctx, cancel := r.timeoutContext(ctx)
+		defer cancel()
+		return formatTransaction(r.client.ExecuteTransaction(ctx, script))
+	case name == "WATCH" && r.multi:
+		return "(error) ERR WATCH inside MULTI is not allowed"
+	case name == "WATCH":
+		r.queue = append(r.queue, line)
+		return "OK"
+	case name == "UNWATCH" && !r.multi:
+		r.queue = nil
+		return "OK"
+	case r.multi:
+		r.queue = append(r.queue, line)
+		return "QUEUED"
+	}
+
+	ctx, cancel := r.timeoutContext(ctx)
+	defer cancel()
+	return formatResult(r.client.ExecuteCommand(ctx, line))
+}
+
+// timeoutContext aplica el plazo configurado a cada comando
+func (r *REPL) timeoutContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.config.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, r.config.Timeout)
+}
+
+// help devuelve la ayuda de la sesión o la sintaxis de un comando
+func (r *REPL) help(args []string) string {
+	if len(args) == 0 {
+		return strings.Join([]string{
+			"Escribe comandos Redis; se analizan antes de enviarse y no se envían si tienen errores.",
+			"  help <comando>     Sintaxis y descripción de un comando",
+			"  quit, exit         Terminar la sesión (también Ctrl-D)",
+			"  Tab                Completar comandos, subcomandos y opciones",
+			"  ↑/↓, Ctrl-P/N      Recorrer el historial",
+			"  Ctrl-C             Cancelar la línea o el comando en curso",
+		}, "\n")
+	}
+
+	specs := r.analyzer.GetCommandSpecs()
+	name := strings.ToUpper(strings.Join(args, " "))
+	spec, exists := specs[name]
+	if !exists {
+		return fmt.Sprintf("(error) Unknown command: %s", name)
+	}
+
+	lines := []string{Synopsis(spec)}
+	if spec.Description != "" {
+		lines = append(lines, "  "+spec.Description)
+	}
+	if spec.Since != "" {
+		lines = append(lines, "  since: "+spec.Since)
+	}
+	if spec.Group != "" {
+		lines = append(lines, "  group: "+spec.Group)
+	}
+	if spec.Complexity != "" {
+		lines = append(lines, "  complexity: "+spec.Complexity)
+	}
+	return strings.Join(lines, "\n")
+}
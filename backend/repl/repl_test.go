@@ -0,0 +1,227 @@
+package repl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"redis-analyzer-api/parser"
+	"redis-analyzer-api/redis"
+)
+
+// newTestREPL crea una sesión contra un puerto sin Redis: los comandos
+// válidos fallan al enviarse con un error de conexión
+func newTestREPL() *REPL {
+	return New(redis.NewClient(redis.Config{Host: "127.0.0.1", Port: 1}), Config{Prompt: "127.0.0.1:1"})
+}
+
+func TestComplete(t *testing.T) {
+	r := newTestREPL()
+
+	tests := []struct {
+		line     string
+		expected []string
+		start    int
+	}{
+		{line: "se", expected: []string{"select", "set", "setbit", "setex", "setnx", "setrange"}, start: 0},
+		{line: "SET k v e", expected: []string{"ex", "exat"}, start: 8},
+		{line: "SET k v NX ", expected: []string{"EX", "EXAT", "GET", "KEEPTTL", "PX", "PXAT"}, start: 11},
+		{line: "CLIENT K", expected: []string{"KILL"}, start: 7},
+		{line: "ZRANGE k 0 1 BY", expected: []string{"BYLEX", "BYSCORE"}, start: 13},
+		{line: "GET k ", expected: []string{}, start: 6},
+		{line: `SET "a b`, expected: nil, start: 8},
+		{line: "# SET k v e", expected: nil, start: 11},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, start := r.Complete([]rune(tt.line), len([]rune(tt.line)))
+			if strings.Join(got, ",") != strings.Join(tt.expected, ",") || start != tt.start {
+				t.Errorf("Expected %v at %d, got %v at %d", tt.expected, tt.start, got, start)
+			}
+		})
+	}
+}
+
+func TestHint(t *testing.T) {
+	r := newTestREPL()
+
+	tests := []struct {
+		line    string
+		hint    string
+		isError bool
+	}{
+		{line: "SET", hint: " key"},
+		{line: "SET k ", hint: "value"},
+		{line: "SET k v EX ", hint: "seconds"},
+		{line: "EXPIRE k 10 ", hint: "[NX] [XX] [GT] [LT]"},
+		{line: "SET k", hint: ""},
+		{line: "GE", hint: ""},
+		{line: "FOO ", hint: "Unknown command: FOO", isError: true},
+		{line: "GET k v ", hint: "Too many arguments", isError: true},
+		{line: "# SET k ", hint: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			hint, isError := r.Hint([]rune(tt.line))
+			if isError != tt.isError || !strings.HasPrefix(hint, tt.hint) || (tt.hint == "" && hint != "") {
+				t.Errorf("Expected hint %q (error=%v), got %q (error=%v)", tt.hint, tt.isError, hint, isError)
+			}
+		})
+	}
+}
+
+func TestSynopsis(t *testing.T) {
+	specs := newTestREPL().analyzer.GetCommandSpecs()
+
+	tests := map[string]string{
+		"ZADD":  "ZADD key [NX | XX] [GT | LT] [CH] [INCR] score member [score member ...]",
+		"LMPOP": "LMPOP numkeys key [key ...] <LEFT | RIGHT> [COUNT count]",
+		"SORT":  "SORT key [BY pattern] [LIMIT offset count] [GET pattern [GET pattern ...]] [ASC | DESC] [ALPHA] [STORE destination]",
+	}
+	for name, expected := range tests {
+		if got := Synopsis(specs[name]); got != expected {
+			t.Errorf("%s: expected %q, got %q", name, expected, got)
+		}
+	}
+}
+
+func TestFormatReply(t *testing.T) {
+	reply := &redis.Reply{Type: redis.ReplyArray, Elements: []*redis.Reply{
+		{Type: redis.ReplyBulk, Str: "a"},
+		{Type: redis.ReplyArray, Elements: []*redis.Reply{
+			{Type: redis.ReplyInteger, Integer: 1},
+			{Type: redis.ReplyNil},
+		}},
+		{Type: redis.ReplyArray},
+	}}
+
+	expected := "1) \"a\"\n2) 1) (integer) 1\n   2) (nil)\n3) (empty array)"
+	if got := FormatReply(reply); got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestFormatDiagnostic(t *testing.T) {
+	d := parser.Diagnostic{
+		Span:     parser.Span{Start: parser.Pos{Offset: 6, Line: 1, Column: 7}, End: parser.Pos{Offset: 7, Line: 1, Column: 8}},
+		Severity: parser.SeverityError,
+		Code:     "EXCESSIVE_ARGS",
+		Message:  "Too many arguments",
+	}
+
+	expected := "(error) Too many arguments [EXCESSIVE_ARGS]\n  GET k x\n        ^"
+	if got := FormatDiagnostic("GET k x", d, false); got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestRunLines(t *testing.T) {
+	r := newTestREPL()
+	input := strings.Join([]string{
+		"GET k x",
+		"# comentario",
+		"GET #",
+		"MULTI",
+		"SET a 1",
+		"INCR b",
+		"DISCARD",
+		"EXEC",
+		"help getex",
+		"quit",
+		"GET never",
+	}, "\n")
+
+	var out bytes.Buffer
+	if err := r.Run(context.Background(), strings.NewReader(input), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"(error) Too many arguments. Expected at most 1, got 2 [EXCESSIVE_ARGS]",
+		"  GET k x",
+		"        ^",
+		"(error) dial tcp 127.0.0.1:1: connect: connection refused",
+		"OK",
+		"QUEUED",
+		"QUEUED",
+		"OK",
+		"(error) ERR EXEC without MULTI",
+		"GETEX key [EX seconds | PX milliseconds | EXAT timestamp | PXAT milliseconds-timestamp | PERSIST]",
+	}
+	lines := strings.Split(out.String(), "\n")
+	for i, line := range expected {
+		if i >= len(lines) || lines[i] != line {
+			t.Fatalf("Unexpected output:\n%s", out.String())
+		}
+	}
+	if strings.Contains(out.String(), "never") {
+		t.Errorf("Expected quit to end the session")
+	}
+}
+
+func TestEditor(t *testing.T) {
+	r := newTestREPL()
+	h := &history{entries: []string{"GET old"}, max: 10}
+	keys := strings.Join([]string{
+		"se\t\x15",        // varias opciones: no completa nada; Ctrl-U borra
+		"st\x1b[De\x1b[F", // inserta la e que falta y vuelve al final
+		" k v n\t\r",      // completa NX
+		"\x1b[A\x1b[A\r",  // recupera la línea anterior del historial
+	}, "")
+	ed := &editor{
+		in:       bufio.NewReader(strings.NewReader(keys)),
+		out:      io.Discard,
+		width:    func() int { return 80 },
+		history:  h,
+		complete: r.Complete,
+		hint:     r.Hint,
+	}
+
+	for _, expected := range []string{"set k v nx ", "GET old"} {
+		line, err := ed.readLine("> ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line != expected {
+			t.Errorf("Expected %q, got %q", expected, line)
+		}
+		h.add(line)
+	}
+	if _, err := ed.readLine("> "); err != io.EOF {
+		t.Errorf("Expected EOF at the end of the input, got %v", err)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	h, err := loadHistory(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"GET a", "GET a", "AUTH secret", "GET b", "GET c"} {
+		if err := h.add(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "GET a\nGET b\nGET c\n" {
+		t.Errorf("Unexpected history file: %q", data)
+	}
+
+	// Al cargarlo se conservan y se reescriben las últimas líneas
+	h, err = loadHistory(path, 2)
+	if err != nil || strings.Join(h.entries, ",") != "GET b,GET c" {
+		t.Errorf("Expected the last 2 entries, got %v (%v)", h.entries, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "GET b\nGET c\n" {
+		t.Errorf("Expected the history file to be trimmed, got %q", data)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package repl
+
+import (
+	"errors"
+)
+
+// En otras plataformas no se controla el terminal: la sesión lee líneas
+// completas sin edición, autocompletado ni historial navegable
+
+func isTerminal(fd int) bool {
+	return false
+}
+
+func makeRaw(fd int) (func(), error) {
+	return nil, errors.New("raw terminal mode is not supported on this platform")
+}
+
+func terminalWidth(fd int) int {
+	return 80
+}
@@ -0,0 +1,89 @@
+package semantic
+
+import (
+	"fmt"
+
+	"redis-analyzer-api/parser"
+)
+
+// Category clasifica un comando según el acceso que necesita
+type Category string
+
+const (
+	CategoryRead  Category = "read"  // solo lee datos o el estado de la conexión
+	CategoryWrite Category = "write" // modifica datos
+	CategoryAdmin Category = "admin" // administra el servidor o no está clasificado
+)
+
+// Category clasifica el comando a partir de sus command_flags y categorías
+// ACL. Los comandos que no se pueden clasificar como lectura o escritura
+// (DEBUG, SCRIPT FLUSH, MODULE LOAD...) se consideran de administración.
+func (s CommandSpec) Category() Category {
+	switch {
+	case s.HasFlag("admin") || s.hasACLCategory("@admin"):
+		return CategoryAdmin
+	case s.HasFlag("write") || s.HasFlag("may_replicate") || s.hasACLCategory("@write"):
+		return CategoryWrite
+	case s.HasFlag("readonly") || s.hasACLCategory("@read"):
+		return CategoryRead
+	case s.hasACLCategory("@connection") || s.hasACLCategory("@transaction"):
+		// PING, SELECT, MULTI, EXEC, WATCH...
+		return CategoryRead
+	case s.HasFlag("loading") && s.HasFlag("stale"):
+		// Redis los permite mientras carga datos porque no tocan el dataset
+		// (INFO, TIME, MEMORY STATS...)
+		return CategoryRead
+	}
+	return CategoryAdmin
+}
+
+// hasACLCategory indica si el comando pertenece a la categoría ACL dada
+// (p.ej. "@write")
+func (s CommandSpec) hasACLCategory(category string) bool {
+	for _, c := range s.ACLCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// CommandCategory devuelve la categoría de un comando, resolviendo los
+// subcomandos (OBJECT ENCODING es lectura, CONFIG SET administración). Los
+// comandos desconocidos se consideran de administración.
+func (a *Analyzer) CommandCategory(cmd *parser.RedisCommand) Category {
+	spec, exists := a.lookupCommand(cmd)
+	if !exists {
+		return CategoryAdmin
+	}
+	return spec.Category()
+}
+
+// SetReadOnly activa el modo solo lectura: los comandos que no son de
+// lectura según sus flags son un error READ_ONLY. Se rechazan también los de
+// administración, ya que muchos modifican el servidor sin estar marcados
+// como escritura (CONFIG SET, SHUTDOWN, SCRIPT FLUSH...).
+func (a *Analyzer) SetReadOnly(enabled bool) {
+	a.readOnly = enabled
+}
+
+// ReadOnly indica si el analizador está en modo solo lectura
+func (a *Analyzer) ReadOnly() bool {
+	return a.readOnly
+}
+
+// validateReadOnly rechaza en modo solo lectura los comandos de escritura y
+// administración
+func (a *Analyzer) validateReadOnly(commandName string, spec CommandSpec, cmd *parser.RedisCommand, result *ValidationResult) {
+	if !a.readOnly {
+		return
+	}
+	if category := spec.Category(); category != CategoryRead {
+		result.addError(SemanticError{
+			Message: fmt.Sprintf("Command %s is not allowed in read-only mode (%s command)", commandName, category),
+			Command: commandName,
+			Type:    "READ_ONLY",
+			Span:    cmd.Command.Span(),
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package semantic
+
+import (
+	"testing"
+	"redis-analyzer-api/parser"
+)
+
+func TestCommandCategory(t *testing.T) {
+	analyzer := New()
+
+	tests := []struct {
+		input    string
+		expected Category
+	}{
+		{"GET a", CategoryRead},
+		{"KEYS *", CategoryRead},
+		{"OBJECT ENCODING a", CategoryRead},
+		{"PING", CategoryRead},
+		{"MULTI", CategoryRead},
+		{"INFO", CategoryRead},
+		{"SET a 1", CategoryWrite},
+		{"DEL a", CategoryWrite},
+		{"FLUSHDB", CategoryWrite},
+		{"PUBLISH ch msg", CategoryWrite},
+		{"EVAL \"return 1\" 0", CategoryWrite},
+		{"CONFIG SET maxmemory 100mb", CategoryAdmin},
+		{"CONFIG GET maxmemory", CategoryAdmin},
+		{"SCRIPT FLUSH", CategoryAdmin},
+		{"SHUTDOWN", CategoryAdmin},
+		{"NOSUCHCOMMAND a", CategoryAdmin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			cmd, errs := parser.ParseCommand(tt.input)
+			if len(errs) > 0 {
+				t.Fatalf("Parse errors: %v", errs)
+			}
+			if got := analyzer.CommandCategory(cmd); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestReadOnlyMode(t *testing.T) {
+	analyzer := New()
+	analyzer.SetReadOnly(true)
+
+	tests := []struct {
+		input   string
+		allowed bool
+	}{
+		{"GET a", true},
+		{"SCAN 0 MATCH user:*", true},
+		{"INFO memory", true},
+		{"MULTI", true},
+		{"SET a 1", false},
+		{"FLUSHDB", false},
+		{"PUBLISH ch msg", false},
+		{"CONFIG SET maxmemory 100mb", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			cmd, errs := parser.ParseCommand(tt.input)
+			if len(errs) > 0 {
+				t.Fatalf("Parse errors: %v", errs)
+			}
+			result := analyzer.ValidateCommand(cmd)
+			readOnlyErrors := 0
+			for _, err := range result.Errors {
+				if err.Type == "READ_ONLY" {
+					readOnlyErrors++
+				}
+			}
+			if tt.allowed && readOnlyErrors > 0 {
+				t.Errorf("Expected %s to be allowed, got %+v", tt.input, result.Errors)
+			}
+			if !tt.allowed && (readOnlyErrors != 1 || result.Valid) {
+				t.Errorf("Expected one READ_ONLY error, got %+v", result.Errors)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package semantic
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"redis-analyzer-api/parser"
 )
@@ -10,20 +11,61 @@ import (
 type SemanticError struct {
 	Message  string
 	Command  string
-	Position int
+	Position int // desplazamiento en bytes del argumento que causa el error
 	Type     string
+	Span     parser.Span
+	Rule     string // regla de la política que rechazó el comando (Type "POLICY")
 }
 
 func (e SemanticError) Error() string {
 	return fmt.Sprintf("Semantic error in command '%s': %s", e.Command, e.Message)
 }
 
+// Diagnostic convierte el error en un diagnóstico estructurado
+func (e SemanticError) Diagnostic() parser.Diagnostic {
+	return parser.Diagnostic{
+		Span:     e.Span,
+		Severity: parser.SeverityError,
+		Code:     e.Type,
+		Message:  e.Message,
+	}
+}
+
 // ValidationResult contiene el resultado de la validación semántica
 type ValidationResult struct {
 	Valid      bool
 	Errors     []SemanticError
 	Warnings   []string
 	CommandInfo map[string]interface{}
+	Diagnostics []parser.Diagnostic // errores y avisos anclados al texto fuente
+	Keys        []KeyRef            // claves del comando con su hash tag y slot
+}
+
+// addError registra un error semántico y su diagnóstico
+func (r *ValidationResult) addError(err SemanticError) {
+	if !err.Span.IsZero() {
+		err.Position = err.Span.Start.Offset
+	}
+	r.Valid = false
+	r.Errors = append(r.Errors, err)
+	r.Diagnostics = append(r.Diagnostics, err.Diagnostic())
+}
+
+// AddError registra un error detectado fuera del analizador (p.ej. por la
+// política de comandos) y marca el resultado como no válido
+func (r *ValidationResult) AddError(err SemanticError) {
+	r.addError(err)
+}
+
+// addWarning registra un aviso y su diagnóstico
+func (r *ValidationResult) addWarning(code string, span parser.Span, message string) {
+	r.Warnings = append(r.Warnings, message)
+	r.Diagnostics = append(r.Diagnostics, parser.Diagnostic{
+		Span:     span,
+		Severity: parser.SeverityWarning,
+		Code:     code,
+		Message:  message,
+	})
 }
 
 // CommandSpec define la especificación de un comando Redis
@@ -35,6 +77,19 @@ type CommandSpec struct {
 	ValueTypes   []string // tipos esperados para cada argumento
 	Options      map[string]OptionSpec
 	Description  string
+
+	// Metadatos de commands.json / COMMAND DOCS
+	Arity         int      // aridad de Redis (incluye el nombre; negativa = mínimo)
+	Group         string
+	Since         string
+	Complexity    string
+	Flags         []string // command_flags (write, readonly, admin...)
+	ACLCategories []string
+	DocFlags      []string // doc_flags (deprecated...)
+	KeySpecs      []KeySpec
+	Arguments     []ArgumentSpec
+	Container     string   // comando contenedor si es un subcomando
+	Subcommands   []string // subcomandos si es un contenedor
 }
 
 // OptionSpec define la especificación de una opción de comando
@@ -48,6 +103,8 @@ type OptionSpec struct {
 // Analyzer representa el analizador semántico
 type Analyzer struct {
 	commands map[string]CommandSpec
+	cluster  bool // los comandos se ejecutan contra Redis Cluster
+	readOnly bool // solo se admiten comandos de lectura
 }
 
 // New crea un nuevo analizador semántico
@@ -55,23 +112,55 @@ func New() *Analyzer {
 	analyzer := &Analyzer{
 		commands: make(map[string]CommandSpec),
 	}
-	analyzer.initializeCommands()
+	analyzer.LoadCommands(DefaultCommandSpecs())
 	return analyzer
 }
 
-// initializeCommands inicializa las especificaciones de comandos Redis
+// LoadCommands reemplaza la tabla de comandos por las especificaciones dadas.
+// Los comandos básicos que falten en la tabla se completan con las
+// especificaciones escritas a mano de initializeCommands.
+func (a *Analyzer) LoadCommands(specs map[string]CommandSpec) {
+	a.commands = make(map[string]CommandSpec, len(specs))
+	for name, spec := range specs {
+		a.commands[strings.ToUpper(name)] = spec
+	}
+	a.initializeCommands()
+}
+
+// LoadCommandsFile carga la tabla de comandos desde un archivo commands.json
+func (a *Analyzer) LoadCommandsFile(path string) error {
+	specs, err := LoadCommandsFile(path)
+	if err != nil {
+		return err
+	}
+	a.LoadCommands(specs)
+	return nil
+}
+
+// defaultCommand registra una especificación escrita a mano solo si la
+// tabla cargada no tiene ese comando. Si lo tiene, la cargada gana en todos
+// los campos: su gramática, aridad y key specs son más completas.
+func (a *Analyzer) defaultCommand(spec CommandSpec) {
+	if _, ok := a.commands[spec.Name]; ok {
+		return
+	}
+	a.commands[spec.Name] = spec
+}
+
+// initializeCommands añade las especificaciones mínimas de los comandos
+// básicos, para tablas cargadas con -commands-file que no los incluyan
 func (a *Analyzer) initializeCommands() {
 	// Comandos básicos de strings
-	a.commands["GET"] = CommandSpec{
+	a.defaultCommand(CommandSpec{
 		Name:        "GET",
 		MinArgs:     1,
 		MaxArgs:     1,
 		KeyPosition: 0,
 		ValueTypes:  []string{"key"},
 		Description: "Get the value of a key",
-	}
+	})
 	
-	a.commands["SET"] = CommandSpec{
+	a.defaultCommand(CommandSpec{
 		Name:        "SET",
 		MinArgs:     2,
 		MaxArgs:     -1,
@@ -84,38 +173,38 @@ func (a *Analyzer) initializeCommands() {
 			"XX": {HasValue: false, Description: "Only set if key exists", Conflicts: []string{"NX"}},
 		},
 		Description: "Set the string value of a key",
-	}
+	})
 	
-	a.commands["DEL"] = CommandSpec{
+	a.defaultCommand(CommandSpec{
 		Name:        "DEL",
 		MinArgs:     1,
 		MaxArgs:     -1,
 		KeyPosition: 0,
 		ValueTypes:  []string{"key"},
 		Description: "Delete one or more keys",
-	}
+	})
 	
 	// Comandos de hash
-	a.commands["HGET"] = CommandSpec{
+	a.defaultCommand(CommandSpec{
 		Name:        "HGET",
 		MinArgs:     2,
 		MaxArgs:     2,
 		KeyPosition: 0,
 		ValueTypes:  []string{"key", "field"},
 		Description: "Get the value of a hash field",
-	}
+	})
 	
-	a.commands["HSET"] = CommandSpec{
+	a.defaultCommand(CommandSpec{
 		Name:        "HSET",
 		MinArgs:     3,
 		MaxArgs:     -1,
 		KeyPosition: 0,
 		ValueTypes:  []string{"key", "field", "value"},
 		Description: "Set the string value of a hash field",
-	}
+	})
 	
 	// Comandos de sorted sets
-	a.commands["ZADD"] = CommandSpec{
+	a.defaultCommand(CommandSpec{
 		Name:        "ZADD",
 		MinArgs:     3,
 		MaxArgs:     -1,
@@ -126,9 +215,9 @@ func (a *Analyzer) initializeCommands() {
 			"XX": {HasValue: false, Description: "Only update existing elements", Conflicts: []string{"NX"}},
 		},
 		Description: "Add one or more members to a sorted set",
-	}
+	})
 	
-	a.commands["ZRANGE"] = CommandSpec{
+	a.defaultCommand(CommandSpec{
 		Name:        "ZRANGE",
 		MinArgs:     3,
 		MaxArgs:     -1,
@@ -138,10 +227,10 @@ func (a *Analyzer) initializeCommands() {
 			"WITHSCORES": {HasValue: false, Description: "Return scores along with members"},
 		},
 		Description: "Return a range of members in a sorted set",
-	}
+	})
 	
 	// Comandos de utilidad
-	a.commands["SCAN"] = CommandSpec{
+	a.defaultCommand(CommandSpec{
 		Name:        "SCAN",
 		MinArgs:     1,
 		MaxArgs:     -1,
@@ -153,7 +242,7 @@ func (a *Analyzer) initializeCommands() {
 			"TYPE":  {HasValue: true, ValueType: "string", Description: "Filter by type"},
 		},
 		Description: "Incrementally iterate over keys",
-	}
+	})
 }
 
 // ValidateCommand valida un comando Redis parseado
@@ -163,58 +252,145 @@ func (a *Analyzer) ValidateCommand(cmd *parser.RedisCommand) ValidationResult {
 		Errors:      []SemanticError{},
 		Warnings:    []string{},
 		CommandInfo: make(map[string]interface{}),
+		Diagnostics: []parser.Diagnostic{},
 	}
 	
 	commandName := strings.ToUpper(cmd.Command.Value)
 	spec, exists := a.commands[commandName]
 	
 	if !exists {
-		result.Valid = false
-		result.Errors = append(result.Errors, SemanticError{
+		result.addError(SemanticError{
 			Message: fmt.Sprintf("Unknown command: %s", commandName),
 			Command: commandName,
 			Type:    "UNKNOWN_COMMAND",
+			Span:    cmd.Command.Span(),
 		})
 		return result
 	}
 	
+	// Resolver subcomandos (CLIENT KILL, CONFIG GET...)
+	if len(spec.Subcommands) > 0 && len(cmd.Arguments) > 0 {
+		subName := commandName + " " + strings.ToUpper(argumentText(cmd.Arguments[0]))
+		if subSpec, ok := a.commands[subName]; ok {
+			subResult := a.ValidateCommand(&parser.RedisCommand{
+				Command: &parser.Identifier{
+					Token: cmd.Command.Token,
+					Value: subSpec.Name,
+					Loc:   parser.JoinSpans(cmd.Command.Span(), cmd.Arguments[0].Span()),
+				},
+				Arguments: cmd.Arguments[1:],
+				Loc:       cmd.Span(),
+			})
+			// Las posiciones de las claves se refieren al comando original
+			for i := range subResult.Keys {
+				subResult.Keys[i].Index++
+			}
+			return subResult
+		}
+		if len(spec.Arguments) == 0 {
+			result.addError(SemanticError{
+				Message: fmt.Sprintf("Unknown subcommand '%s' for %s", strings.ToUpper(argumentText(cmd.Arguments[0])), commandName),
+				Command: commandName,
+				Type:    "UNKNOWN_COMMAND",
+				Span:    cmd.Arguments[0].Span(),
+			})
+			return result
+		}
+	}
+	
+	if spec.IsDeprecated() {
+		result.addWarning("DEPRECATED_COMMAND", cmd.Command.Span(), fmt.Sprintf("Command %s is deprecated", commandName))
+	}
+	
+	a.validateReadOnly(commandName, spec, cmd, &result)
+	
 	// Validar número de argumentos
 	argCount := len(cmd.Arguments)
 	if argCount < spec.MinArgs {
-		result.Valid = false
-		result.Errors = append(result.Errors, SemanticError{
+		result.addError(SemanticError{
 			Message: fmt.Sprintf("Too few arguments. Expected at least %d, got %d", spec.MinArgs, argCount),
 			Command: commandName,
 			Type:    "INSUFFICIENT_ARGS",
+			Span:    cmd.Span(),
 		})
 	}
 	
 	if spec.MaxArgs != -1 && argCount > spec.MaxArgs {
-		result.Valid = false
-		result.Errors = append(result.Errors, SemanticError{
+		result.addError(SemanticError{
 			Message: fmt.Sprintf("Too many arguments. Expected at most %d, got %d", spec.MaxArgs, argCount),
 			Command: commandName,
 			Type:    "EXCESSIVE_ARGS",
+			Span:    excessSpan(cmd, spec.MaxArgs),
 		})
 	}
 	
-	// Validar tipos de argumentos
-	a.validateArgumentTypes(cmd, spec, &result)
-	
-	// Validar opciones
-	a.validateOptions(cmd, spec, &result)
+	if len(spec.Arguments) > 0 {
+		// Validar contra la gramática del comando (solo si el número de
+		// argumentos es correcto, para no duplicar errores)
+		if result.Valid {
+			a.validateGrammar(cmd, spec, &result)
+		}
+	} else {
+		// Validar tipos de argumentos
+		a.validateArgumentTypes(cmd, spec, &result)
+		
+		// Validar opciones
+		a.validateOptions(cmd, spec, &result)
+	}
 	
 	// Agregar información del comando
 	result.CommandInfo["name"] = commandName
 	result.CommandInfo["description"] = spec.Description
 	result.CommandInfo["has_key"] = spec.KeyPosition >= 0
+	if spec.Group != "" {
+		result.CommandInfo["group"] = spec.Group
+	}
+	if spec.Since != "" {
+		result.CommandInfo["since"] = spec.Since
+	}
 	if spec.KeyPosition >= 0 && spec.KeyPosition < len(cmd.Arguments) {
 		result.CommandInfo["key"] = cmd.Arguments[spec.KeyPosition].String()
 	}
+	result.Keys = commandKeys(spec, cmd.Arguments)
+	a.validateCrossSlot(commandName, &result)
 	
 	return result
 }
 
+// validateGrammar compara los argumentos con la gramática del comando y
+// valida los rangos de los valores enlazados
+func (a *Analyzer) validateGrammar(cmd *parser.RedisCommand, spec CommandSpec, result *ValidationResult) {
+	bindings, err := MatchArguments(spec.Arguments, cmd.Arguments)
+	if err != nil {
+		err.Command = spec.Name
+		if err.Span.IsZero() {
+			// Falta un argumento al final: anclar el error al final del comando
+			end := cmd.Span().End
+			err.Span = parser.Span{Start: end, End: end}
+		}
+		result.addError(*err)
+		return
+	}
+	
+	for i, binding := range bindings {
+		if !binding.IsToken || i+1 >= len(bindings) || bindings[i+1].Element != binding.Element {
+			continue
+		}
+		option := strings.ToUpper(binding.Element.Token)
+		if option != "EX" && option != "PX" && option != "EXAT" && option != "PXAT" {
+			continue
+		}
+		if intLit, ok := cmd.Arguments[bindings[i+1].Index].(*parser.IntegerLiteral); ok && intLit.Value <= 0 {
+			result.addError(SemanticError{
+				Message: fmt.Sprintf("Expiration time must be positive, got %d", intLit.Value),
+				Command: spec.Name,
+				Type:    "INVALID_VALUE_RANGE",
+				Span:    intLit.Span(),
+			})
+		}
+	}
+}
+
 // validateArgumentTypes valida los tipos de argumentos
 func (a *Analyzer) validateArgumentTypes(cmd *parser.RedisCommand, spec CommandSpec, result *ValidationResult) {
 	for i, arg := range cmd.Arguments {
@@ -228,59 +404,77 @@ func (a *Analyzer) validateArgumentTypes(cmd *parser.RedisCommand, spec CommandS
 		switch expectedType {
 		case "key":
 			if actualType != "Identifier" && actualType != "StringLiteral" && actualType != "PatternExpression" {
-				result.Errors = append(result.Errors, SemanticError{
+				result.addError(SemanticError{
 					Message: fmt.Sprintf("Argument %d should be a key (identifier or string), got %s", i+1, actualType),
 					Command: cmd.Command.Value,
 					Type:    "TYPE_MISMATCH",
+					Span:    arg.Span(),
 				})
-				result.Valid = false
 			}
 		case "value":
 			// Los valores pueden ser de cualquier tipo
 		case "field":
 			if actualType != "Identifier" && actualType != "StringLiteral" {
-				result.Errors = append(result.Errors, SemanticError{
+				result.addError(SemanticError{
 					Message: fmt.Sprintf("Argument %d should be a field name, got %s", i+1, actualType),
 					Command: cmd.Command.Value,
 					Type:    "TYPE_MISMATCH",
+					Span:    arg.Span(),
 				})
-				result.Valid = false
 			}
 		case "score":
 			if actualType != "IntegerLiteral" && actualType != "FloatLiteral" {
-				result.Errors = append(result.Errors, SemanticError{
+				result.addError(SemanticError{
 					Message: fmt.Sprintf("Argument %d should be a numeric score, got %s", i+1, actualType),
 					Command: cmd.Command.Value,
 					Type:    "TYPE_MISMATCH",
+					Span:    arg.Span(),
 				})
-				result.Valid = false
 			}
 		case "member":
 			if actualType != "Identifier" && actualType != "StringLiteral" {
-				result.Errors = append(result.Errors, SemanticError{
+				result.addError(SemanticError{
 					Message: fmt.Sprintf("Argument %d should be a member name, got %s", i+1, actualType),
 					Command: cmd.Command.Value,
 					Type:    "TYPE_MISMATCH",
+					Span:    arg.Span(),
 				})
-				result.Valid = false
 			}
 		case "cursor":
 			if actualType != "IntegerLiteral" {
-				result.Errors = append(result.Errors, SemanticError{
+				result.addError(SemanticError{
 					Message: fmt.Sprintf("Argument %d should be a cursor (integer), got %s", i+1, actualType),
 					Command: cmd.Command.Value,
 					Type:    "TYPE_MISMATCH",
+					Span:    arg.Span(),
+				})
+			}
+		case "integer":
+			if !isIntegerArgument(arg) {
+				result.addError(SemanticError{
+					Message: fmt.Sprintf("Argument %d should be an integer, got %s", i+1, actualType),
+					Command: cmd.Command.Value,
+					Type:    "TYPE_MISMATCH",
+					Span:    arg.Span(),
+				})
+			}
+		case "double":
+			if !isDoubleArgument(arg) {
+				result.addError(SemanticError{
+					Message: fmt.Sprintf("Argument %d should be a number, got %s", i+1, actualType),
+					Command: cmd.Command.Value,
+					Type:    "TYPE_MISMATCH",
+					Span:    arg.Span(),
 				})
-				result.Valid = false
 			}
 		case "start", "stop":
 			if actualType != "IntegerLiteral" {
-				result.Errors = append(result.Errors, SemanticError{
+				result.addError(SemanticError{
 					Message: fmt.Sprintf("Argument %d should be an index (integer), got %s", i+1, actualType),
 					Command: cmd.Command.Value,
 					Type:    "TYPE_MISMATCH",
+					Span:    arg.Span(),
 				})
-				result.Valid = false
 			}
 		}
 	}
@@ -298,20 +492,19 @@ func (a *Analyzer) validateOptions(cmd *parser.RedisCommand, spec CommandSpec, r
 			// Verificar si la opción es válida para este comando
 			optionSpec, exists := spec.Options[optionName]
 			if !exists {
-				result.Warnings = append(result.Warnings, 
-					fmt.Sprintf("Unknown option '%s' for command %s", optionName, cmd.Command.Value))
+				result.addWarning("UNKNOWN_OPTION", arg.Span(), fmt.Sprintf("Unknown option '%s' for command %s", optionName, cmd.Command.Value))
 				continue
 			}
 			
 			// Verificar conflictos
 			for _, conflict := range optionSpec.Conflicts {
 				if usedOptions[conflict] {
-					result.Errors = append(result.Errors, SemanticError{
+					result.addError(SemanticError{
 						Message: fmt.Sprintf("Option '%s' conflicts with '%s'", optionName, conflict),
 						Command: cmd.Command.Value,
 						Type:    "OPTION_CONFLICT",
+						Span:    arg.Span(),
 					})
-					result.Valid = false
 				}
 			}
 			
@@ -320,12 +513,12 @@ func (a *Analyzer) validateOptions(cmd *parser.RedisCommand, spec CommandSpec, r
 			// Verificar si la opción requiere un valor
 			if optionSpec.HasValue {
 				if i+1 >= len(cmd.Arguments) {
-					result.Errors = append(result.Errors, SemanticError{
+					result.addError(SemanticError{
 						Message: fmt.Sprintf("Option '%s' requires a value", optionName),
 						Command: cmd.Command.Value,
 						Type:    "MISSING_OPTION_VALUE",
+						Span:    arg.Span(),
 					})
-					result.Valid = false
 				} else {
 					// Validar el tipo del valor de la opción
 					valueArg := cmd.Arguments[i+1]
@@ -352,48 +545,137 @@ func (a *Analyzer) validateOptionValue(optionName string, spec OptionSpec, value
 	switch spec.ValueType {
 	case "integer":
 		if valueType != "IntegerLiteral" {
-			result.Errors = append(result.Errors, SemanticError{
+			result.addError(SemanticError{
 				Message: fmt.Sprintf("Option '%s' expects an integer value, got %s", optionName, valueType),
 				Command: commandName,
 				Type:    "OPTION_TYPE_MISMATCH",
+				Span:    value.Span(),
 			})
-			result.Valid = false
 		} else {
 			// Validar rangos específicos
 			if intLit, ok := value.(*parser.IntegerLiteral); ok {
 				if optionName == "EX" || optionName == "PX" {
 					if intLit.Value <= 0 {
-						result.Errors = append(result.Errors, SemanticError{
+						result.addError(SemanticError{
 							Message: fmt.Sprintf("Expiration time must be positive, got %d", intLit.Value),
 							Command: commandName,
 							Type:    "INVALID_VALUE_RANGE",
+							Span:    value.Span(),
 						})
-						result.Valid = false
 					}
 				}
 			}
 		}
+	case "double":
+		if !isDoubleArgument(value) {
+			result.addError(SemanticError{
+				Message: fmt.Sprintf("Option '%s' expects a numeric value, got %s", optionName, valueType),
+				Command: commandName,
+				Type:    "OPTION_TYPE_MISMATCH",
+				Span:    value.Span(),
+			})
+		}
 	case "pattern":
 		if valueType != "PatternExpression" && valueType != "StringLiteral" && valueType != "Identifier" {
-			result.Warnings = append(result.Warnings, 
-				fmt.Sprintf("Option '%s' expects a pattern, got %s", optionName, valueType))
+			result.addWarning("OPTION_TYPE_MISMATCH", value.Span(), fmt.Sprintf("Option '%s' expects a pattern, got %s", optionName, valueType))
 		}
 	case "string":
 		if valueType != "StringLiteral" && valueType != "Identifier" {
-			result.Warnings = append(result.Warnings, 
-				fmt.Sprintf("Option '%s' expects a string, got %s", optionName, valueType))
+			result.addWarning("OPTION_TYPE_MISMATCH", value.Span(), fmt.Sprintf("Option '%s' expects a string, got %s", optionName, valueType))
 		}
 	}
 }
 
-// ValidateProgram valida un programa completo con múltiples comandos
+// excessSpan devuelve el rango de los argumentos que sobran
+func excessSpan(cmd *parser.RedisCommand, maxArgs int) parser.Span {
+	return parser.JoinSpans(cmd.Arguments[maxArgs].Span(), cmd.Arguments[len(cmd.Arguments)-1].Span())
+}
+
+// isIntegerArgument indica si un argumento es un entero literal o una
+// cadena que Redis interpretaría como entero
+func isIntegerArgument(arg parser.Expression) bool {
+	switch v := arg.(type) {
+	case *parser.IntegerLiteral:
+		return true
+	case *parser.StringLiteral:
+		_, err := strconv.ParseInt(v.Value, 10, 64)
+		return err == nil
+	}
+	return false
+}
+
+// isDoubleArgument indica si un argumento es un número (entero, decimal,
+// o las formas "inf", "+inf" y "-inf" que acepta Redis)
+func isDoubleArgument(arg parser.Expression) bool {
+	switch v := arg.(type) {
+	case *parser.IntegerLiteral, *parser.FloatLiteral:
+		return true
+	case *parser.StringLiteral, *parser.Identifier:
+		_, ok := parseDouble(argumentText(v))
+		return ok
+	}
+	return false
+}
+
+// argumentText devuelve el texto de un argumento sin comillas
+func argumentText(arg parser.Expression) string {
+	if sl, ok := arg.(*parser.StringLiteral); ok {
+		return sl.Value
+	}
+	return arg.String()
+}
+
+// IsDeprecated indica si el comando está marcado como obsoleto
+func (s CommandSpec) IsDeprecated() bool {
+	for _, flag := range s.DocFlags {
+		if flag == "deprecated" {
+			return true
+		}
+	}
+	return false
+}
+
+// HasFlag indica si el comando tiene el flag dado (p.ej. "write")
+func (s CommandSpec) HasFlag(flag string) bool {
+	for _, f := range s.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateProgram valida un programa completo con múltiples comandos.
+// Devuelve un resultado por comando, incluidos los de los bloques MULTI/EXEC.
+// Además sigue el tipo de cada clave a lo largo del programa (HSET la hace
+// un hash, DEL la borra, SET la convierte en cadena) y marca como WRONGTYPE
+// los comandos que la usarían con otro tipo.
 func (a *Analyzer) ValidateProgram(program *parser.Program) []ValidationResult {
+	return a.ValidateProgramWithTypes(program, nil)
+}
+
+// ValidateProgramWithTypes es ValidateProgram partiendo de los tipos que las
+// claves tienen en el servidor (p.ej. obtenidos con TYPE)
+func (a *Analyzer) ValidateProgramWithTypes(program *parser.Program, types KeyTypes) []ValidationResult {
 	results := make([]ValidationResult, 0, len(program.Statements))
+	env := newTypeEnv(types)
 	
 	for _, stmt := range program.Statements {
-		if cmd, ok := stmt.(*parser.RedisCommand); ok {
-			result := a.ValidateCommand(cmd)
+		switch s := stmt.(type) {
+		case *parser.RedisCommand:
+			result := a.ValidateCommand(s)
+			a.validateUnbalancedEnd(s, &result)
+			a.validateKeyTypes(s, &result, env)
 			results = append(results, result)
+		case *parser.TransactionBlock:
+			block := a.ValidateTransaction(s)
+			// Los comandos de un bloque descartado no llegan a ejecutarse
+			if !s.IsDiscarded() {
+				for i, cmd := range s.Commands {
+					a.validateKeyTypes(cmd, &block[i+1], env)
+				}
+			}
+			results = append(results, block...)
 		}
 	}
 	
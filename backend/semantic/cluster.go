@@ -0,0 +1,106 @@
+package semantic
+
+import (
+	"fmt"
+	"strings"
+	"redis-analyzer-api/parser"
+)
+
+// SlotCount es el número de hash slots de Redis Cluster
+const SlotCount = 16384
+
+// Slot devuelve el hash slot de Redis Cluster al que pertenece una clave:
+// CRC16 de su hash tag módulo 16384
+func Slot(key string) int {
+	return int(crc16(HashTag(key))) % SlotCount
+}
+
+// crc16 calcula el CRC16-CCITT (XMODEM) que usa Redis Cluster
+func crc16(s string) uint16 {
+	var crc uint16
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// SetClusterMode indica si los comandos se ejecutarán contra Redis Cluster.
+// En modo cluster las claves en slots distintos son un error CROSSSLOT;
+// fuera de él solo se avisa, ya que el comando funcionaría en un nodo único.
+func (a *Analyzer) SetClusterMode(enabled bool) {
+	a.cluster = enabled
+}
+
+// ClusterMode indica si el analizador valida para Redis Cluster
+func (a *Analyzer) ClusterMode() bool {
+	return a.cluster
+}
+
+// validateCrossSlot comprueba que todas las claves de un comando pertenezcan
+// al mismo slot
+func (a *Analyzer) validateCrossSlot(commandName string, result *ValidationResult) {
+	if len(result.Keys) < 2 {
+		return
+	}
+	first := result.Keys[0]
+	for _, key := range result.Keys[1:] {
+		if key.Slot == first.Slot {
+			continue
+		}
+		a.addCrossSlot(result, SemanticError{
+			Message: fmt.Sprintf("Keys '%s' (slot %d) and '%s' (slot %d) don't hash to the same slot",
+				first.Name, first.Slot, key.Name, key.Slot),
+			Command: commandName,
+			Type:    "CROSSSLOT",
+			Span:    key.Span,
+		})
+		return
+	}
+}
+
+// validateTransactionSlots comprueba que los comandos encolados en un bloque
+// MULTI/EXEC usen claves de un único slot. results contiene un resultado por
+// comando encolado; las claves de cada comando entre sí ya se comprobaron
+// en ValidateCommand, así que basta con comparar su primera clave.
+func (a *Analyzer) validateTransactionSlots(commands []*parser.RedisCommand, results []ValidationResult) {
+	var first KeyRef
+	found := false
+	for i := range results {
+		if len(results[i].Keys) == 0 {
+			continue
+		}
+		key := results[i].Keys[0]
+		if !found {
+			first, found = key, true
+			continue
+		}
+		if key.Slot == first.Slot {
+			continue
+		}
+		a.addCrossSlot(&results[i], SemanticError{
+			Message: fmt.Sprintf("Transaction keys '%s' (slot %d) and '%s' (slot %d) don't hash to the same slot",
+				first.Name, first.Slot, key.Name, key.Slot),
+			Command: strings.ToUpper(commands[i].Command.Value),
+			Type:    "CROSSSLOT",
+			Span:    key.Span,
+		})
+		return
+	}
+}
+
+// addCrossSlot registra un CROSSSLOT como error en modo cluster o como aviso
+// en otro caso
+func (a *Analyzer) addCrossSlot(result *ValidationResult, err SemanticError) {
+	if a.cluster {
+		result.addError(err)
+		return
+	}
+	result.addWarning(err.Type, err.Span, err.Message)
+}
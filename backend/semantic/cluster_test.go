@@ -0,0 +1,86 @@
+package semantic
+
+import (
+	"testing"
+	"redis-analyzer-api/parser"
+)
+
+func TestSlot(t *testing.T) {
+	tests := []struct {
+		key      string
+		expected int
+	}{
+		{"123456789", 12739},
+		{"foo", 12182},
+		{"bar", 5061},
+		{"{foo}:profile", 12182},
+		{"session:{bar}", 5061},
+	}
+
+	for _, tt := range tests {
+		if got := Slot(tt.key); got != tt.expected {
+			t.Errorf("Slot(%q) = %d, expected %d", tt.key, got, tt.expected)
+		}
+	}
+	if Slot("{}foo") == Slot("foo") {
+		t.Errorf("An empty hash tag should hash the whole key")
+	}
+}
+
+func TestCrossSlot(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		crossSlot bool
+	}{
+		{name: "Single key", input: "GET foo"},
+		{name: "Same hash tag", input: "DEL {user:1}:a {user:1}:b"},
+		{name: "Different slots", input: "DEL foo bar", crossSlot: true},
+		{name: "MSET different slots", input: "MSET foo 1 bar 2", crossSlot: true},
+		{name: "MSET values are not keys", input: "MSET {t}a foo {t}b bar"},
+		{name: "Transaction same slot", input: "MULTI\nSET {t}a 1\nINCR {t}b\nEXEC"},
+		{name: "Transaction different slots", input: "MULTI\nSET foo 1\nINCR bar\nEXEC", crossSlot: true},
+		{name: "Keyless commands in transaction", input: "MULTI\nPING\nSET foo 1\nEXEC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			program, parseErrors := parser.ParseCommands(tt.input)
+			if len(parseErrors) > 0 {
+				t.Fatalf("Parse error: %v", parseErrors)
+			}
+
+			for _, cluster := range []bool{false, true} {
+				analyzer := New()
+				analyzer.SetClusterMode(cluster)
+
+				var found *parser.Diagnostic
+				valid := true
+				for _, result := range analyzer.ValidateProgram(program) {
+					valid = valid && result.Valid
+					for i, diag := range result.Diagnostics {
+						if diag.Code == "CROSSSLOT" {
+							found = &result.Diagnostics[i]
+						}
+					}
+				}
+
+				if !tt.crossSlot {
+					if found != nil {
+						t.Errorf("Unexpected CROSSSLOT: %s", found)
+					}
+					continue
+				}
+				if found == nil {
+					t.Fatalf("Expected CROSSSLOT (cluster=%v)", cluster)
+				}
+				if cluster && (found.Severity != parser.SeverityError || valid) {
+					t.Errorf("Expected CROSSSLOT error in cluster mode, got %s", found)
+				}
+				if !cluster && (found.Severity != parser.SeverityWarning || !valid) {
+					t.Errorf("Expected CROSSSLOT warning outside cluster mode, got %s", found)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,401 @@
+package semantic
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// defaultCommandsJSON es una copia de la tabla de comandos de Redis en el
+// formato de commands.json, para que el analizador funcione sin conexión
+//
+//go:embed commands.json
+var defaultCommandsJSON []byte
+
+var (
+	defaultSpecsOnce sync.Once
+	defaultSpecs     map[string]CommandSpec
+	defaultSpecsErr  error
+)
+
+// CommandDoc representa una entrada del formato commands.json de Redis
+type CommandDoc struct {
+	Summary       string        `json:"summary"`
+	Since         string        `json:"since"`
+	Group         string        `json:"group"`
+	Complexity    string        `json:"complexity"`
+	Container     string        `json:"container,omitempty"`
+	Arity         int           `json:"arity"`
+	KeySpecs      []KeySpecDoc  `json:"key_specs,omitempty"`
+	Arguments     []ArgumentDoc `json:"arguments,omitempty"`
+	CommandFlags  []string      `json:"command_flags,omitempty"`
+	ACLCategories []string      `json:"acl_categories,omitempty"`
+	DocFlags      []string      `json:"doc_flags,omitempty"`
+}
+
+// ArgumentDoc representa un argumento (posiblemente compuesto) de un comando
+type ArgumentDoc struct {
+	Name          string        `json:"name"`
+	Type          string        `json:"type"`
+	Token         string        `json:"token,omitempty"`
+	DisplayText   string        `json:"display_text,omitempty"`
+	KeySpecIndex  *int          `json:"key_spec_index,omitempty"`
+	Optional      bool          `json:"optional,omitempty"`
+	Multiple      bool          `json:"multiple,omitempty"`
+	MultipleToken bool          `json:"multiple_token,omitempty"`
+	Since         string        `json:"since,omitempty"`
+	Arguments     []ArgumentDoc `json:"arguments,omitempty"`
+}
+
+// KeySpecDoc describe cómo localizar las claves de un comando
+type KeySpecDoc struct {
+	Flags       []string       `json:"flags"`
+	BeginSearch BeginSearchDoc `json:"begin_search"`
+	FindKeys    FindKeysDoc    `json:"find_keys"`
+}
+
+// BeginSearchDoc indica dónde empezar a buscar claves ("index" o "keyword")
+type BeginSearchDoc struct {
+	Type string `json:"type"`
+	Spec struct {
+		Index     int    `json:"index,omitempty"`
+		Keyword   string `json:"keyword,omitempty"`
+		StartFrom int    `json:"startfrom,omitempty"`
+	} `json:"spec"`
+}
+
+// FindKeysDoc indica cómo encontrar las claves a partir del inicio ("range" o "keynum")
+type FindKeysDoc struct {
+	Type string `json:"type"`
+	Spec struct {
+		LastKey   int `json:"lastkey,omitempty"`
+		Step      int `json:"step,omitempty"`
+		Limit     int `json:"limit,omitempty"`
+		KeyNumIdx int `json:"keynumidx,omitempty"`
+		FirstKey  int `json:"firstkey,omitempty"`
+	} `json:"spec"`
+}
+
+// KeySpec es la versión aplanada de KeySpecDoc usada por el analizador
+type KeySpec struct {
+	Flags       []string
+	BeginSearch string // "index" o "keyword"
+	Index       int
+	Keyword     string
+	StartFrom   int
+	FindKeys    string // "range" o "keynum"
+	LastKey     int
+	KeyStep     int
+	Limit       int
+	KeyNumIdx   int
+	FirstKey    int
+}
+
+// ArgumentSpec describe un argumento de la gramática de un comando
+type ArgumentSpec struct {
+	Name          string
+	Type          string // key, string, integer, double, pattern, unix-time, pure-token, oneof, block
+	Token         string
+	Optional      bool
+	Multiple      bool
+	MultipleToken bool
+	KeySpecIndex  int // -1 si el argumento no es una clave
+	Since         string
+	Arguments     []ArgumentSpec
+}
+
+// DefaultCommandSpecs devuelve las especificaciones de la tabla embebida
+func DefaultCommandSpecs() map[string]CommandSpec {
+	defaultSpecsOnce.Do(func() {
+		defaultSpecs, defaultSpecsErr = ParseCommandsJSON(strings.NewReader(string(defaultCommandsJSON)))
+	})
+	if defaultSpecsErr != nil {
+		// La tabla embebida se valida en los tests; esto no debería ocurrir
+		panic(fmt.Sprintf("invalid embedded commands.json: %v", defaultSpecsErr))
+	}
+
+	specs := make(map[string]CommandSpec, len(defaultSpecs))
+	for name, spec := range defaultSpecs {
+		specs[name] = spec
+	}
+	return specs
+}
+
+// ParseCommandsJSON lee una tabla de comandos en el formato commands.json
+func ParseCommandsJSON(r io.Reader) (map[string]CommandSpec, error) {
+	var docs map[string]CommandDoc
+	if err := json.NewDecoder(r).Decode(&docs); err != nil {
+		return nil, fmt.Errorf("failed to parse commands JSON: %w", err)
+	}
+	return SpecsFromDocs(docs), nil
+}
+
+// LoadCommandsFile lee una tabla de comandos desde un archivo commands.json
+func LoadCommandsFile(path string) (map[string]CommandSpec, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open commands file: %w", err)
+	}
+	defer f.Close()
+
+	return ParseCommandsJSON(f)
+}
+
+// SpecsFromDocs convierte la documentación de comandos en especificaciones.
+// Los subcomandos se registran como "CONTENEDOR SUBCOMANDO" (p.ej. "CLIENT KILL").
+func SpecsFromDocs(docs map[string]CommandDoc) map[string]CommandSpec {
+	specs := make(map[string]CommandSpec, len(docs))
+
+	for name, doc := range docs {
+		name = strings.ToUpper(strings.ReplaceAll(name, "|", " "))
+		specs[name] = specFromDoc(name, doc)
+	}
+
+	// Enlazar contenedores con sus subcomandos
+	for name, spec := range specs {
+		if spec.Container == "" {
+			continue
+		}
+		container, ok := specs[spec.Container]
+		if !ok {
+			container = CommandSpec{
+				Name:        spec.Container,
+				MinArgs:     1,
+				MaxArgs:     -1,
+				KeyPosition: -1,
+				Arity:       -2,
+				Group:       spec.Group,
+				Description: fmt.Sprintf("A container for %s commands", strings.ToLower(spec.Container)),
+			}
+		}
+		container.Subcommands = append(container.Subcommands, strings.TrimPrefix(name, spec.Container+" "))
+		specs[spec.Container] = container
+	}
+
+	return specs
+}
+
+// specFromDoc construye un CommandSpec a partir de su documentación
+func specFromDoc(name string, doc CommandDoc) CommandSpec {
+	// Palabras que forman el nombre (2 en subcomandos), incluidas en la aridad
+	words := len(strings.Fields(name))
+
+	spec := CommandSpec{
+		Name:          name,
+		MaxArgs:       -1,
+		KeyPosition:   -1,
+		Description:   doc.Summary,
+		Arity:         doc.Arity,
+		Group:         doc.Group,
+		Since:         doc.Since,
+		Complexity:    doc.Complexity,
+		Container:     strings.ToUpper(doc.Container),
+		Flags:         lowerAll(doc.CommandFlags),
+		ACLCategories: doc.ACLCategories,
+		DocFlags:      lowerAll(doc.DocFlags),
+	}
+
+	switch {
+	case doc.Arity > 0:
+		spec.MinArgs = doc.Arity - words
+		spec.MaxArgs = spec.MinArgs
+	case doc.Arity < 0:
+		spec.MinArgs = -doc.Arity - words
+	}
+	if spec.MinArgs < 0 {
+		spec.MinArgs = 0
+	}
+
+	for _, ks := range doc.KeySpecs {
+		spec.KeySpecs = append(spec.KeySpecs, KeySpec{
+			Flags:       ks.Flags,
+			BeginSearch: ks.BeginSearch.Type,
+			Index:       ks.BeginSearch.Spec.Index,
+			Keyword:     ks.BeginSearch.Spec.Keyword,
+			StartFrom:   ks.BeginSearch.Spec.StartFrom,
+			FindKeys:    ks.FindKeys.Type,
+			LastKey:     ks.FindKeys.Spec.LastKey,
+			KeyStep:     ks.FindKeys.Spec.Step,
+			Limit:       ks.FindKeys.Spec.Limit,
+			KeyNumIdx:   ks.FindKeys.Spec.KeyNumIdx,
+			FirstKey:    ks.FindKeys.Spec.FirstKey,
+		})
+	}
+	for _, ks := range spec.KeySpecs {
+		if ks.BeginSearch == "index" {
+			spec.KeyPosition = ks.Index - words
+			break
+		}
+	}
+
+	spec.Arguments = argumentsFromDocs(doc.Arguments)
+	spec.ValueTypes = positionalValueTypes(spec.Arguments)
+	spec.Options = optionsFromArguments(spec.Arguments)
+
+	return spec
+}
+
+// argumentsFromDocs convierte recursivamente los argumentos documentados
+func argumentsFromDocs(docs []ArgumentDoc) []ArgumentSpec {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	args := make([]ArgumentSpec, len(docs))
+	for i, doc := range docs {
+		keySpecIndex := -1
+		if doc.KeySpecIndex != nil {
+			keySpecIndex = *doc.KeySpecIndex
+		}
+		args[i] = ArgumentSpec{
+			Name:          doc.Name,
+			Type:          doc.Type,
+			Token:         doc.Token,
+			Optional:      doc.Optional,
+			Multiple:      doc.Multiple,
+			MultipleToken: doc.MultipleToken,
+			KeySpecIndex:  keySpecIndex,
+			Since:         doc.Since,
+			Arguments:     argumentsFromDocs(doc.Arguments),
+		}
+	}
+	return args
+}
+
+// positionalValueTypes deriva los tipos posicionales de los argumentos
+// obligatorios iniciales, hasta la primera opción o argumento compuesto
+func positionalValueTypes(args []ArgumentSpec) []string {
+	types := []string{}
+	for _, arg := range args {
+		if arg.Optional || arg.Token != "" || arg.Type == "block" || arg.Type == "oneof" || arg.Type == "pure-token" {
+			break
+		}
+		types = append(types, valueTypeFor(arg.Type))
+		if arg.Multiple {
+			break
+		}
+	}
+	return types
+}
+
+// valueTypeFor traduce un tipo de commands.json a los tipos del analizador
+func valueTypeFor(argType string) string {
+	switch argType {
+	case "key":
+		return "key"
+	case "integer", "unix-time":
+		return "integer"
+	case "double":
+		return "double"
+	case "pattern":
+		return "pattern"
+	default:
+		return "value"
+	}
+}
+
+// optionsFromArguments extrae las opciones con token de primer nivel.
+// Los tokens de un mismo oneof se marcan como conflictivos entre sí.
+func optionsFromArguments(args []ArgumentSpec) map[string]OptionSpec {
+	options := make(map[string]OptionSpec)
+
+	for _, arg := range args {
+		switch {
+		case arg.Token != "":
+			options[strings.ToUpper(arg.Token)] = optionFromArgument(arg)
+		case arg.Type == "oneof":
+			tokens := []string{}
+			for _, alt := range arg.Arguments {
+				if token := leadingToken(alt); token != "" {
+					tokens = append(tokens, strings.ToUpper(token))
+				}
+			}
+			for _, alt := range arg.Arguments {
+				token := strings.ToUpper(leadingToken(alt))
+				if token == "" {
+					continue
+				}
+				option := optionFromArgument(alt)
+				for _, other := range tokens {
+					if other != token {
+						option.Conflicts = append(option.Conflicts, other)
+					}
+				}
+				options[token] = option
+			}
+		case arg.Type == "block":
+			if token := leadingToken(arg); token != "" {
+				options[strings.ToUpper(token)] = optionFromArgument(arg)
+			}
+		}
+	}
+
+	if len(options) == 0 {
+		return nil
+	}
+	return options
+}
+
+// leadingToken devuelve el token con el que empieza un argumento, si lo hay
+func leadingToken(arg ArgumentSpec) string {
+	if arg.Token != "" {
+		return arg.Token
+	}
+	if arg.Type == "block" && len(arg.Arguments) > 0 {
+		return leadingToken(arg.Arguments[0])
+	}
+	return ""
+}
+
+// optionFromArgument construye un OptionSpec para un argumento con token
+func optionFromArgument(arg ArgumentSpec) OptionSpec {
+	option := OptionSpec{Description: arg.Name}
+
+	switch {
+	case arg.Type == "pure-token":
+		option.HasValue = false
+	case arg.Type == "block" && arg.Token == "":
+		// El token es el primer elemento del bloque; el valor es el segundo
+		if len(arg.Arguments) > 1 {
+			option.HasValue = true
+			option.ValueType = optionValueType(arg.Arguments[1].Type)
+		}
+	default:
+		option.HasValue = true
+		option.ValueType = optionValueType(arg.Type)
+	}
+
+	return option
+}
+
+// optionValueType traduce el tipo de un valor de opción
+func optionValueType(argType string) string {
+	switch argType {
+	case "integer", "unix-time":
+		return "integer"
+	case "double":
+		return "double"
+	case "pattern":
+		return "pattern"
+	case "string", "key":
+		return "string"
+	default:
+		return ""
+	}
+}
+
+// lowerAll convierte a minúsculas una lista de flags
+func lowerAll(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	result := make([]string, len(values))
+	for i, v := range values {
+		result[i] = strings.ToLower(v)
+	}
+	return result
+}
@@ -0,0 +1,251 @@
+package semantic
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"redis-analyzer-api/parser"
+)
+
+func TestDefaultCommandSpecs(t *testing.T) {
+	specs := DefaultCommandSpecs()
+
+	if len(specs) < 200 {
+		t.Fatalf("Expected the full command table, got %d commands", len(specs))
+	}
+
+	tests := []struct {
+		name        string
+		minArgs     int
+		maxArgs     int
+		keyPosition int
+		group       string
+		flag        string
+	}{
+		{name: "EXPIRE", minArgs: 2, maxArgs: -1, keyPosition: 0, group: "generic", flag: "write"},
+		{name: "LPUSH", minArgs: 2, maxArgs: -1, keyPosition: 0, group: "list", flag: "write"},
+		{name: "INCR", minArgs: 1, maxArgs: 1, keyPosition: 0, group: "string", flag: "write"},
+		{name: "PING", minArgs: 0, maxArgs: -1, keyPosition: -1, group: "connection", flag: "fast"},
+		{name: "FLUSHALL", minArgs: 0, maxArgs: -1, keyPosition: -1, group: "server", flag: "write"},
+		{name: "CLIENT KILL", minArgs: 1, maxArgs: -1, keyPosition: -1, group: "connection", flag: "admin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, exists := specs[tt.name]
+			if !exists {
+				t.Fatalf("Expected command spec for %s", tt.name)
+			}
+			if spec.MinArgs != tt.minArgs || spec.MaxArgs != tt.maxArgs {
+				t.Errorf("Expected args %d..%d, got %d..%d", tt.minArgs, tt.maxArgs, spec.MinArgs, spec.MaxArgs)
+			}
+			if spec.KeyPosition != tt.keyPosition {
+				t.Errorf("Expected key position %d, got %d", tt.keyPosition, spec.KeyPosition)
+			}
+			if spec.Group != tt.group {
+				t.Errorf("Expected group %s, got %s", tt.group, spec.Group)
+			}
+			if !spec.HasFlag(tt.flag) {
+				t.Errorf("Expected flag %s, got %v", tt.flag, spec.Flags)
+			}
+			if spec.Description == "" {
+				t.Errorf("Expected non-empty description")
+			}
+		})
+	}
+
+	client := specs["CLIENT"]
+	if len(client.Subcommands) == 0 {
+		t.Errorf("Expected CLIENT to list its subcommands")
+	}
+
+	// Modificar la copia no debe afectar a la tabla compartida
+	delete(specs, "GET")
+	if _, exists := DefaultCommandSpecs()["GET"]; !exists {
+		t.Errorf("DefaultCommandSpecs should return an independent copy")
+	}
+}
+
+func TestValidateLoadedCommands(t *testing.T) {
+	analyzer := New()
+
+	tests := []struct {
+		name          string
+		input         string
+		expectValid   bool
+		expectError   string
+		expectWarning string
+	}{
+		{name: "EXPIRE with integer", input: "EXPIRE mykey 60", expectValid: true},
+		{name: "EXPIRE with condition", input: "EXPIRE mykey 60 NX", expectValid: true},
+		{name: "EXPIRE with non-integer", input: "EXPIRE mykey soon", expectValid: false, expectError: "should be a seconds (integer)"},
+		{name: "LPUSH several elements", input: "LPUSH list a b c", expectValid: true},
+		{name: "LPUSH without elements", input: "LPUSH list", expectValid: false, expectError: "Too few arguments"},
+		{name: "INCR", input: "INCR counter", expectValid: true},
+		{name: "INCR extra argument", input: "INCR counter 1", expectValid: false, expectError: "Too many arguments"},
+		{name: "INCRBYFLOAT with float", input: "INCRBYFLOAT counter 1.5", expectValid: true},
+		{name: "Subcommand", input: "CLIENT KILL ID 42", expectValid: true},
+		{name: "Subcommand case insensitive", input: "config get maxmemory", expectValid: true},
+		{name: "Unknown subcommand", input: "CLIENT FROBNICATE", expectValid: false, expectError: "Unknown subcommand"},
+		{name: "Deprecated command", input: "GETSET mykey value", expectValid: true, expectWarning: "deprecated"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, parseErrors := parser.ParseCommand(tt.input)
+			if len(parseErrors) > 0 {
+				t.Fatalf("Parse error: %v", parseErrors)
+			}
+
+			result := analyzer.ValidateCommand(cmd)
+
+			if result.Valid != tt.expectValid {
+				t.Errorf("Expected valid=%v, got valid=%v (errors: %v)", tt.expectValid, result.Valid, result.Errors)
+			}
+
+			if tt.expectError != "" {
+				found := false
+				for _, err := range result.Errors {
+					if contains(err.Message, tt.expectError) {
+						found = true
+						break
+					}
+				}
+				if !found {
+					t.Errorf("Expected error containing '%s', got errors: %v", tt.expectError, result.Errors)
+				}
+			}
+
+			if tt.expectWarning != "" {
+				found := false
+				for _, warning := range result.Warnings {
+					if contains(warning, tt.expectWarning) {
+						found = true
+						break
+					}
+				}
+				if !found {
+					t.Errorf("Expected warning containing '%s', got warnings: %v", tt.expectWarning, result.Warnings)
+				}
+			}
+		})
+	}
+}
+
+func TestParseCommandsJSON(t *testing.T) {
+	input := `{
+		"MYCMD": {
+			"summary": "A custom command",
+			"since": "1.0.0",
+			"group": "custom",
+			"arity": -3,
+			"key_specs": [
+				{
+					"flags": ["RW"],
+					"begin_search": {"type": "index", "spec": {"index": 1}},
+					"find_keys": {"type": "range", "spec": {"lastkey": 0, "step": 1, "limit": 0}}
+				}
+			],
+			"arguments": [
+				{"name": "key", "type": "key", "key_spec_index": 0},
+				{"name": "count", "type": "integer"},
+				{"name": "condition", "type": "oneof", "optional": true, "arguments": [
+					{"name": "nx", "type": "pure-token", "token": "NX"},
+					{"name": "xx", "type": "pure-token", "token": "XX"}
+				]},
+				{"name": "pattern", "type": "pattern", "token": "MATCH", "optional": true}
+			],
+			"command_flags": ["WRITE"]
+		}
+	}`
+
+	specs, err := ParseCommandsJSON(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	spec, exists := specs["MYCMD"]
+	if !exists {
+		t.Fatalf("Expected MYCMD spec")
+	}
+
+	if spec.MinArgs != 2 || spec.MaxArgs != -1 {
+		t.Errorf("Expected args 2..-1, got %d..%d", spec.MinArgs, spec.MaxArgs)
+	}
+	if spec.KeyPosition != 0 {
+		t.Errorf("Expected key position 0, got %d", spec.KeyPosition)
+	}
+	if len(spec.ValueTypes) != 2 || spec.ValueTypes[0] != "key" || spec.ValueTypes[1] != "integer" {
+		t.Errorf("Unexpected value types: %v", spec.ValueTypes)
+	}
+	if !spec.HasFlag("write") {
+		t.Errorf("Expected write flag, got %v", spec.Flags)
+	}
+	if len(spec.KeySpecs) != 1 || spec.KeySpecs[0].BeginSearch != "index" || spec.KeySpecs[0].Index != 1 {
+		t.Errorf("Unexpected key specs: %+v", spec.KeySpecs)
+	}
+	if spec.Arguments[0].KeySpecIndex != 0 || spec.Arguments[1].KeySpecIndex != -1 {
+		t.Errorf("Unexpected key spec indexes: %d, %d", spec.Arguments[0].KeySpecIndex, spec.Arguments[1].KeySpecIndex)
+	}
+
+	nx, exists := spec.Options["NX"]
+	if !exists || nx.HasValue || len(nx.Conflicts) != 1 || nx.Conflicts[0] != "XX" {
+		t.Errorf("Unexpected NX option: %+v", nx)
+	}
+	match, exists := spec.Options["MATCH"]
+	if !exists || !match.HasValue || match.ValueType != "pattern" {
+		t.Errorf("Unexpected MATCH option: %+v", match)
+	}
+
+	if _, err := ParseCommandsJSON(strings.NewReader("{invalid")); err == nil {
+		t.Errorf("Expected error for invalid JSON")
+	}
+}
+
+func TestAnalyzerLoadCommandsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "commands.json")
+	content := `{"PING": {"summary": "Ping", "group": "connection", "arity": -1}}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write commands file: %v", err)
+	}
+
+	analyzer := New()
+	if err := analyzer.LoadCommandsFile(path); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	specs := analyzer.GetCommandSpecs()
+	if _, exists := specs["PING"]; !exists {
+		t.Errorf("Expected PING from the loaded file")
+	}
+	if _, exists := specs["LPUSH"]; exists {
+		t.Errorf("Loading a file should replace the command table")
+	}
+
+	// Los comandos básicos que faltan se completan con su especificación
+	// escrita a mano
+	if set, exists := specs["SET"]; !exists || len(set.Arguments) != 0 || set.Options["EX"].ValueType != "integer" {
+		t.Errorf("Expected the hand-written SET spec after loading, got %+v", set)
+	}
+
+	if err := analyzer.LoadCommandsFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Errorf("Expected error for missing file")
+	}
+}
+
+func TestLoadedSpecsWin(t *testing.T) {
+	// Si la tabla cargada tiene el comando, su especificación se usa entera
+	loaded := DefaultCommandSpecs()
+	specs := New().GetCommandSpecs()
+	for _, name := range []string{"GET", "SET", "DEL", "HGET", "HSET", "ZADD", "ZRANGE", "SCAN"} {
+		spec := specs[name]
+		if !reflect.DeepEqual(spec, loaded[name]) {
+			t.Errorf("%s: expected the loaded spec, got %+v", name, spec)
+		}
+	}
+	if _, exists := specs["SET"].Options["GET"]; !exists {
+		t.Errorf("Expected the SET options from the loaded grammar")
+	}
+}
@@ -0,0 +1,86 @@
+package semantic
+
+import (
+	"redis-analyzer-api/parser"
+)
+
+// FormatOptions devuelve las opciones de parser.FormatWithOptions que
+// dependen de la gramática: los subcomandos y los tokens de opción se
+// escriben en mayúsculas, y la lista repetida con la que terminan comandos
+// como HSET, ZADD o MSET se reparte en líneas si no cabe
+func (a *Analyzer) FormatOptions() parser.FormatOptions {
+	return parser.FormatOptions{
+		Keywords: a.keywordArguments,
+		Wrap:     a.repeatedArguments,
+	}
+}
+
+// grammarBindings enlaza los argumentos del comando con su gramática.
+// offset es el número de argumentos que ocupa el subcomando (0 o 1).
+func (a *Analyzer) grammarBindings(cmd *parser.RedisCommand) (spec CommandSpec, bindings []ArgumentBinding, offset int, ok bool) {
+	spec, exists := a.lookupCommand(cmd)
+	if !exists {
+		return spec, nil, 0, false
+	}
+	if spec.Container != "" {
+		offset = 1
+	}
+	bindings, err := MatchArguments(spec.Arguments, cmd.Arguments[offset:])
+	return spec, bindings, offset, err == nil
+}
+
+// keywordArguments marca el subcomando y los argumentos enlazados a un token
+// de la gramática. Si los argumentos no encajan solo se marca el subcomando,
+// ya que escribir en mayúsculas un valor cambiaría el comando.
+func (a *Analyzer) keywordArguments(cmd *parser.RedisCommand) []bool {
+	keywords := make([]bool, len(cmd.Arguments))
+	_, bindings, offset, ok := a.grammarBindings(cmd)
+	if offset == 1 {
+		keywords[0] = true
+	}
+	if ok {
+		for _, binding := range bindings {
+			if binding.IsToken {
+				keywords[binding.Index+offset] = true
+			}
+		}
+	}
+	return keywords
+}
+
+// repeatedArguments devuelve dónde empieza la lista con la que termina el
+// comando y cuántos argumentos tiene cada elemento. Solo se consideran las
+// listas de valores simples sin token (key value, score member...).
+func (a *Analyzer) repeatedArguments(cmd *parser.RedisCommand) (start, size int, ok bool) {
+	spec, bindings, offset, ok := a.grammarBindings(cmd)
+	if !ok || len(spec.Arguments) == 0 {
+		return 0, 0, false
+	}
+
+	last := &spec.Arguments[len(spec.Arguments)-1]
+	if !last.Multiple || last.Token != "" || last.Type == "oneof" || last.Type == "pure-token" {
+		return 0, 0, false
+	}
+	elements := []*ArgumentSpec{last}
+	if last.Type == "block" {
+		elements = nil
+		for i := range last.Arguments {
+			elem := &last.Arguments[i]
+			if elem.Optional || elem.Multiple || elem.Token != "" || elem.Type == "block" || elem.Type == "oneof" {
+				return 0, 0, false
+			}
+			elements = append(elements, elem)
+		}
+	}
+
+	for _, binding := range bindings {
+		for _, elem := range elements {
+			if binding.Element == elem {
+				start = binding.Index
+				size = len(elements)
+				return start + offset, size, (len(cmd.Arguments)-offset-start)%size == 0
+			}
+		}
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,78 @@
+package semantic
+
+import (
+	"strings"
+	"testing"
+
+	"redis-analyzer-api/parser"
+)
+
+func TestFormatOptions(t *testing.T) {
+	input := strings.Join([]string{
+		"set ex ex ex 10 nx",
+		"client kill id 5",
+		"lmpop 2 a b left count 3",
+		"get k count",
+		"foo bar ex",
+		"zadd scores nx ch 1 alice 2 bob 3 carol 4 dave 5 erin 6 frank 7 grace 8 heidi 9 ivan",
+		"mset a 1 b 2",
+	}, "\n")
+
+	expected := strings.Join([]string{
+		"SET ex ex EX 10 NX",
+		"CLIENT KILL ID 5",
+		"LMPOP 2 a b LEFT COUNT 3",
+		"GET k count",
+		"FOO bar ex",
+		"ZADD scores NX CH \\",
+		"  1 alice \\",
+		"  2 bob \\",
+		"  3 carol \\",
+		"  4 dave \\",
+		"  5 erin \\",
+		"  6 frank \\",
+		"  7 grace \\",
+		"  8 heidi \\",
+		"  9 ivan",
+		"MSET a 1 b 2",
+		"",
+	}, "\n")
+
+	program, diagnostics := parser.ParseCommandsWithDiagnostics(input)
+	if len(diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics: %v", diagnostics)
+	}
+	formatted := parser.FormatWithOptions(program, New().FormatOptions())
+	if formatted != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, formatted)
+	}
+}
+
+func TestRepeatedArguments(t *testing.T) {
+	a := New()
+
+	tests := []struct {
+		input string
+		start int
+		size  int
+		ok    bool
+	}{
+		{input: "HSET h f v g w", start: 1, size: 2, ok: true},
+		{input: "ZADD z NX 1 a", start: 2, size: 2, ok: true},
+		{input: "MSET a 1 b 2", start: 0, size: 2, ok: true},
+		{input: "RPUSH l a b c", start: 1, size: 1, ok: true},
+		{input: "GEOADD g 1 2 a", start: 1, size: 3, ok: true},
+		{input: "GET k", ok: false},
+		{input: "HSET h f", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			cmd, _ := parser.ParseCommand(tt.input)
+			start, size, ok := a.repeatedArguments(cmd)
+			if ok != tt.ok || (ok && (start != tt.start || size != tt.size)) {
+				t.Errorf("Expected (%d, %d, %v), got (%d, %d, %v)", tt.start, tt.size, tt.ok, start, size, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,482 @@
+package semantic
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"redis-analyzer-api/parser"
+)
+
+// ArgumentBinding relaciona un argumento del comando con el elemento de la
+// gramática que lo ha consumido
+type ArgumentBinding struct {
+	Index   int           // posición del argumento en cmd.Arguments
+	Element *ArgumentSpec // elemento de la gramática
+	Parent  *ArgumentSpec // oneof al que pertenece el elemento, si lo hay
+	IsToken bool          // true si el argumento es el token del elemento
+}
+
+// grammarFailure describe por qué un elemento de la gramática no encajó
+type grammarFailure struct {
+	kind     string // "token", "type", "missing" o "unexpected"
+	soft     bool   // fallo al intentar un elemento opcional o una repetición extra
+	element  *ArgumentSpec
+	token    string // token ya consumido cuando falta su valor
+	bindings []ArgumentBinding
+}
+
+// grammarMatcher compara los argumentos de un comando con su gramática
+// mediante backtracking, recordando los fallos en la posición más lejana
+type grammarMatcher struct {
+	args     []parser.Expression
+	bindings []ArgumentBinding
+
+	farthest int
+	failures []grammarFailure
+	soft     int // posición en la que empezó el intento opcional en curso
+}
+
+// MatchArguments compara los argumentos con la gramática del comando.
+// Devuelve los argumentos enlazados a su elemento, o un error semántico
+// que nombra el elemento de la gramática que falló.
+func MatchArguments(grammar []ArgumentSpec, args []parser.Expression) ([]ArgumentBinding, *SemanticError) {
+	m := &grammarMatcher{args: args, farthest: -1, soft: -1}
+
+	matched := m.matchSequence(grammar, 0, func(pos int) bool {
+		if pos == len(m.args) {
+			return true
+		}
+		m.fail(pos, grammarFailure{kind: "unexpected"})
+		return false
+	})
+	if matched {
+		return m.bindings, nil
+	}
+
+	return nil, m.error()
+}
+
+// OptionValues devuelve los argumentos que la gramática del comando enlaza
+// como valor del token option (100 en SCAN 0 COUNT 100). Una palabra igual
+// al token en la posición de otro valor (SCAN 0 MATCH COUNT) no cuenta. ok
+// es false si el comando no tiene gramática o sus argumentos no encajan.
+func (a *Analyzer) OptionValues(cmd *parser.RedisCommand, option string) (values []parser.Expression, ok bool) {
+	spec, bindings, offset, ok := a.grammarBindings(cmd)
+	if !ok || len(spec.Arguments) == 0 {
+		return nil, false
+	}
+	for i, binding := range bindings {
+		if !binding.IsToken || !strings.EqualFold(binding.Element.Token, option) || i+1 == len(bindings) {
+			continue
+		}
+		if next := bindings[i+1]; !next.IsToken && next.Index == binding.Index+1 {
+			values = append(values, cmd.Arguments[next.Index+offset])
+		}
+	}
+	return values, true
+}
+
+// NextArgument es un elemento de la gramática que puede escribirse a
+// continuación de los argumentos de un comando
+type NextArgument struct {
+	Element  *ArgumentSpec
+	IsToken  bool   // se espera el token del elemento; si no, su valor
+	Token    string // token ya escrito cuando se espera su valor
+	Optional bool   // el comando es válido sin este elemento
+}
+
+// ExpectedArguments devuelve los elementos de la gramática que pueden seguir
+// a args, en el orden de la gramática. Devuelve nil si algún argumento ya no
+// encaja o si la gramática no admite más argumentos.
+func ExpectedArguments(grammar []ArgumentSpec, args []parser.Expression) []NextArgument {
+	m := &grammarMatcher{args: args, farthest: -1, soft: -1}
+	m.matchSequence(grammar, 0, func(pos int) bool {
+		if pos == len(m.args) {
+			return true
+		}
+		m.fail(pos, grammarFailure{kind: "unexpected"})
+		return false
+	})
+	if m.farthest != len(args) {
+		return nil
+	}
+
+	var next []NextArgument
+	seen := map[*ArgumentSpec]bool{}
+	for _, f := range m.failures {
+		if (f.kind != "token" && f.kind != "missing") || seen[f.element] {
+			continue
+		}
+		seen[f.element] = true
+		next = append(next, NextArgument{
+			Element:  f.element,
+			IsToken:  f.kind == "token",
+			Token:    f.token,
+			Optional: f.soft,
+		})
+	}
+	return next
+}
+
+// NextArguments devuelve lo que puede escribirse después de los argumentos
+// del comando según su gramática, resolviendo los subcomandos
+func (a *Analyzer) NextArguments(cmd *parser.RedisCommand) []NextArgument {
+	spec, exists := a.lookupCommand(cmd)
+	if !exists {
+		return nil
+	}
+	args := cmd.Arguments
+	if spec.Container != "" {
+		args = args[1:]
+	}
+	return ExpectedArguments(spec.Arguments, args)
+}
+
+// fail registra un fallo; solo se conservan los de la posición más lejana
+func (m *grammarMatcher) fail(pos int, failure grammarFailure) {
+	if pos < m.farthest {
+		return
+	}
+	if pos > m.farthest {
+		m.farthest = pos
+		m.failures = nil
+	}
+	failure.soft = pos == m.soft
+	failure.bindings = append([]ArgumentBinding(nil), m.bindings...)
+	m.failures = append(m.failures, failure)
+}
+
+// attempt ejecuta un intento opcional: sus fallos en pos son "blandos" y se
+// usan en los mensajes solo si no hay un fallo obligatorio más preciso
+func (m *grammarMatcher) attempt(pos int, try func() bool) bool {
+	prev := m.soft
+	m.soft = pos
+	ok := try()
+	m.soft = prev
+	return ok
+}
+
+// bind enlaza un argumento y continúa; deshace el enlace si la continuación falla
+func (m *grammarMatcher) bind(binding ArgumentBinding, next func() bool) bool {
+	m.bindings = append(m.bindings, binding)
+	if next() {
+		return true
+	}
+	m.bindings = m.bindings[:len(m.bindings)-1]
+	return false
+}
+
+// matchSequence encaja una secuencia de elementos a partir de pos.
+// Las opciones con token consecutivas se aceptan en cualquier orden,
+// igual que hace Redis al procesar SET, ZADD o SCAN.
+func (m *grammarMatcher) matchSequence(elems []ArgumentSpec, pos int, k func(int) bool) bool {
+	if len(elems) == 0 {
+		return k(pos)
+	}
+
+	if isUnorderedOption(elems[0]) {
+		end := 1
+		for end < len(elems) && isUnorderedOption(elems[end]) {
+			end++
+		}
+		return m.matchOptions(elems[:end], make([]bool, end), pos, func(p int) bool {
+			return m.matchSequence(elems[end:], p, k)
+		})
+	}
+
+	elem := &elems[0]
+	next := func(p int) bool { return m.matchSequence(elems[1:], p, k) }
+
+	if !elem.Optional {
+		return m.matchRepeated(elem, nil, pos, next)
+	}
+	if m.attempt(pos, func() bool { return m.matchRepeated(elem, nil, pos, next) }) {
+		return true
+	}
+	return next(pos)
+}
+
+// matchOptions encaja un grupo de opciones con token en cualquier orden
+func (m *grammarMatcher) matchOptions(options []ArgumentSpec, used []bool, pos int, k func(int) bool) bool {
+	for i := range options {
+		if used[i] {
+			continue
+		}
+		used[i] = true
+		ok := m.attempt(pos, func() bool {
+			return m.matchRepeated(&options[i], nil, pos, func(p int) bool {
+				return m.matchOptions(options, used, p, k)
+			})
+		})
+		used[i] = false
+		if ok {
+			return true
+		}
+	}
+	return k(pos)
+}
+
+// matchRepeated encaja un elemento una vez o, si es múltiple, una o más veces
+func (m *grammarMatcher) matchRepeated(elem, parent *ArgumentSpec, pos int, k func(int) bool) bool {
+	if !elem.Multiple {
+		return m.matchElement(elem, parent, pos, true, k)
+	}
+
+	var repeat func(p int, first bool) bool
+	repeat = func(p int, first bool) bool {
+		return m.matchElement(elem, parent, p, first || elem.MultipleToken, func(q int) bool {
+			if q == p {
+				return false
+			}
+			extra := m.attempt(q, func() bool { return repeat(q, false) })
+			return extra || k(q)
+		})
+	}
+	return repeat(pos, true)
+}
+
+// matchElement encaja una única aparición de un elemento
+func (m *grammarMatcher) matchElement(elem, parent *ArgumentSpec, pos int, withToken bool, k func(int) bool) bool {
+	if withToken && elem.Token != "" {
+		if pos >= len(m.args) || !strings.EqualFold(argumentText(m.args[pos]), elem.Token) {
+			m.fail(pos, grammarFailure{kind: "token", element: elem})
+			return false
+		}
+		return m.bind(ArgumentBinding{Index: pos, Element: elem, Parent: parent, IsToken: true}, func() bool {
+			return m.matchValue(elem, parent, pos+1, elem.Token, k)
+		})
+	}
+	return m.matchValue(elem, parent, pos, "", k)
+}
+
+// matchValue encaja el contenido de un elemento (tras su token, si lo tiene)
+func (m *grammarMatcher) matchValue(elem, parent *ArgumentSpec, pos int, token string, k func(int) bool) bool {
+	switch elem.Type {
+	case "pure-token":
+		return k(pos)
+	case "oneof":
+		for i := range elem.Arguments {
+			if m.matchRepeated(&elem.Arguments[i], elem, pos, k) {
+				return true
+			}
+		}
+		return false
+	case "block":
+		return m.matchSequence(elem.Arguments, pos, k)
+	}
+
+	if pos >= len(m.args) {
+		m.fail(pos, grammarFailure{kind: "missing", element: elem, token: token})
+		return false
+	}
+	if !argumentMatchesType(m.args[pos], elem.Type) {
+		m.fail(pos, grammarFailure{kind: "type", element: elem, token: token})
+		return false
+	}
+	return m.bind(ArgumentBinding{Index: pos, Element: elem, Parent: parent}, func() bool {
+		return k(pos + 1)
+	})
+}
+
+// error construye el error semántico a partir de los fallos más lejanos
+func (m *grammarMatcher) error() *SemanticError {
+	pos := m.farthest
+
+	// Una opción incompatible con otra ya usada explica mejor el fallo
+	if pos < len(m.args) {
+		text := strings.ToUpper(argumentText(m.args[pos]))
+		for _, f := range m.failures {
+			if err := optionConflict(text, f.bindings, m.spanAt(pos)); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Después, los fallos de valor de elementos obligatorios
+	for _, f := range m.failures {
+		if !f.soft && (f.kind == "type" || f.kind == "missing") {
+			return m.valueError(f)
+		}
+	}
+
+	expected := m.expectedTokens(false)
+	if len(expected) == 0 {
+		for _, f := range m.failures {
+			if f.kind == "type" || f.kind == "missing" {
+				return m.valueError(f)
+			}
+		}
+		expected = m.expectedTokens(true)
+	}
+
+	if pos >= len(m.args) {
+		return &SemanticError{
+			Message:  fmt.Sprintf("Missing required argument: expected one of %s", strings.Join(expected, ", ")),
+			Span:     m.spanAt(pos),
+			Type:     "INSUFFICIENT_ARGS",
+		}
+	}
+
+	message := fmt.Sprintf("Unexpected argument '%s' at position %d", argumentText(m.args[pos]), pos+1)
+	if len(expected) > 0 {
+		message += fmt.Sprintf(" (expected one of %s)", strings.Join(expected, ", "))
+	}
+	return &SemanticError{
+		Message:  message,
+		Span:     m.spanAt(pos),
+		Type:     "UNEXPECTED_ARGUMENT",
+	}
+}
+
+// expectedTokens lista los tokens esperados en la posición del fallo
+func (m *grammarMatcher) expectedTokens(includeSoft bool) []string {
+	expected := []string{}
+	for _, f := range m.failures {
+		if f.kind == "token" && (includeSoft || !f.soft) && !containsString(expected, f.element.Token) {
+			expected = append(expected, f.element.Token)
+		}
+	}
+	return expected
+}
+
+// valueError describe un valor ausente o de tipo incorrecto
+func (m *grammarMatcher) valueError(f grammarFailure) *SemanticError {
+	pos := m.farthest
+
+	switch {
+	case f.kind == "missing" && f.token != "":
+		return &SemanticError{
+			Message:  fmt.Sprintf("Option '%s' requires a value (%s)", strings.ToUpper(f.token), f.element.Name),
+			Span:     m.spanAt(pos),
+			Type:     "MISSING_OPTION_VALUE",
+		}
+	case f.kind == "missing":
+		return &SemanticError{
+			Message:  fmt.Sprintf("Missing required argument '%s' (%s)", f.element.Name, f.element.Type),
+			Span:     m.spanAt(pos),
+			Type:     "INSUFFICIENT_ARGS",
+		}
+	default:
+		return &SemanticError{
+			Message:  fmt.Sprintf("Argument %d should be %s, got %s", pos+1, describeElement(f.element), m.args[pos].Type()),
+			Span:     m.spanAt(pos),
+			Type:     "TYPE_MISMATCH",
+		}
+	}
+}
+
+// spanAt devuelve el rango del argumento en pos; si pos está al final, un
+// rango vacío tras el último argumento
+func (m *grammarMatcher) spanAt(pos int) parser.Span {
+	if pos < len(m.args) {
+		return m.args[pos].Span()
+	}
+	if len(m.args) == 0 {
+		return parser.Span{}
+	}
+	end := m.args[len(m.args)-1].Span().End
+	return parser.Span{Start: end, End: end}
+}
+
+// optionConflict detecta si un token sobrante repite una opción ya usada o
+// es una alternativa de un oneof del que ya se eligió otra opción
+func optionConflict(token string, bindings []ArgumentBinding, span parser.Span) *SemanticError {
+	for _, b := range bindings {
+		if !b.IsToken {
+			continue
+		}
+		used := strings.ToUpper(b.Element.Token)
+		if used == token && !b.Element.Multiple {
+			return &SemanticError{
+				Message:  fmt.Sprintf("Option '%s' specified more than once", token),
+				Span:     span,
+				Type:     "DUPLICATE_OPTION",
+			}
+		}
+		if b.Parent == nil {
+			continue
+		}
+		for _, alt := range b.Parent.Arguments {
+			if strings.ToUpper(leadingToken(alt)) == token {
+				return &SemanticError{
+					Message:  fmt.Sprintf("Option '%s' conflicts with '%s' (%s)", token, used, b.Parent.Name),
+					Span:     span,
+					Type:     "OPTION_CONFLICT",
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// isUnorderedOption indica si un elemento es una opción introducida por token
+// (o un oneof de opciones con token) que puede aparecer en cualquier orden
+func isUnorderedOption(elem ArgumentSpec) bool {
+	if !elem.Optional {
+		return false
+	}
+	if elem.Type == "oneof" && elem.Token == "" {
+		for _, alt := range elem.Arguments {
+			if leadingToken(alt) == "" {
+				return false
+			}
+		}
+		return len(elem.Arguments) > 0
+	}
+	return leadingToken(elem) != ""
+}
+
+// argumentMatchesType comprueba si un argumento es válido para un tipo de la gramática
+func argumentMatchesType(arg parser.Expression, argType string) bool {
+	// Una opción con valor ocupa dos posiciones de argv
+	if opt, ok := arg.(*parser.OptionExpression); ok && opt.Value != nil {
+		return false
+	}
+
+	switch argType {
+	case "integer", "unix-time":
+		return isIntegerArgument(arg)
+	case "double":
+		return isDoubleArgument(arg)
+	default:
+		// key, string y pattern aceptan cualquier secuencia de bytes: también
+		// un rango como [1,2], que Redis recibe como un único argumento
+		return true
+	}
+}
+
+// describeElement describe lo que se esperaba de un elemento para los errores
+func describeElement(elem *ArgumentSpec) string {
+	switch elem.Type {
+	case "key":
+		return fmt.Sprintf("a key (%s)", elem.Name)
+	case "integer":
+		return fmt.Sprintf("a %s (integer)", elem.Name)
+	case "unix-time":
+		return fmt.Sprintf("a %s (unix time)", elem.Name)
+	case "double":
+		return fmt.Sprintf("a numeric %s", elem.Name)
+	default:
+		return fmt.Sprintf("a %s (%s)", elem.Name, elem.Type)
+	}
+}
+
+// parseDouble interpreta un número como lo hace Redis (acepta inf, no NaN)
+func parseDouble(s string) (float64, bool) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) {
+		return 0, false
+	}
+	return f, true
+}
+
+// containsString indica si una lista contiene una cadena
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
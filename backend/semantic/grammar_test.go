@@ -0,0 +1,216 @@
+package semantic
+
+import (
+	"testing"
+	"redis-analyzer-api/parser"
+)
+
+func TestValidateGrammar(t *testing.T) {
+	analyzer := New()
+
+	tests := []struct {
+		name        string
+		input       string
+		expectValid bool
+		expectError string
+		expectType  string
+	}{
+		{name: "ZADD with condition before score", input: "ZADD k NX 1 a", expectValid: true},
+		{name: "ZADD options in any order", input: "ZADD k GT CH NX 1 a 2 b", expectValid: true},
+		{name: "ZADD conflicting condition", input: "ZADD k NX XX 1 a", expectValid: false, expectError: "conflicts with 'NX'", expectType: "OPTION_CONFLICT"},
+		{name: "ZADD member without score", input: "ZADD k 1 a 2", expectValid: false, expectError: "Missing required argument 'member'", expectType: "INSUFFICIENT_ARGS"},
+		{name: "ZADD non-numeric score after option", input: "ZADD k NX abc a", expectValid: false, expectError: "should be a numeric score", expectType: "TYPE_MISMATCH"},
+		{name: "SET options in any order", input: `SET k "v" EX 10 NX`, expectValid: true},
+		{name: "SET with GET and KEEPTTL", input: `SET k "v" GET KEEPTTL`, expectValid: true},
+		{name: "SET expiration conflict", input: `SET k "v" KEEPTTL EX 10`, expectValid: false, expectError: "conflicts with 'KEEPTTL'", expectType: "OPTION_CONFLICT"},
+		{name: "SET duplicated option", input: `SET k "v" GET GET`, expectValid: false, expectError: "specified more than once", expectType: "DUPLICATE_OPTION"},
+		{name: "SET unknown option", input: `SET k "v" FOO`, expectValid: false, expectError: "Unexpected argument 'FOO'", expectType: "UNEXPECTED_ARGUMENT"},
+		{name: "SET non-integer expiration", input: `SET k "v" EX soon`, expectValid: false, expectError: "should be a seconds (integer)", expectType: "TYPE_MISMATCH"},
+		{name: "SET value equal to an option name", input: "SET k NX", expectValid: true},
+		{name: "SCAN options in any order", input: "SCAN 0 COUNT 10 MATCH user:*", expectValid: true},
+		{name: "ZRANGE with LIMIT block", input: "ZRANGE k 0 -1 BYSCORE LIMIT 0 10 WITHSCORES", expectValid: true},
+		{name: "ZRANGE LIMIT missing count", input: "ZRANGE k 0 -1 LIMIT 0", expectValid: false, expectError: "Missing required argument 'count'"},
+		{name: "BLPOP several keys and timeout", input: "BLPOP a b c 5", expectValid: true},
+		{name: "LMPOP with direction and count", input: "LMPOP 2 a b LEFT COUNT 3", expectValid: true},
+		{name: "LMPOP without direction", input: "LMPOP 2 a b", expectValid: false, expectError: "expected one of LEFT, RIGHT"},
+		{name: "HSET incomplete pair", input: "HSET h f1 v1 f2", expectValid: false, expectError: "Missing required argument 'value'"},
+		{name: "EXPIRE conflicting condition", input: "EXPIRE k 10 NX GT", expectValid: false, expectError: "conflicts with 'NX'"},
+		{name: "SET range as value", input: "SET k [1,2]", expectValid: true},
+		{name: "GET range as key", input: "GET [1,2]", expectValid: true},
+		{name: "SET range as expiration", input: "SET k v EX [1,2]", expectValid: false, expectError: "should be a seconds (integer)", expectType: "TYPE_MISMATCH"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, parseErrors := parser.ParseCommand(tt.input)
+			if len(parseErrors) > 0 {
+				t.Fatalf("Parse error: %v", parseErrors)
+			}
+
+			result := analyzer.ValidateCommand(cmd)
+
+			if result.Valid != tt.expectValid {
+				t.Errorf("Expected valid=%v, got valid=%v. Errors: %v", tt.expectValid, result.Valid, result.Errors)
+			}
+
+			if tt.expectError != "" {
+				found := false
+				for _, err := range result.Errors {
+					if contains(err.Message, tt.expectError) && (tt.expectType == "" || err.Type == tt.expectType) {
+						found = true
+						break
+					}
+				}
+				if !found {
+					t.Errorf("Expected %s error containing '%s', got errors: %v", tt.expectType, tt.expectError, result.Errors)
+				}
+			}
+		})
+	}
+}
+
+func TestArgumentMatchesType(t *testing.T) {
+	value := &parser.Identifier{Value: "v"}
+	tests := []struct {
+		arg      parser.Expression
+		argType  string
+		expected bool
+	}{
+		{&parser.RangeExpression{Start: &parser.IntegerLiteral{Value: 1}, End: &parser.IntegerLiteral{Value: 2}}, "string", true},
+		{&parser.RangeExpression{Start: &parser.IntegerLiteral{Value: 1}, End: &parser.IntegerLiteral{Value: 2}}, "integer", false},
+		{&parser.OptionExpression{Option: value}, "key", true},
+		{&parser.OptionExpression{Option: value, Value: value}, "string", false},
+	}
+	for _, tt := range tests {
+		if got := argumentMatchesType(tt.arg, tt.argType); got != tt.expected {
+			t.Errorf("argumentMatchesType(%s, %s): expected %v, got %v", tt.arg, tt.argType, tt.expected, got)
+		}
+	}
+}
+
+func TestMatchArgumentsBindings(t *testing.T) {
+	spec := DefaultCommandSpecs()["ZADD"]
+
+	cmd, parseErrors := parser.ParseCommand("ZADD k XX 1 a")
+	if len(parseErrors) > 0 {
+		t.Fatalf("Parse error: %v", parseErrors)
+	}
+
+	bindings, err := MatchArguments(spec.Arguments, cmd.Arguments)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []struct {
+		name    string
+		isToken bool
+	}{
+		{"key", false},
+		{"xx", true},
+		{"score", false},
+		{"member", false},
+	}
+
+	if len(bindings) != len(expected) {
+		t.Fatalf("Expected %d bindings, got %d", len(expected), len(bindings))
+	}
+	for i, exp := range expected {
+		if bindings[i].Index != i || bindings[i].Element.Name != exp.name || bindings[i].IsToken != exp.isToken {
+			t.Errorf("Binding %d: expected %s (token=%v), got %s (token=%v) at %d",
+				i, exp.name, exp.isToken, bindings[i].Element.Name, bindings[i].IsToken, bindings[i].Index)
+		}
+	}
+	if bindings[1].Parent == nil || bindings[1].Parent.Name != "condition" {
+		t.Errorf("Expected XX to belong to the 'condition' oneof")
+	}
+
+	// El error se ancla al argumento que no encaja
+	cmd, _ = parser.ParseCommand("ZADD k 1 a b")
+	if _, err := MatchArguments(spec.Arguments, cmd.Arguments); err == nil || err.Span != cmd.Arguments[3].Span() {
+		t.Errorf("Expected error anchored to argument 4, got %+v", err)
+	}
+}
+
+func TestOptionValues(t *testing.T) {
+	analyzer := New()
+
+	tests := []struct {
+		input    string
+		option   string
+		expected []string
+	}{
+		{"SCAN 0 COUNT 100", "count", []string{"100"}},
+		{"SCAN 0 MATCH COUNT COUNT 5", "COUNT", []string{"5"}},
+		{"SCAN 0 MATCH COUNT", "COUNT", nil},
+		{"SET k v EX 10", "EX", []string{"10"}},
+		{"ZRANGE k 0 -1 BYSCORE LIMIT 2 10", "LIMIT", []string{"2"}},
+	}
+
+	for _, tt := range tests {
+		cmd, _ := parser.ParseCommand(tt.input)
+		values, ok := analyzer.OptionValues(cmd, tt.option)
+		got := []string{}
+		for _, value := range values {
+			got = append(got, value.String())
+		}
+		if !ok || len(got) != len(tt.expected) || (len(got) > 0 && got[0] != tt.expected[0]) {
+			t.Errorf("%s: expected %v, got %v (ok=%v)", tt.input, tt.expected, got, ok)
+		}
+	}
+
+	cmd, _ := parser.ParseCommand("SCAN 0 COUNT")
+	if _, ok := analyzer.OptionValues(cmd, "COUNT"); ok {
+		t.Errorf("Expected ok=false when the arguments do not match the grammar")
+	}
+}
+
+func TestNextArguments(t *testing.T) {
+	analyzer := New()
+
+	tests := []struct {
+		input    string
+		expected []string // token, o nombre del valor, de cada elemento esperado
+		optional bool     // todos los elementos esperados son opcionales
+	}{
+		{input: "SET", expected: []string{"key"}},
+		{input: "SET k v EX", expected: []string{"seconds"}},
+		{input: "SET k v NX", expected: []string{"GET", "EX", "PX", "EXAT", "PXAT", "KEEPTTL"}, optional: true},
+		{input: "EXPIRE k 10", expected: []string{"NX", "XX", "GT", "LT"}, optional: true},
+		{input: "CLIENT KILL", expected: []string{"ip-port", "ID", "TYPE"}, optional: true},
+		{input: "GET k", expected: nil},
+		{input: "GET k extra", expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			cmd, parseErrors := parser.ParseCommand(tt.input)
+			if len(parseErrors) > 0 {
+				t.Fatalf("Parse error: %v", parseErrors)
+			}
+
+			got := []string{}
+			for _, next := range analyzer.NextArguments(cmd) {
+				if next.Optional != tt.optional {
+					t.Errorf("Expected %s to have optional=%v", next.Element.Name, tt.optional)
+				}
+				if next.IsToken {
+					got = append(got, next.Element.Token)
+				} else {
+					got = append(got, next.Element.Name)
+				}
+			}
+			if len(got) < len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, got)
+			}
+			for i, name := range tt.expected {
+				if got[i] != name {
+					t.Errorf("Expected %v, got %v", tt.expected, got)
+					break
+				}
+			}
+			if tt.expected == nil && len(got) > 0 {
+				t.Errorf("Expected nothing else, got %v", got)
+			}
+		})
+	}
+}
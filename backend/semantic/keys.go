@@ -0,0 +1,172 @@
+package semantic
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"redis-analyzer-api/parser"
+)
+
+// KeyRef describe un argumento de un comando que Redis trata como clave
+type KeyRef struct {
+	Name    string // nombre de la clave sin comillas
+	HashTag string // parte de la clave que determina el slot en Redis Cluster
+	Slot    int    // hash slot de Redis Cluster (0-16383)
+	Index   int    // posición del argumento en cmd.Arguments
+	Span    parser.Span
+}
+
+// HashTag devuelve la parte de la clave que Redis Cluster usa para calcular
+// su slot: el contenido del primer {...} si no está vacío, o la clave
+// completa si no tiene hash tag
+func HashTag(key string) string {
+	start := strings.IndexByte(key, '{')
+	if start < 0 {
+		return key
+	}
+	end := strings.IndexByte(key[start+1:], '}')
+	if end <= 0 {
+		return key
+	}
+	return key[start+1 : start+1+end]
+}
+
+// Keys devuelve las claves de un comando en el orden en que aparecen,
+// localizadas mediante las key specs de su especificación
+func (a *Analyzer) Keys(cmd *parser.RedisCommand) []KeyRef {
+	spec, exists := a.lookupCommand(cmd)
+	if !exists {
+		return nil
+	}
+	args := cmd.Arguments
+	if spec.Container != "" && len(args) > 0 {
+		// Descartar el nombre del subcomando (CLIENT KILL, OBJECT ENCODING...)
+		args = args[1:]
+	}
+	keys := commandKeys(spec, args)
+	if spec.Container != "" {
+		for i := range keys {
+			keys[i].Index++
+		}
+	}
+	return keys
+}
+
+// commandKeys localiza las claves entre los argumentos que siguen al nombre
+// completo del comando. Si la especificación no tiene key specs se usa
+// KeyPosition.
+func commandKeys(spec CommandSpec, args []parser.Expression) []KeyRef {
+	indexes := keyIndexes(spec, args)
+	keys := make([]KeyRef, 0, len(indexes))
+	for i := range indexes {
+		name := keyText(args[i])
+		keys = append(keys, KeyRef{Name: name, HashTag: HashTag(name), Slot: Slot(name), Index: i, Span: args[i].Span()})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Index < keys[j].Index })
+	return keys
+}
+
+// keyIndexes devuelve la posición de cada clave entre los argumentos junto
+// con los flags de las key specs que la localizan (RO, RW, OW, RM...)
+func keyIndexes(spec CommandSpec, args []parser.Expression) map[int][]string {
+	indexes := map[int][]string{}
+	if len(spec.KeySpecs) == 0 {
+		if spec.KeyPosition >= 0 && spec.KeyPosition < len(args) {
+			indexes[spec.KeyPosition] = nil
+		}
+	}
+
+	// Las key specs cuentan posiciones sobre argv, que incluye las palabras
+	// del nombre del comando
+	words := len(strings.Fields(spec.Name))
+	argv := make([]string, words, words+len(args))
+	for _, arg := range args {
+		argv = append(argv, keyText(arg))
+	}
+	for _, ks := range spec.KeySpecs {
+		for _, i := range keySpecIndexes(ks, argv) {
+			indexes[i-words] = append(indexes[i-words], ks.Flags...)
+		}
+	}
+	return indexes
+}
+
+// keySpecIndexes devuelve las posiciones de argv que una key spec marca como
+// claves, siguiendo el mismo algoritmo que Redis (getKeysUsingKeySpecs)
+func keySpecIndexes(ks KeySpec, argv []string) []int {
+	argc := len(argv)
+
+	first := 0
+	switch ks.BeginSearch {
+	case "index":
+		first = ks.Index
+	case "keyword":
+		if ks.StartFrom >= 0 {
+			for i := ks.StartFrom; i < argc-1; i++ {
+				if strings.EqualFold(argv[i], ks.Keyword) {
+					first = i + 1
+					break
+				}
+			}
+		} else {
+			for i := argc + ks.StartFrom; i >= 1 && i < argc; i-- {
+				if strings.EqualFold(argv[i], ks.Keyword) {
+					first = i + 1
+					break
+				}
+			}
+		}
+	}
+	if first <= 0 || first >= argc {
+		return nil
+	}
+
+	step := ks.KeyStep
+	if step <= 0 {
+		step = 1
+	}
+
+	var last int
+	switch ks.FindKeys {
+	case "range":
+		switch {
+		case ks.LastKey >= 0:
+			last = first + ks.LastKey
+		case ks.Limit == 0:
+			last = argc + ks.LastKey
+		default:
+			last = first + (argc-first)/ks.Limit + ks.LastKey
+		}
+	case "keynum":
+		if first+ks.KeyNumIdx >= argc {
+			return nil
+		}
+		numKeys, err := strconv.Atoi(argv[first+ks.KeyNumIdx])
+		if err != nil || numKeys < 0 {
+			return nil
+		}
+		first += ks.FirstKey
+		last = first + numKeys - 1
+	default:
+		return nil
+	}
+
+	indexes := []int{}
+	for i := first; i <= last && i < argc; i += step {
+		indexes = append(indexes, i)
+	}
+	return indexes
+}
+
+// keyText devuelve el texto de un argumento tal como lo recibe Redis
+func keyText(arg parser.Expression) string {
+	switch v := arg.(type) {
+	case *parser.StringLiteral:
+		return v.Value
+	case *parser.IntegerLiteral:
+		return v.Token.Literal
+	case *parser.FloatLiteral:
+		return v.Token.Literal
+	}
+	return arg.String()
+}
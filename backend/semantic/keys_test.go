@@ -0,0 +1,69 @@
+package semantic
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"redis-analyzer-api/parser"
+)
+
+func TestHashTag(t *testing.T) {
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{"user:1", "user:1"},
+		{"{user:1}:profile", "user:1"},
+		{"profile:{user:1}", "user:1"},
+		{"{a}{b}", "a"},
+		{"{}x", "{}x"},
+		{"{user", "{user"},
+		{"a}{b}", "b"},
+		{"x{}{y}", "x{}{y}"},
+	}
+
+	for _, tt := range tests {
+		if got := HashTag(tt.key); got != tt.expected {
+			t.Errorf("HashTag(%q) = %q, expected %q", tt.key, got, tt.expected)
+		}
+	}
+}
+
+func TestCommandKeys(t *testing.T) {
+	analyzer := New()
+
+	tests := []struct {
+		input    string
+		expected []string // clave(hash tag)@posición
+	}{
+		{"GET {user:1}:profile", []string{"{user:1}:profile(user:1)@0"}},
+		{"DEL a {b}c \"{b}d\"", []string{"a(a)@0", "{b}c(b)@1", "{b}d(b)@2"}},
+		{"MSET k1 v1 {t}k2 v2", []string{"k1(k1)@0", "{t}k2(t)@2"}},
+		{"EVAL \"return 1\" 2 k1 k2 arg", []string{"k1(k1)@2", "k2(k2)@3"}},
+		{"ZUNIONSTORE dest 2 a b WEIGHTS 1 2", []string{"dest(dest)@0", "a(a)@2", "b(b)@3"}},
+		{"OBJECT ENCODING {t}k", []string{"{t}k(t)@1"}},
+		{"SCAN 0 MATCH {t}:*", []string{}},
+		{"PING", []string{}},
+	}
+
+	for _, tt := range tests {
+		program, parseErrors := parser.ParseCommands(tt.input)
+		if len(parseErrors) > 0 {
+			t.Fatalf("%q: parse error: %v", tt.input, parseErrors)
+		}
+		cmd := program.Statements[0].(*parser.RedisCommand)
+
+		got := []string{}
+		for _, key := range analyzer.Keys(cmd) {
+			got = append(got, fmt.Sprintf("%s(%s)@%d", key.Name, key.HashTag, key.Index))
+		}
+		if strings.Join(got, " ") != strings.Join(tt.expected, " ") {
+			t.Errorf("%q: expected keys %v, got %v", tt.input, tt.expected, got)
+		}
+
+		result := analyzer.ValidateCommand(cmd)
+		if len(result.Keys) != len(tt.expected) {
+			t.Errorf("%q: expected %d keys in validation result, got %v", tt.input, len(tt.expected), result.Keys)
+		}
+	}
+}
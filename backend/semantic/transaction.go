@@ -0,0 +1,116 @@
+package semantic
+
+import (
+	"fmt"
+	"strings"
+	"redis-analyzer-api/parser"
+)
+
+// ValidateTransaction valida un bloque MULTI/EXEC. Devuelve un resultado por
+// comando del bloque (MULTI, comandos encolados y EXEC/DISCARD) con los
+// errores propios de las transacciones añadidos a la validación normal.
+func (a *Analyzer) ValidateTransaction(block *parser.TransactionBlock) []ValidationResult {
+	results := make([]ValidationResult, 0, len(block.Commands)+2)
+
+	multi := a.ValidateCommand(block.Multi)
+	if block.End == nil {
+		multi.addError(SemanticError{
+			Message: "MULTI without matching EXEC or DISCARD",
+			Command: "MULTI",
+			Type:    "UNBALANCED_TRANSACTION",
+			Span:    block.Multi.Command.Span(),
+		})
+	}
+	results = append(results, multi)
+
+	queued := make([]ValidationResult, 0, len(block.Commands))
+	for _, cmd := range block.Commands {
+		result := a.ValidateCommand(cmd)
+		a.validateQueuedCommand(cmd, &result)
+		queued = append(queued, result)
+	}
+	a.validateTransactionSlots(block.Commands, queued)
+	results = append(results, queued...)
+
+	if block.End != nil {
+		results = append(results, a.ValidateCommand(block.End))
+	}
+
+	return results
+}
+
+// validateQueuedCommand comprueba que un comando pueda encolarse dentro de
+// una transacción
+func (a *Analyzer) validateQueuedCommand(cmd *parser.RedisCommand, result *ValidationResult) {
+	commandName := strings.ToUpper(cmd.Command.Value)
+
+	switch commandName {
+	case "MULTI":
+		result.addError(SemanticError{
+			Message: "MULTI calls can not be nested",
+			Command: commandName,
+			Type:    "NESTED_MULTI",
+			Span:    cmd.Command.Span(),
+		})
+		return
+	case "WATCH":
+		result.addError(SemanticError{
+			Message: "WATCH inside MULTI is not allowed",
+			Command: commandName,
+			Type:    "WATCH_INSIDE_MULTI",
+			Span:    cmd.Command.Span(),
+		})
+		return
+	}
+
+	spec, exists := a.lookupCommand(cmd)
+	if !exists {
+		return
+	}
+
+	if spec.HasFlag("no_multi") {
+		result.addError(SemanticError{
+			Message: fmt.Sprintf("Command %s is not allowed inside a transaction", spec.Name),
+			Command: commandName,
+			Type:    "COMMAND_NOT_ALLOWED_IN_TRANSACTION",
+			Span:    cmd.Command.Span(),
+		})
+	} else if spec.HasFlag("blocking") {
+		result.addWarning("BLOCKING_IN_TRANSACTION", cmd.Command.Span(),
+			fmt.Sprintf("Command %s does not block inside a transaction", spec.Name))
+	}
+}
+
+// validateUnbalancedEnd reporta un EXEC o DISCARD sin MULTI previo
+func (a *Analyzer) validateUnbalancedEnd(cmd *parser.RedisCommand, result *ValidationResult) {
+	commandName := strings.ToUpper(cmd.Command.Value)
+	if commandName != "EXEC" && commandName != "DISCARD" {
+		return
+	}
+
+	result.addError(SemanticError{
+		Message: fmt.Sprintf("%s without MULTI", commandName),
+		Command: commandName,
+		Type:    "UNBALANCED_TRANSACTION",
+		Span:    cmd.Command.Span(),
+	})
+}
+
+// lookupCommand busca la especificación de un comando, resolviendo los
+// subcomandos (CLIENT KILL, CONFIG GET...)
+func (a *Analyzer) lookupCommand(cmd *parser.RedisCommand) (CommandSpec, bool) {
+	commandName := strings.ToUpper(cmd.Command.Value)
+	spec, exists := a.commands[commandName]
+	if !exists {
+		return spec, false
+	}
+
+	if len(spec.Subcommands) > 0 && len(cmd.Arguments) > 0 {
+		subName := commandName + " " + strings.ToUpper(argumentText(cmd.Arguments[0]))
+		if subSpec, ok := a.commands[subName]; ok {
+			return subSpec, true
+		}
+	}
+
+	return spec, true
+}
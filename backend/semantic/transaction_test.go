@@ -0,0 +1,64 @@
+package semantic
+
+import (
+	"testing"
+	"redis-analyzer-api/parser"
+)
+
+func TestValidateTransaction(t *testing.T) {
+	analyzer := New()
+
+	tests := []struct {
+		name          string
+		input         string
+		expectResults int
+		expectError   string
+		expectWarning string
+	}{
+		{name: "Balanced block", input: "WATCH k\nMULTI\nINCR k\nEXEC", expectResults: 4},
+		{name: "Discarded block", input: "MULTI\nSET k v\nDISCARD", expectResults: 3},
+		{name: "MULTI without EXEC", input: "MULTI\nINCR k", expectResults: 2, expectError: "UNBALANCED_TRANSACTION"},
+		{name: "EXEC without MULTI", input: "INCR k\nEXEC", expectResults: 2, expectError: "UNBALANCED_TRANSACTION"},
+		{name: "DISCARD without MULTI", input: "DISCARD", expectResults: 1, expectError: "UNBALANCED_TRANSACTION"},
+		{name: "Nested MULTI", input: "MULTI\nMULTI\nEXEC", expectResults: 3, expectError: "NESTED_MULTI"},
+		{name: "WATCH inside MULTI", input: "MULTI\nWATCH k\nEXEC", expectResults: 3, expectError: "WATCH_INSIDE_MULTI"},
+		{name: "Command not allowed", input: "MULTI\nMONITOR\nEXEC", expectResults: 3, expectError: "COMMAND_NOT_ALLOWED_IN_TRANSACTION"},
+		{name: "Blocking command", input: "MULTI\nBLPOP list 5\nEXEC", expectResults: 3, expectWarning: "BLOCKING_IN_TRANSACTION"},
+		{name: "Invalid queued command", input: "MULTI\nINCR\nEXEC", expectResults: 3, expectError: "INSUFFICIENT_ARGS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			program, parseErrors := parser.ParseCommands(tt.input)
+			if len(parseErrors) > 0 {
+				t.Fatalf("Parse error: %v", parseErrors)
+			}
+
+			results := analyzer.ValidateProgram(program)
+			if len(results) != tt.expectResults {
+				t.Fatalf("Expected %d results, got %d", tt.expectResults, len(results))
+			}
+
+			codes := map[string]parser.Severity{}
+			for _, result := range results {
+				for _, diag := range result.Diagnostics {
+					codes[diag.Code] = diag.Severity
+				}
+			}
+
+			if tt.expectError == "" && tt.expectWarning == "" {
+				for i, result := range results {
+					if !result.Valid || len(result.Warnings) > 0 {
+						t.Errorf("Expected clean result for command %d, got %v %v", i, result.Errors, result.Warnings)
+					}
+				}
+			}
+			if tt.expectError != "" && codes[tt.expectError] != parser.SeverityError {
+				t.Errorf("Expected error %s, got diagnostics %v", tt.expectError, codes)
+			}
+			if tt.expectWarning != "" && codes[tt.expectWarning] != parser.SeverityWarning {
+				t.Errorf("Expected warning %s, got diagnostics %v", tt.expectWarning, codes)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package semantic
+
+import (
+	"fmt"
+	"strings"
+
+	"redis-analyzer-api/parser"
+)
+
+// KeyTypes asocia cada clave con su tipo tal como lo devuelve TYPE (string,
+// list, set, zset, hash, stream). Sirve para que ValidateProgramWithTypes
+// parta del estado real del servidor.
+type KeyTypes map[string]string
+
+// groupTypes es el tipo de las claves que usan los comandos de cada grupo.
+// Los bitmaps y los HyperLogLog son cadenas y los índices geo, sorted sets.
+var groupTypes = map[string]string{
+	"string":      "string",
+	"bitmap":      "string",
+	"hyperloglog": "string",
+	"list":        "list",
+	"set":         "set",
+	"sorted_set":  "zset",
+	"geo":         "zset",
+	"hash":        "hash",
+	"stream":      "stream",
+}
+
+// existingOnly son comandos de escritura que solo modifican claves que ya
+// existen: no crean la clave si no está
+var existingOnly = map[string]bool{
+	"LPUSHX":  true,
+	"RPUSHX":  true,
+	"LINSERT": true,
+	"LSET":    true,
+}
+
+// setOperands son comandos de sorted sets que también aceptan sets como
+// operandos (con score 1)
+var setOperands = map[string]bool{
+	"ZUNION":      true,
+	"ZUNIONSTORE": true,
+	"ZINTER":      true,
+	"ZINTERSTORE": true,
+	"ZINTERCARD":  true,
+	"ZDIFF":       true,
+	"ZDIFFSTORE":  true,
+}
+
+// inferredType es el tipo inferido de una clave y el comando que lo fijó
+type inferredType struct {
+	name    string
+	command string // vacío si el tipo viene del servidor
+	line    int
+}
+
+// describe explica de dónde sale el tipo para los mensajes de error
+func (t inferredType) describe() string {
+	if t.command == "" {
+		return fmt.Sprintf("a %s on the server", t.name)
+	}
+	return fmt.Sprintf("a %s (%s at line %d)", t.name, t.command, t.line)
+}
+
+// typeEnv sigue el tipo de cada clave a lo largo de un programa. Una clave
+// que no está en el mapa tiene tipo desconocido o no existe.
+type typeEnv map[string]inferredType
+
+// newTypeEnv crea el entorno inicial a partir de los tipos del servidor
+func newTypeEnv(types KeyTypes) typeEnv {
+	env := typeEnv{}
+	for key, name := range types {
+		if name != "" && name != "none" {
+			env[key] = inferredType{name: name}
+		}
+	}
+	return env
+}
+
+// set fija el tipo de una clave tras ejecutar cmd
+func (env typeEnv) set(key, name string, cmd *parser.RedisCommand) {
+	env[key] = inferredType{name: name, command: strings.ToUpper(cmd.Command.Value), line: cmd.Span().Start.Line}
+}
+
+// validateKeyTypes comprueba que las claves del comando tengan el tipo que
+// espera su grupo (un GET sobre un hash fallaría con WRONGTYPE) y actualiza
+// el entorno con el efecto del comando. Solo se marca un error cuando el
+// tipo es seguro; si un comando puede dejar la clave vacía (LPOP, HDEL...)
+// su tipo pasa a ser desconocido.
+func (a *Analyzer) validateKeyTypes(cmd *parser.RedisCommand, result *ValidationResult, env typeEnv) {
+	if !result.Valid {
+		// El comando no se ejecutaría: no cambia ninguna clave
+		return
+	}
+
+	commandName := strings.ToUpper(cmd.Command.Value)
+	switch commandName {
+	case "SELECT", "SWAPDB", "FLUSHDB", "FLUSHALL":
+		// Otra base de datos o una base vacía: los tipos anteriores ya no valen
+		for key := range env {
+			delete(env, key)
+		}
+		return
+	}
+
+	spec, exists := a.lookupCommand(cmd)
+	if !exists || len(result.Keys) == 0 {
+		return
+	}
+	args := cmd.Arguments
+	offset := 0
+	if spec.Container != "" {
+		args, offset = args[1:], 1
+	}
+	flags := keyIndexes(spec, args)
+	keyFlags := func(key KeyRef) []string { return flags[key.Index-offset] }
+
+	expected, typed := groupTypes[spec.Group]
+	if !typed {
+		updateGenericKeyTypes(commandName, spec, result.Keys, keyFlags, cmd, env)
+		return
+	}
+
+	overwrite, conditional := stringWriteMode(commandName, cmd)
+	for _, key := range result.Keys {
+		current, known := env[key.Name]
+		if !known || current.name == expected || overwrite || conditional || hasKeyFlag(keyFlags(key), "OW") {
+			continue
+		}
+		if current.name == "set" && setOperands[commandName] {
+			continue
+		}
+		result.addError(SemanticError{
+			Message: fmt.Sprintf("Key '%s' holds %s, but %s operates on a %s", key.Name, current.describe(), spec.Name, expected),
+			Command: commandName,
+			Type:    "WRONGTYPE",
+			Span:    key.Span,
+		})
+	}
+	if !result.Valid || conditional {
+		return
+	}
+
+	// Los comandos que reservan memoria (denyoom) crean la clave si no
+	// existe, salvo los que además escriben en una clave de destino (LMOVE,
+	// SINTERSTORE...), cuyas claves de origen pueden quedar vacías
+	creates := spec.HasFlag("denyoom") && !existingOnly[commandName]
+	for _, key := range result.Keys {
+		if hasKeyFlag(keyFlags(key), "OW") {
+			creates = false
+		}
+	}
+	for _, key := range result.Keys {
+		switch f := keyFlags(key); {
+		case overwrite || hasKeyFlag(f, "OW"):
+			env.set(key.Name, expected, cmd)
+		case hasKeyFlag(f, "RO"):
+		case creates:
+			env.set(key.Name, expected, cmd)
+		default:
+			delete(env, key.Name)
+		}
+	}
+}
+
+// stringWriteMode indica si el comando escribe una cadena sin comprobar el
+// tipo anterior de la clave: SET, SETEX, PSETEX y MSET la sobrescriben
+// (salvo SET ... GET, que devuelve el valor anterior) y SETNX, MSETNX y
+// SET ... NX no hacen nada si ya existe
+func stringWriteMode(commandName string, cmd *parser.RedisCommand) (overwrite, conditional bool) {
+	switch commandName {
+	case "SETEX", "PSETEX", "MSET":
+		return true, false
+	case "SETNX", "MSETNX":
+		return false, true
+	case "SET":
+		overwrite = true
+		for i := 2; i < len(cmd.Arguments); i++ {
+			switch strings.ToUpper(argumentText(cmd.Arguments[i])) {
+			case "GET":
+				overwrite = false
+			case "NX":
+				conditional = true
+			}
+		}
+		return overwrite && !conditional, conditional
+	}
+	return false, false
+}
+
+// updateGenericKeyTypes aplica el efecto de los comandos que no dependen
+// del tipo de la clave (DEL, RENAME, COPY, EXPIRE...). Los comandos de
+// otros grupos con claves (EVAL, FCALL...) pueden hacer cualquier cosa con
+// ellas, así que su tipo pasa a ser desconocido.
+func updateGenericKeyTypes(commandName string, spec CommandSpec, keys []KeyRef, keyFlags func(KeyRef) []string, cmd *parser.RedisCommand, env typeEnv) {
+	switch {
+	case (commandName == "RENAME" || commandName == "RENAMENX" || commandName == "COPY") && len(keys) == 2:
+		source, destination := keys[0].Name, keys[1].Name
+		if source == destination {
+			return
+		}
+		if current, known := env[source]; known {
+			env.set(destination, current.name, cmd)
+		} else {
+			delete(env, destination)
+		}
+		if commandName != "COPY" {
+			delete(env, source)
+		}
+	case commandName == "SORT":
+		// SORT ... STORE guarda el resultado como lista
+		for _, key := range keys {
+			if hasKeyFlag(keyFlags(key), "OW") {
+				env.set(key.Name, "list", cmd)
+			}
+		}
+	case spec.Group == "generic" && commandName != "MOVE" && commandName != "RESTORE" && commandName != "MIGRATE":
+		for _, key := range keys {
+			if hasKeyFlag(keyFlags(key), "DELETE") {
+				delete(env, key.Name)
+			}
+		}
+	default:
+		for _, key := range keys {
+			delete(env, key.Name)
+		}
+	}
+}
+
+// hasKeyFlag indica si los flags de una key spec incluyen flag
+func hasKeyFlag(flags []string, flag string) bool {
+	for _, f := range flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
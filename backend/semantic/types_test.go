@@ -0,0 +1,89 @@
+package semantic
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"redis-analyzer-api/parser"
+)
+
+// wrongTypeLines devuelve las líneas con errores WRONGTYPE del programa
+func wrongTypeLines(t *testing.T, a *Analyzer, input string, types KeyTypes) []int {
+	t.Helper()
+	program, diagnostics := parser.ParseCommandsWithDiagnostics(input)
+	if len(diagnostics) != 0 {
+		t.Fatalf("unexpected parse diagnostics: %v", diagnostics)
+	}
+
+	lines := []int{}
+	for _, result := range a.ValidateProgramWithTypes(program, types) {
+		for _, err := range result.Errors {
+			if err.Type == "WRONGTYPE" {
+				lines = append(lines, err.Span.Start.Line)
+			}
+		}
+	}
+	return lines
+}
+
+func TestKeyTypes(t *testing.T) {
+	a := New()
+
+	tests := []struct {
+		name    string
+		program []string
+		lines   []int
+	}{
+		{name: "hash then string", program: []string{"HSET user:1 f v", "GET user:1", "ZADD user:1 1 a"}, lines: []int{2, 3}},
+		{name: "same type", program: []string{"HSET h f v", "HGET h f", "HINCRBY h n 1"}},
+		{name: "del clears", program: []string{"HSET k f v", "DEL k", "GET k"}},
+		{name: "set overwrites", program: []string{"HSET k f v", "SET k x", "GET k", "HGET k f"}, lines: []int{4}},
+		{name: "set get checks", program: []string{"HSET k f v", "SET k x GET"}, lines: []int{2}},
+		{name: "setnx keeps", program: []string{"HSET k f v", "SETNX k x", "HGET k f"}},
+		{name: "geo is zset", program: []string{"GEOADD g 1 2 a", "ZSCORE g a", "LPUSH g x"}, lines: []int{3}},
+		{name: "bitmap is string", program: []string{"SETBIT b 1 1", "GET b", "PFADD b x"}},
+		{name: "pop may empty", program: []string{"RPUSH l a", "LPOP l", "SADD l x"}},
+		{name: "read does not create", program: []string{"GET k", "HSET k f v"}},
+		{name: "rename moves", program: []string{"SADD s a", "RENAME s t", "GET s", "GET t"}, lines: []int{4}},
+		{name: "copy keeps source", program: []string{"SADD s a", "COPY s t", "GET s", "GET t"}, lines: []int{3, 4}},
+		{name: "store destination", program: []string{"SET d x", "SINTERSTORE d a b", "SMEMBERS d", "GET d"}, lines: []int{4}},
+		{name: "store source", program: []string{"SET a x", "SINTERSTORE d a b"}, lines: []int{2}},
+		{name: "zset operands", program: []string{"SADD s a", "ZUNIONSTORE d 1 s", "ZRANGE d 0 -1"}},
+		{name: "sort store", program: []string{"SORT src STORE dst", "LLEN dst", "GET dst"}, lines: []int{3}},
+		{name: "select resets", program: []string{"HSET k f v", "SELECT 1", "GET k"}},
+		{name: "subcommand", program: []string{"SET s x", "XGROUP CREATE s g $"}, lines: []int{2}},
+		{name: "transaction", program: []string{"HSET k f v", "MULTI", "INCR k", "EXEC"}, lines: []int{3}},
+		{name: "discarded", program: []string{"MULTI", "DEL k", "DISCARD", "HSET k f v", "GET k"}, lines: []int{5}},
+		{name: "invalid command", program: []string{"HSET k f", "GET k"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := wrongTypeLines(t, a, strings.Join(tt.program, "\n"), nil)
+			if fmt.Sprint(lines) != fmt.Sprint(append([]int{}, tt.lines...)) {
+				t.Errorf("Expected WRONGTYPE on lines %v, got %v", tt.lines, lines)
+			}
+		})
+	}
+}
+
+func TestKeyTypesFromServer(t *testing.T) {
+	a := New()
+	types := KeyTypes{"cart": "hash", "gone": "none"}
+
+	if lines := wrongTypeLines(t, a, "GET cart\nLPUSH gone x\nHGETALL cart", types); fmt.Sprint(lines) != "[1]" {
+		t.Errorf("Expected WRONGTYPE on line 1, got %v", lines)
+	}
+
+	program, _ := parser.ParseCommandsWithDiagnostics("SET a x\nLLEN a")
+	results := a.ValidateProgram(program)
+	if results[1].Valid || results[1].Errors[0].Message != "Key 'a' holds a string (SET at line 1), but LLEN operates on a list" {
+		t.Errorf("Unexpected result: %+v", results[1].Errors)
+	}
+	program, _ = parser.ParseCommandsWithDiagnostics("LLEN cart")
+	results = a.ValidateProgramWithTypes(program, types)
+	if results[0].Valid || !strings.Contains(results[0].Errors[0].Message, "a hash on the server") {
+		t.Errorf("Unexpected result: %+v", results[0].Errors)
+	}
+}